@@ -3,7 +3,6 @@ package main
 import (
 	"context"
 	"encoding/json"
-	"net/http"
 
 	"github.com/mark3labs/mcp-go/mcp"
 )
@@ -21,12 +20,14 @@ type abilityScoreListAPIResponse struct {
 }
 
 // abilityScoreDetail defines the structure for a detailed ability score response.
+// Description takes the place of Desc in the 2024 ruleset.
 type abilityScoreDetail struct {
-	Index    string   `json:"index"`
-	Name     string   `json:"name"`
-	FullName string   `json:"full_name"`
-	Desc     []string `json:"desc"`
-	Skills   []struct {
+	Index       string     `json:"index"`
+	Name        string     `json:"name"`
+	FullName    string     `json:"full_name"`
+	Desc        paragraphs `json:"desc,omitempty"`
+	Description string     `json:"description,omitempty"`
+	Skills      []struct {
 		Index string `json:"index"`
 		Name  string `json:"name"`
 		URL   string `json:"url"`
@@ -43,12 +44,12 @@ type abilityScoreToolOutput struct {
 
 // fetchAbilityScoreByNameResult fetches an ability score by index and returns an MCP tool result.
 func fetchAbilityScoreByNameResult(
-	client *http.Client,
+	ctx context.Context,
+	src dataSource,
 	input abilityScoreToolInput,
-	fetchByName func(*http.Client, endpoint, string, any) error,
 ) (*mcp.CallToolResult, error) {
 	abilityScore := &abilityScoreDetail{}
-	err := fetchByName(client, abilityScores, input.Name, abilityScore)
+	err := fetchByName(ctx, src, abilityScores, input.Name, abilityScore)
 	if err != nil {
 		return mcp.NewToolResultErrorFromErr("failed to fetch ability score", err), err
 	}
@@ -62,11 +63,11 @@ func fetchAbilityScoreByNameResult(
 
 // fetchAbilityScoreListResult fetches a list of ability scores and returns an MCP tool result.
 func fetchAbilityScoreListResult(
-	client *http.Client,
-	fetchList func(*http.Client, endpoint, any, string) error,
+	ctx context.Context,
+	src dataSource,
 ) (*mcp.CallToolResult, error) {
 	var results []abilityScoreListAPIResponse
-	err := fetchList(client, abilityScores, &results, "")
+	err := fetchList(ctx, src, abilityScores, &results, "")
 	if err != nil {
 		return mcp.NewToolResultErrorFromErr("Failed to fetch ability score list", err), err
 	}
@@ -79,19 +80,16 @@ func fetchAbilityScoreListResult(
 }
 
 // runAbilityScoreTool executes the core logic for the ability-scores tool.
-func runAbilityScoreTool(
-	input abilityScoreToolInput,
-	fetchByName func(*http.Client, endpoint, string, any) error,
-	fetchList func(*http.Client, endpoint, any, string) error,
-) (*mcp.CallToolResult, error) {
-	client := http.DefaultClient
+func runAbilityScoreTool(ctx context.Context, src dataSource, input abilityScoreToolInput) (*mcp.CallToolResult, error) {
 	if input.Name != "" {
-		return fetchAbilityScoreByNameResult(client, input, fetchByName)
+		return fetchAbilityScoreByNameResult(ctx, src, input)
 	}
-	return fetchAbilityScoreListResult(client, fetchList)
+	return fetchAbilityScoreListResult(ctx, src)
 }
 
-// handleAbilityScoreTool is the MCP handler for the ability-scores tool.
-func handleAbilityScoreTool(ctx context.Context, req mcp.CallToolRequest, input abilityScoreToolInput) (*mcp.CallToolResult, error) {
-	return runAbilityScoreTool(input, fetchByName, fetchList)
+// handleAbilityScoreTool returns the MCP handler for the ability-scores tool.
+func handleAbilityScoreTool(src dataSource) mcp.TypedToolHandlerFunc[abilityScoreToolInput] {
+	return func(ctx context.Context, req mcp.CallToolRequest, input abilityScoreToolInput) (*mcp.CallToolResult, error) {
+		return runAbilityScoreTool(ctx, src, input)
+	}
 }
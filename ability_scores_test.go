@@ -1,9 +1,9 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
-	"net/http"
 	"strings"
 	"testing"
 
@@ -28,8 +28,8 @@ func TestRunAbilityScoreTool(t *testing.T) {
 	cases := []struct {
 		name       string
 		input      abilityScoreToolInput
-		mockByName func(*http.Client, endpoint, string, any) error
-		mockList   func(*http.Client, endpoint, any, string) error
+		mockByName func(context.Context, endpoint, string, any) error
+		mockList   func(context.Context, endpoint, string, any) error
 		wantOutput abilityScoreToolOutput
 		wantErr    bool
 		wantErrMsg string
@@ -37,7 +37,7 @@ func TestRunAbilityScoreTool(t *testing.T) {
 		{
 			name:  "by name",
 			input: abilityScoreToolInput{Name: "str"},
-			mockByName: func(_ *http.Client, _ endpoint, name string, v any) error {
+			mockByName: func(_ context.Context, _ endpoint, name string, v any) error {
 				ptr, ok := v.(*abilityScoreDetail)
 				if !ok {
 					return errors.New("wrong type")
@@ -45,7 +45,7 @@ func TestRunAbilityScoreTool(t *testing.T) {
 				*ptr = abilityScore
 				return nil
 			},
-			mockList:   func(_ *http.Client, _ endpoint, v any, _ string) error { return nil },
+			mockList:   func(_ context.Context, _ endpoint, _ string, v any) error { return nil },
 			wantOutput: abilityScoreToolOutput{AbilityScore: &abilityScore},
 			wantErr:    false,
 			wantErrMsg: "",
@@ -53,8 +53,8 @@ func TestRunAbilityScoreTool(t *testing.T) {
 		{
 			name:       "list",
 			input:      abilityScoreToolInput{},
-			mockByName: func(_ *http.Client, _ endpoint, name string, v any) error { return nil },
-			mockList: func(_ *http.Client, _ endpoint, v any, _ string) error {
+			mockByName: func(_ context.Context, _ endpoint, name string, v any) error { return nil },
+			mockList: func(_ context.Context, _ endpoint, _ string, v any) error {
 				ptr, ok := v.(*[]abilityScoreListAPIResponse)
 				if !ok {
 					return errors.New("wrong type")
@@ -69,10 +69,10 @@ func TestRunAbilityScoreTool(t *testing.T) {
 		{
 			name:  "fetchByName error",
 			input: abilityScoreToolInput{Name: "fail"},
-			mockByName: func(_ *http.Client, _ endpoint, name string, v any) error {
+			mockByName: func(_ context.Context, _ endpoint, name string, v any) error {
 				return errors.New("fetchByName failed")
 			},
-			mockList:   func(_ *http.Client, _ endpoint, v any, _ string) error { return nil },
+			mockList:   func(_ context.Context, _ endpoint, _ string, v any) error { return nil },
 			wantOutput: abilityScoreToolOutput{},
 			wantErr:    true,
 			wantErrMsg: "fetchByName failed",
@@ -80,8 +80,8 @@ func TestRunAbilityScoreTool(t *testing.T) {
 		{
 			name:       "fetchList error",
 			input:      abilityScoreToolInput{},
-			mockByName: func(_ *http.Client, _ endpoint, name string, v any) error { return nil },
-			mockList: func(_ *http.Client, _ endpoint, v any, _ string) error {
+			mockByName: func(_ context.Context, _ endpoint, name string, v any) error { return nil },
+			mockList: func(_ context.Context, _ endpoint, _ string, v any) error {
 				return errors.New("fetchList failed")
 			},
 			wantOutput: abilityScoreToolOutput{},
@@ -92,7 +92,7 @@ func TestRunAbilityScoreTool(t *testing.T) {
 
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			res, err := runAbilityScoreTool(tc.input, tc.mockByName, tc.mockList)
+			res, err := runAbilityScoreTool(context.Background(), &mockDataSource{get: tc.mockByName, list: tc.mockList}, tc.input)
 			if tc.wantErr {
 				if err == nil {
 					t.Errorf("expected Go error, got nil")
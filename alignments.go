@@ -3,7 +3,6 @@ package main
 import (
 	"context"
 	"encoding/json"
-	"net/http"
 
 	"github.com/mark3labs/mcp-go/mcp"
 )
@@ -21,11 +20,13 @@ type alignmentListAPIResponse struct {
 }
 
 // alignmentDetail defines the structure for a detailed alignment response.
+// Only one of Desc (2014) and Description (2024) is set.
 type alignmentDetail struct {
-	Index string `json:"index"`
-	Name  string `json:"name"`
-	Desc  string `json:"desc"`
-	URL   string `json:"url"`
+	Index       string `json:"index"`
+	Name        string `json:"name"`
+	Desc        string `json:"desc,omitempty"`
+	Description string `json:"description,omitempty"`
+	URL         string `json:"url"`
 }
 
 // alignmentToolOutput defines the output structure for the alignments tool.
@@ -37,12 +38,12 @@ type alignmentToolOutput struct {
 
 // fetchAlignmentByNameResult fetches an alignment by index and returns an MCP tool result.
 func fetchAlignmentByNameResult(
-	client *http.Client,
+	ctx context.Context,
+	src dataSource,
 	input alignmentToolInput,
-	fetchByName func(*http.Client, endpoint, string, any) error,
 ) (*mcp.CallToolResult, error) {
 	alignment := &alignmentDetail{}
-	err := fetchByName(client, alignments, input.Name, alignment)
+	err := fetchByName(ctx, src, alignments, input.Name, alignment)
 	if err != nil {
 		return mcp.NewToolResultErrorFromErr("failed to fetch alignment", err), err
 	}
@@ -56,11 +57,11 @@ func fetchAlignmentByNameResult(
 
 // fetchAlignmentListResult fetches a list of alignments and returns an MCP tool result.
 func fetchAlignmentListResult(
-	client *http.Client,
-	fetchList func(*http.Client, endpoint, any, string) error,
+	ctx context.Context,
+	src dataSource,
 ) (*mcp.CallToolResult, error) {
 	var results []alignmentListAPIResponse
-	err := fetchList(client, alignments, &results, "")
+	err := fetchList(ctx, src, alignments, &results, "")
 	if err != nil {
 		return mcp.NewToolResultErrorFromErr("Failed to fetch alignment list", err), err
 	}
@@ -73,19 +74,16 @@ func fetchAlignmentListResult(
 }
 
 // runAlignmentTool executes the core logic for the alignments tool.
-func runAlignmentTool(
-	input alignmentToolInput,
-	fetchByName func(*http.Client, endpoint, string, any) error,
-	fetchList func(*http.Client, endpoint, any, string) error,
-) (*mcp.CallToolResult, error) {
-	client := http.DefaultClient
+func runAlignmentTool(ctx context.Context, src dataSource, input alignmentToolInput) (*mcp.CallToolResult, error) {
 	if input.Name != "" {
-		return fetchAlignmentByNameResult(client, input, fetchByName)
+		return fetchAlignmentByNameResult(ctx, src, input)
 	}
-	return fetchAlignmentListResult(client, fetchList)
+	return fetchAlignmentListResult(ctx, src)
 }
 
-// handleAlignmentTool is the MCP handler for the alignments tool.
-func handleAlignmentTool(ctx context.Context, req mcp.CallToolRequest, input alignmentToolInput) (*mcp.CallToolResult, error) {
-	return runAlignmentTool(input, fetchByName, fetchList)
+// handleAlignmentTool returns the MCP handler for the alignments tool.
+func handleAlignmentTool(src dataSource) mcp.TypedToolHandlerFunc[alignmentToolInput] {
+	return func(ctx context.Context, req mcp.CallToolRequest, input alignmentToolInput) (*mcp.CallToolResult, error) {
+		return runAlignmentTool(ctx, src, input)
+	}
 }
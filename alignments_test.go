@@ -1,9 +1,9 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
-	"net/http"
 	"strings"
 	"testing"
 
@@ -22,8 +22,8 @@ func TestRunAlignmentTool(t *testing.T) {
 	cases := []struct {
 		name       string
 		input      alignmentToolInput
-		mockByName func(*http.Client, endpoint, string, any) error
-		mockList   func(*http.Client, endpoint, any, string) error
+		mockByName func(context.Context, endpoint, string, any) error
+		mockList   func(context.Context, endpoint, string, any) error
 		wantOutput alignmentToolOutput
 		wantErr    bool
 		wantErrMsg string
@@ -31,7 +31,7 @@ func TestRunAlignmentTool(t *testing.T) {
 		{
 			name:  "by name",
 			input: alignmentToolInput{Name: "chaotic-good"},
-			mockByName: func(_ *http.Client, _ endpoint, name string, v any) error {
+			mockByName: func(_ context.Context, _ endpoint, name string, v any) error {
 				ptr, ok := v.(*alignmentDetail)
 				if !ok {
 					return errors.New("wrong type")
@@ -39,7 +39,7 @@ func TestRunAlignmentTool(t *testing.T) {
 				*ptr = alignment
 				return nil
 			},
-			mockList:   func(_ *http.Client, _ endpoint, v any, _ string) error { return nil },
+			mockList:   func(_ context.Context, _ endpoint, _ string, v any) error { return nil },
 			wantOutput: alignmentToolOutput{Alignment: &alignment},
 			wantErr:    false,
 			wantErrMsg: "",
@@ -47,8 +47,8 @@ func TestRunAlignmentTool(t *testing.T) {
 		{
 			name:       "list",
 			input:      alignmentToolInput{},
-			mockByName: func(_ *http.Client, _ endpoint, name string, v any) error { return nil },
-			mockList: func(_ *http.Client, _ endpoint, v any, _ string) error {
+			mockByName: func(_ context.Context, _ endpoint, name string, v any) error { return nil },
+			mockList: func(_ context.Context, _ endpoint, _ string, v any) error {
 				ptr, ok := v.(*[]alignmentListAPIResponse)
 				if !ok {
 					return errors.New("wrong type")
@@ -63,10 +63,10 @@ func TestRunAlignmentTool(t *testing.T) {
 		{
 			name:  "fetchByName error",
 			input: alignmentToolInput{Name: "fail"},
-			mockByName: func(_ *http.Client, _ endpoint, name string, v any) error {
+			mockByName: func(_ context.Context, _ endpoint, name string, v any) error {
 				return errors.New("fetchByName failed")
 			},
-			mockList:   func(_ *http.Client, _ endpoint, v any, _ string) error { return nil },
+			mockList:   func(_ context.Context, _ endpoint, _ string, v any) error { return nil },
 			wantOutput: alignmentToolOutput{},
 			wantErr:    true,
 			wantErrMsg: "fetchByName failed",
@@ -74,8 +74,8 @@ func TestRunAlignmentTool(t *testing.T) {
 		{
 			name:       "fetchList error",
 			input:      alignmentToolInput{},
-			mockByName: func(_ *http.Client, _ endpoint, name string, v any) error { return nil },
-			mockList: func(_ *http.Client, _ endpoint, v any, _ string) error {
+			mockByName: func(_ context.Context, _ endpoint, name string, v any) error { return nil },
+			mockList: func(_ context.Context, _ endpoint, _ string, v any) error {
 				return errors.New("fetchList failed")
 			},
 			wantOutput: alignmentToolOutput{},
@@ -86,7 +86,7 @@ func TestRunAlignmentTool(t *testing.T) {
 
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			res, err := runAlignmentTool(tc.input, tc.mockByName, tc.mockList)
+			res, err := runAlignmentTool(context.Background(), &mockDataSource{get: tc.mockByName, list: tc.mockList}, tc.input)
 			if tc.wantErr {
 				if err == nil {
 					t.Errorf("expected Go error, got nil")
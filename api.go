@@ -1,11 +1,15 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 )
@@ -14,8 +18,11 @@ import (
 type endpoint string
 
 const (
-	apiBaseURL            = "https://www.dnd5eapi.co/api"
-	requestTimeoutSeconds = 10
+	// defaultAPIBaseURL is the root of the API unless configured otherwise; each request appends the
+	// selected ruleset, e.g. /api/2014.
+	defaultAPIBaseURL = "https://www.dnd5eapi.co/api"
+	// defaultRequestTimeout is how long a single API request may take unless configured otherwise.
+	defaultRequestTimeout = 10 * time.Second
 
 	abilityScores       endpoint = "ability-scores"
 	alignments          endpoint = "alignments"
@@ -43,74 +50,160 @@ const (
 	weaponProperties    endpoint = "weapon-properties"
 )
 
+// allEndpoints lists every endpoint exposed by the D&D 5e API.
+var allEndpoints = []endpoint{
+	abilityScores,
+	alignments,
+	backgrounds,
+	classes,
+	conditions,
+	damageTypes,
+	equipment,
+	equipmentCategories,
+	feats,
+	features,
+	languages,
+	magicItems,
+	magicSchools,
+	monsters,
+	proficiencies,
+	races,
+	ruleSections,
+	rules,
+	skills,
+	spells,
+	subclasses,
+	subraces,
+	traits,
+	weaponProperties,
+}
+
+// apiReference is a link to another API resource, as embedded in most API responses.
+// Source is only set on references to homebrew entries.
+type apiReference struct {
+	Index  string `json:"index"`
+	Name   string `json:"name"`
+	URL    string `json:"url"`
+	Source string `json:"source,omitempty"`
+}
+
+// paragraphs is a description made of one or more paragraphs. The 2014 ruleset returns most
+// descriptions as a list of paragraphs, while the 2024 ruleset often returns a single string;
+// both decode to a list.
+type paragraphs []string
+
+// UnmarshalJSON decodes either a list of paragraphs or a single string.
+func (p *paragraphs) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*p = paragraphs{text}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*p = list
+	return nil
+}
+
 // listResponse defines the structure of the response for a list endpoint.
 type listResponse struct {
-	Count   int                      `json:"count"`
-	Results []map[string]interface{} `json:"results"`
+	Count   int             `json:"count"`
+	Results json.RawMessage `json:"results"`
 }
 
-// fetchAPIItem fetches a single item by endpoint and item from the D&D 5e API.
-func fetchAPIItem(client *http.Client, endpoint endpoint, item string) (map[string]interface{}, error) {
-	resp, err := client.Get(fmt.Sprintf("%s/%s/%s", apiBaseURL, endpoint, url.PathEscape(item)))
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("API request failed with status %d", resp.StatusCode)
+// errUpstreamStatus is returned when the API answers with an unexpected status.
+var errUpstreamStatus = errors.New("API request failed")
+
+// apiDataSource is a dataSource backed by the D&D 5e API over HTTP.
+type apiDataSource struct {
+	client  *http.Client
+	baseURL string
+	cache   *responseCache
+	timeout time.Duration
+}
+
+// apiOption configures an apiDataSource.
+type apiOption func(*apiDataSource)
+
+// withCache makes the apiDataSource serve responses from c and revalidate stale entries with the API.
+func withCache(c *responseCache) apiOption {
+	return func(s *apiDataSource) {
+		s.cache = c
 	}
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+}
+
+// withRequestTimeout bounds each API request to d, in addition to any deadline on the caller's context.
+// A zero duration disables the per-request timeout.
+func withRequestTimeout(d time.Duration) apiOption {
+	return func(s *apiDataSource) {
+		s.timeout = d
 	}
-	var data map[string]interface{}
-	if err := json.Unmarshal(body, &data); err != nil {
-		return nil, err
+}
+
+// newAPIDataSource creates an apiDataSource that sends requests to baseURL using the given client.
+// Requests time out after defaultRequestTimeout unless overridden with withRequestTimeout.
+func newAPIDataSource(client *http.Client, baseURL string, opts ...apiOption) *apiDataSource {
+	s := &apiDataSource{client: client, baseURL: baseURL, timeout: defaultRequestTimeout}
+	for _, opt := range opts {
+		opt(s)
 	}
-	return data, nil
+	return s
 }
 
-// fetchByName fetches an item by name from the D&D 5e API and unmarshals it into the provided variable.
-func fetchByName(client *http.Client, e endpoint, name string, v any) error {
-	logrus.WithFields(logrus.Fields{"endpoint": e, "name": name}).Debug("fetchByName called")
-	index := toKebabCase(name)
-	data, err := fetchAPIItem(client, e, index)
+// Get fetches a single item by endpoint and index and unmarshals it into v.
+func (s *apiDataSource) Get(ctx context.Context, e endpoint, index string, v any) error {
+	body, err := s.fetchAPIItem(ctx, e, index)
 	if err != nil {
-		logrus.WithError(err).Error("fetchAPIItem failed in fetchByName")
 		return err
 	}
-	b, err := json.Marshal(data)
+	return json.Unmarshal(body, v)
+}
+
+// List fetches the items of an endpoint, narrowed by an optional query-string filter, and unmarshals them into v.
+func (s *apiDataSource) List(ctx context.Context, e endpoint, filter string, v any) error {
+	data, err := s.fetchAPIList(ctx, e, filter)
 	if err != nil {
-		logrus.WithError(err).Error("Marshal failed in fetchByName")
 		return err
 	}
-	if err := json.Unmarshal(b, v); err != nil {
-		logrus.WithError(err).Error("Unmarshal failed in fetchByName")
-		return err
+	if len(data.Results) == 0 {
+		return nil
 	}
-	logrus.WithField("name", name).Debug("fetchByName succeeded")
-	return nil
+	return json.Unmarshal(data.Results, v)
+}
+
+// Search fetches the items of an endpoint whose name matches query using the API's name filter.
+func (s *apiDataSource) Search(ctx context.Context, e endpoint, query string, v any) error {
+	return s.List(ctx, e, "name="+url.QueryEscape(query), v)
+}
+
+// endpointURL returns the URL of endpoint e in the ruleset selected by ctx, e.g. ".../api/2014/spells".
+func (s *apiDataSource) endpointURL(ctx context.Context, e endpoint) string {
+	r := rulesetFrom(ctx)
+	return fmt.Sprintf("%s/%s/%s", s.baseURL, r, r.path(e))
+}
+
+// fetchAPIItem fetches the raw body of a single item by endpoint and index from the D&D 5e API.
+// The index may name a sub-resource, such as "paladin/levels"; each path segment is escaped separately.
+func (s *apiDataSource) fetchAPIItem(ctx context.Context, e endpoint, index string) ([]byte, error) {
+	segments := strings.Split(index, "/")
+	for i, seg := range segments {
+		segments[i] = url.PathEscape(seg)
+	}
+	return s.get(ctx, s.endpointURL(ctx, e)+"/"+strings.Join(segments, "/"))
 }
 
 // fetchAPIList fetches a list of items for the given endpoint from the D&D 5e API.
-func fetchAPIList(client *http.Client, endpoint endpoint, filter string) (listResponse, error) {
-	url := fmt.Sprintf("%s/%s", apiBaseURL, endpoint)
+func (s *apiDataSource) fetchAPIList(ctx context.Context, e endpoint, filter string) (listResponse, error) {
+	u := s.endpointURL(ctx, e)
 	if filter != "" {
-		url = fmt.Sprintf("%s?%s", url, filter)
+		u = fmt.Sprintf("%s?%s", u, filter)
 	}
-	resp, err := client.Get(url)
+	body, err := s.get(ctx, u)
 	if err != nil {
 		return listResponse{}, err
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != 200 {
-		return listResponse{}, fmt.Errorf("API request failed with status %d", resp.StatusCode)
-	}
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return listResponse{}, err
-	}
-
 	var data listResponse
 	if err := json.Unmarshal(body, &data); err != nil {
 		return listResponse{}, err
@@ -118,23 +211,79 @@ func fetchAPIList(client *http.Client, endpoint endpoint, filter string) (listRe
 	return data, nil
 }
 
-// fetchList fetches a list of items from the D&D 5e API and unmarshals it into the provided variable.
-func fetchList(client *http.Client, e endpoint, v any, filter string) error {
-	logrus.WithFields(logrus.Fields{"endpoint": e, "filter": filter}).Debug("fetchList called")
-	spells, err := fetchAPIList(client, e, filter)
+// get performs a GET request against the API and returns the response body.
+// When a cache is configured, fresh entries are returned without a request and stale
+// entries are revalidated with If-None-Match/If-Modified-Since. The cache key is the
+// request URL, which encodes the endpoint, index and filter.
+// The request is bound to ctx, so cancelling the tool call aborts it.
+// A 404 response is reported as errNotFound.
+func (s *apiDataSource) get(ctx context.Context, u string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	var stale *cacheEntry
+	if s.cache != nil {
+		if entry, ok := s.cache.get(ctx, u); ok {
+			if s.cache.fresh(entry) {
+				s.cache.hits.Add(1)
+				s.cache.logStats(ctx, logrus.DebugLevel, "Cache hit")
+				return entry.Body, nil
+			}
+			stale = entry
+		}
+		s.cache.misses.Add(1)
+		s.cache.logStats(ctx, logrus.DebugLevel, "Cache miss")
+	}
+
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+	logFrom(ctx).WithField("url", u).Debug("API request")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
 	if err != nil {
-		logrus.WithError(err).Error("fetchAPIList failed in fetchList")
-		return err
+		return nil, err
+	}
+	if stale != nil {
+		if stale.ETag != "" {
+			req.Header.Set("If-None-Match", stale.ETag)
+		}
+		if stale.LastModified != "" {
+			req.Header.Set("If-Modified-Since", stale.LastModified)
+		}
 	}
-	jsonData, err := json.Marshal(spells.Results)
+	resp, err := s.client.Do(req)
 	if err != nil {
-		logrus.WithError(err).Error("Marshal failed in fetchList")
-		return err
+		return nil, err
 	}
-	if err := json.Unmarshal(jsonData, v); err != nil {
-		logrus.WithError(err).Error("Unmarshal failed in fetchList")
-		return err
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified && stale != nil {
+		s.cache.revalidations.Add(1)
+		revalidated := *stale
+		revalidated.StoredAt = time.Now()
+		s.cache.put(ctx, &revalidated)
+		logFrom(ctx).WithField("url", u).Debug("Cache entry revalidated")
+		return stale.Body, nil
 	}
-	logrus.Debug("fetchList succeeded")
-	return nil
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %s", errNotFound, u)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w with status %d", errUpstreamStatus, resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if s.cache != nil {
+		s.cache.put(ctx, &cacheEntry{
+			Key:          u,
+			Body:         body,
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+			StoredAt:     time.Now(),
+		})
+	}
+	return body, nil
 }
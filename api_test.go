@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestAPIServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	spellData, err := os.ReadFile("testdata/spell_by_name.json")
+	if err != nil {
+		t.Fatalf("failed to read spell_by_name.json: %v", err)
+	}
+	listData, err := os.ReadFile("testdata/spell_list.json")
+	if err != nil {
+		t.Fatalf("failed to read spell_list.json: %v", err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/2014/spells/fireball", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(spellData)
+	})
+	mux.HandleFunc("/2014/spells", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.RawQuery != "" && r.URL.Query().Get("level") != "3" && r.URL.Query().Get("name") != "fire" {
+			w.Write([]byte(`{"count":0,"results":[]}`))
+			return
+		}
+		w.Write([]byte(`{"count":2,"results":` + string(listData) + `}`))
+	})
+	mux.HandleFunc("/2014/classes/paladin/levels", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"level":1,"prof_bonus":2},{"level":2,"prof_bonus":2}]`))
+	})
+	mux.HandleFunc("/2014/monsters", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestAPIDataSource_Get(t *testing.T) {
+	srv := newTestAPIServer(t)
+	src := newAPIDataSource(srv.Client(), srv.URL)
+
+	var spell spellAPIResponse
+	if err := src.Get(context.Background(), spells, "fireball", &spell); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if spell.Name != "Fireball" || spell.Level != 3 {
+		t.Errorf("unexpected spell: %+v", spell)
+	}
+
+	err := src.Get(context.Background(), spells, "unknown", &spell)
+	if !errors.Is(err, errNotFound) {
+		t.Errorf("expected errNotFound, got %v", err)
+	}
+
+	var levels []classLevel
+	if err := src.Get(context.Background(), classes, "paladin/levels", &levels); err != nil {
+		t.Fatalf("unexpected error fetching sub-resource: %v", err)
+	}
+	if len(levels) != 2 {
+		t.Errorf("expected 2 levels, got %+v", levels)
+	}
+}
+
+func TestAPIDataSource_List(t *testing.T) {
+	srv := newTestAPIServer(t)
+	src := newAPIDataSource(srv.Client(), srv.URL)
+
+	cases := []struct {
+		name      string
+		filter    string
+		wantCount int
+	}{
+		{"no filter", "", 2},
+		{"matching filter", "level=3", 2},
+		{"non-matching filter", "level=9", 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var results []spellListAPIResponse
+			if err := src.List(context.Background(), spells, tc.filter, &results); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(results) != tc.wantCount {
+				t.Errorf("expected %d results, got %d", tc.wantCount, len(results))
+			}
+		})
+	}
+
+	var results []monsterListAPIResponse
+	err := src.List(context.Background(), monsters, "", &results)
+	if err == nil || !strings.Contains(err.Error(), "status 500") {
+		t.Errorf("expected status 500 error, got %v", err)
+	}
+}
+
+func TestAPIDataSource_Search(t *testing.T) {
+	srv := newTestAPIServer(t)
+	src := newAPIDataSource(srv.Client(), srv.URL)
+
+	var results []spellListAPIResponse
+	if err := src.Search(context.Background(), spells, "fire", &results); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 2 {
+		t.Errorf("expected 2 results, got %d", len(results))
+	}
+}
+
+func TestAPIDataSource_Cancellation(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	t.Run("caller cancellation", func(t *testing.T) {
+		src := newAPIDataSource(srv.Client(), srv.URL)
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(20*time.Millisecond, cancel)
+		var spell spellAPIResponse
+		err := src.Get(ctx, spells, "fireball", &spell)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+	})
+
+	t.Run("already cancelled", func(t *testing.T) {
+		src := newAPIDataSource(srv.Client(), srv.URL)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		var results []spellListAPIResponse
+		if err := src.List(ctx, spells, "", &results); !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+	})
+
+	t.Run("request timeout", func(t *testing.T) {
+		src := newAPIDataSource(srv.Client(), srv.URL, withRequestTimeout(20*time.Millisecond))
+		var spell spellAPIResponse
+		err := src.Get(context.Background(), spells, "fireball", &spell)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected context.DeadlineExceeded, got %v", err)
+		}
+	})
+
+	t.Run("tool call", func(t *testing.T) {
+		src := newAPIDataSource(srv.Client(), srv.URL)
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		res, err := runSpellTool(ctx, src, spellToolInput{Name: "Fireball"})
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected context.DeadlineExceeded, got %v", err)
+		}
+		if res == nil || !res.IsError {
+			t.Errorf("expected MCP error result, got %+v", res)
+		}
+	})
+}
+
+func TestParagraphsUnmarshal(t *testing.T) {
+	var d struct {
+		List   paragraphs `json:"list"`
+		Single paragraphs `json:"single"`
+	}
+	if err := json.Unmarshal([]byte(`{"list":["one","two"],"single":"only"}`), &d); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(d.List) != 2 || d.List[1] != "two" || len(d.Single) != 1 || d.Single[0] != "only" {
+		t.Errorf("unexpected paragraphs: %+v", d)
+	}
+	if err := json.Unmarshal([]byte(`{"list":3}`), &d); err == nil {
+		t.Error("expected an error for a non-text description")
+	}
+}
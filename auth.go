@@ -0,0 +1,243 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// apiKeyConfig is an entry of the API keys file. Each key is given either in plain text or as the hex
+// SHA-256 of the key, so the file need not hold the secret itself. Zero limits mean unlimited.
+type apiKeyConfig struct {
+	Name              string  `yaml:"name"`
+	Key               string  `yaml:"key"`
+	KeySHA256         string  `yaml:"key_sha256"`
+	RequestsPerMinute float64 `yaml:"requests_per_minute"`
+	Burst             int     `yaml:"burst"`
+	MaxConcurrent     int     `yaml:"max_concurrent"`
+}
+
+// apiKeysFile is the layout of the API keys file, in YAML or JSON:
+//
+//	keys:
+//	  - name: alice
+//	    key_sha256: 2bb80d537b1da3e38bd30361aa855686bde0eacd7162fef6a25fe97bf527a25b
+//	    requests_per_minute: 60
+//	    max_concurrent: 4
+type apiKeysFile struct {
+	Keys []apiKeyConfig `yaml:"keys"`
+}
+
+// apiClient is an authenticated caller of the server and the limits that apply to it.
+type apiClient struct {
+	name  string
+	quota *tokenBucket  // nil if requests are not limited
+	slots chan struct{} // nil if concurrency is not limited
+}
+
+// authenticator checks the API key of each HTTP request against a fixed set of keys.
+type authenticator struct {
+	clients map[[sha256.Size]byte]*apiClient
+}
+
+// loadAPIKeys reads the API keys file at p. Every problem found in the file is reported.
+func loadAPIKeys(p string) (*authenticator, error) {
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+	var file apiKeysFile
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&file); err != nil {
+		return nil, fmt.Errorf("invalid API keys file %s: %w", p, err)
+	}
+	a, err := newAuthenticator(file.Keys)
+	if err != nil {
+		return nil, fmt.Errorf("invalid API keys file %s: %w", p, err)
+	}
+	return a, nil
+}
+
+// newAuthenticator creates an authenticator accepting the given keys.
+func newAuthenticator(keys []apiKeyConfig) (*authenticator, error) {
+	a := &authenticator{clients: map[[sha256.Size]byte]*apiClient{}}
+	names := map[string]bool{}
+	var errs []error
+	for i, k := range keys {
+		var sum [sha256.Size]byte
+		switch {
+		case strings.TrimSpace(k.Name) == "":
+			errs = append(errs, fmt.Errorf("keys[%d]: name is required", i))
+			continue
+		case names[k.Name]:
+			errs = append(errs, fmt.Errorf("keys[%d]: duplicate name %q", i, k.Name))
+			continue
+		case (k.Key == "") == (k.KeySHA256 == ""):
+			errs = append(errs, fmt.Errorf("%s: exactly one of key and key_sha256 is required", k.Name))
+			continue
+		case k.RequestsPerMinute < 0 || k.Burst < 0 || k.MaxConcurrent < 0:
+			errs = append(errs, fmt.Errorf("%s: limits must not be negative", k.Name))
+			continue
+		case k.Key != "":
+			sum = sha256.Sum256([]byte(k.Key))
+		default:
+			b, err := hex.DecodeString(k.KeySHA256)
+			if err != nil || len(b) != sha256.Size {
+				errs = append(errs, fmt.Errorf("%s: key_sha256 must be 64 hex digits", k.Name))
+				continue
+			}
+			copy(sum[:], b)
+		}
+		if _, ok := a.clients[sum]; ok {
+			errs = append(errs, fmt.Errorf("%s: key is already used by another entry", k.Name))
+			continue
+		}
+		names[k.Name] = true
+		c := &apiClient{name: k.Name}
+		if k.RequestsPerMinute > 0 {
+			burst := k.Burst
+			if burst == 0 {
+				burst = int(math.Ceil(k.RequestsPerMinute))
+			}
+			c.quota = newTokenBucket(k.RequestsPerMinute/60, burst)
+		}
+		if k.MaxConcurrent > 0 {
+			c.slots = make(chan struct{}, k.MaxConcurrent)
+		}
+		a.clients[sum] = c
+	}
+	if len(errs) == 0 && len(a.clients) == 0 {
+		errs = append(errs, errors.New("no keys defined"))
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return a, nil
+}
+
+// requestKey returns the API key of r, sent as "Authorization: Bearer <key>" or "X-API-Key: <key>".
+func requestKey(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		scheme, token, ok := strings.Cut(auth, " ")
+		if ok && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token)
+		}
+		return ""
+	}
+	return strings.TrimSpace(r.Header.Get("X-API-Key"))
+}
+
+// middleware rejects HTTP requests without a known API key and records the caller of the others in
+// the request context, where the tool handlers find it.
+func (a *authenticator) middleware(audit *logrus.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := requestKey(r)
+		c := a.clients[sha256.Sum256([]byte(key))]
+		if key == "" || c == nil {
+			audit.WithFields(logrus.Fields{
+				"remote":  r.RemoteAddr,
+				"path":    r.URL.Path,
+				"outcome": "unauthorized",
+			}).Warn("Rejected unauthenticated request")
+			w.Header().Set("WWW-Authenticate", `Bearer realm="dnd5e-mcp"`)
+			http.Error(w, "a valid API key is required", http.StatusUnauthorized)
+			return
+		}
+		ctx := withCaller(r.Context(), caller{client: c, remote: r.RemoteAddr})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// caller identifies who made a tool call.
+type caller struct {
+	client *apiClient
+	remote string
+}
+
+// callerKey is the context key under which the caller of a request is stored.
+type callerKey struct{}
+
+// withCaller returns a copy of ctx recording c as the caller.
+func withCaller(ctx context.Context, c caller) context.Context {
+	return context.WithValue(ctx, callerKey{}, c)
+}
+
+// callerFrom returns the caller recorded in ctx, if any.
+func callerFrom(ctx context.Context) (caller, bool) {
+	c, ok := ctx.Value(callerKey{}).(caller)
+	return c, ok
+}
+
+// errRequestRejected is returned for tool calls over the caller's quota or concurrency limit.
+var errRequestRejected = errors.New("request rejected")
+
+// quotaMiddleware enforces each caller's request quota and concurrency limit around the tool handlers and
+// writes an audit entry for every call. Calls over a limit are rejected rather than queued, so a client
+// cannot hold up the server by flooding it.
+func quotaMiddleware(audit *logrus.Logger) server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			c, ok := callerFrom(ctx)
+			fields := logrus.Fields{"tool": req.Params.Name, "client": "anonymous"}
+			if ok {
+				fields["client"], fields["remote"] = c.client.name, c.remote
+			}
+			reject := func(outcome string, err error) (*mcp.CallToolResult, error) {
+				fields["outcome"] = outcome
+				audit.WithFields(fields).Warn("Tool call rejected")
+				return mcp.NewToolResultErrorFromErr("request rejected", err), fmt.Errorf("%w: %w", errRequestRejected, err)
+			}
+			if ok && c.client.quota != nil && !c.client.quota.allow() {
+				return reject("quota_exceeded", fmt.Errorf("request quota of %s exceeded; try again later", c.client.name))
+			}
+			if ok && c.client.slots != nil {
+				select {
+				case c.client.slots <- struct{}{}:
+					defer func() { <-c.client.slots }()
+				default:
+					return reject("concurrency_limited", fmt.Errorf("%s already has %d requests in progress", c.client.name, cap(c.client.slots)))
+				}
+			}
+
+			start := time.Now()
+			result, err := next(ctx, req)
+			fields["duration"] = time.Since(start)
+			fields["outcome"] = "ok"
+			if err != nil || (result != nil && result.IsError) {
+				fields["outcome"] = "error"
+			}
+			audit.WithFields(fields).Info("Tool called")
+			return result, err
+		}
+	}
+}
+
+// newAuditLogger returns the logger for audit entries: JSON lines appended to the file p, or the standard
+// logger if p is empty.
+func newAuditLogger(p string) (*logrus.Logger, error) {
+	if p == "" {
+		return logrus.StandardLogger(), nil
+	}
+	f, err := os.OpenFile(p, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	l := logrus.New()
+	l.SetOutput(f)
+	l.SetFormatter(&logrus.JSONFormatter{})
+	return l, nil
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sirupsen/logrus"
+)
+
+func TestLoadAPIKeys(t *testing.T) {
+	dir := t.TempDir()
+	sum := sha256.Sum256([]byte("bob-secret"))
+	good := filepath.Join(dir, "keys.yaml")
+	os.WriteFile(good, []byte("keys:\n"+
+		"  - name: alice\n    key: alice-secret\n    requests_per_minute: 30\n    max_concurrent: 2\n"+
+		"  - name: bob\n    key_sha256: "+hex.EncodeToString(sum[:])+"\n"), 0o600)
+	a, err := loadAPIKeys(good)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	alice := a.clients[sha256.Sum256([]byte("alice-secret"))]
+	if alice == nil || alice.quota == nil || alice.quota.burst != 30 || cap(alice.slots) != 2 {
+		t.Errorf("unexpected client for alice: %+v", alice)
+	}
+	if bob := a.clients[sum]; bob == nil || bob.quota != nil || bob.slots != nil {
+		t.Errorf("unexpected client for bob: %+v", bob)
+	}
+
+	bad := filepath.Join(dir, "bad.json")
+	os.WriteFile(bad, []byte(`{"keys":[{"name":"a"},{"name":"b","key":"x","key_sha256":"00"},{"name":"c","key_sha256":"zz"},`+
+		`{"name":"d","key":"x","max_concurrent":-1},{"name":"e","key":"k"},{"name":"e","key":"k2"},{"name":"f","key":"k"}]}`), 0o600)
+	_, err = loadAPIKeys(bad)
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+	for _, want := range []string{
+		"a: exactly one of key and key_sha256 is required",
+		"b: exactly one of key and key_sha256 is required",
+		"c: key_sha256 must be 64 hex digits",
+		"d: limits must not be negative",
+		`keys[5]: duplicate name "e"`,
+		"f: key is already used by another entry",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to contain %q, got:\n%v", want, err)
+		}
+	}
+
+	unknown := filepath.Join(dir, "unknown.yaml")
+	os.WriteFile(unknown, []byte("keys:\n  - name: a\n    key: x\n    requests_per_minit: 5\n"), 0o600)
+	if _, err := loadAPIKeys(unknown); err == nil || !strings.Contains(err.Error(), "requests_per_minit") {
+		t.Errorf("expected an unknown field error, got %v", err)
+	}
+}
+
+func TestAuthenticatorMiddleware(t *testing.T) {
+	a, err := newAuthenticator([]apiKeyConfig{{Name: "alice", Key: "alice-secret"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var audit bytes.Buffer
+	auditLog := logrus.New()
+	auditLog.SetOutput(&audit)
+	var seen string
+	h := a.middleware(auditLog, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, _ := callerFrom(r.Context())
+		seen = c.client.name
+	}))
+
+	for _, tc := range []struct {
+		name   string
+		header [2]string
+		status int
+	}{
+		{"bearer token", [2]string{"Authorization", "Bearer alice-secret"}, http.StatusOK},
+		{"api key header", [2]string{"X-API-Key", "alice-secret"}, http.StatusOK},
+		{"missing key", [2]string{}, http.StatusUnauthorized},
+		{"wrong key", [2]string{"Authorization", "Bearer nope"}, http.StatusUnauthorized},
+		{"wrong scheme", [2]string{"Authorization", "Basic alice-secret"}, http.StatusUnauthorized},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			seen = ""
+			req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+			if tc.header[0] != "" {
+				req.Header.Set(tc.header[0], tc.header[1])
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != tc.status {
+				t.Errorf("expected status %d, got %d", tc.status, rec.Code)
+			}
+			if tc.status == http.StatusOK && seen != "alice" {
+				t.Errorf("expected the handler to see caller alice, got %q", seen)
+			}
+			if tc.status == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Error("expected a WWW-Authenticate header")
+			}
+		})
+	}
+	if got := strings.Count(audit.String(), "outcome=unauthorized"); got != 3 {
+		t.Errorf("expected 3 unauthorized audit entries, got %d:\n%s", got, audit.String())
+	}
+}
+
+// auditEntries parses the JSON audit entries written to buf.
+func auditEntries(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var entries []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var e map[string]any
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("unmarshal audit entry %q: %v", line, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+func TestQuotaMiddleware(t *testing.T) {
+	a, err := newAuthenticator([]apiKeyConfig{
+		{Name: "alice", Key: "a", RequestsPerMinute: 1, Burst: 2},
+		{Name: "bob", Key: "b", MaxConcurrent: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	audit := logrus.New()
+	audit.SetOutput(&buf)
+	audit.SetFormatter(&logrus.JSONFormatter{})
+
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+	handler := quotaMiddleware(audit)(func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if req.Params.Name == "slow" {
+			started <- struct{}{}
+			<-release
+		}
+		return mcp.NewToolResultText("{}"), nil
+	})
+	call := func(key, tool string) (*mcp.CallToolResult, error) {
+		c := a.clients[sha256.Sum256([]byte(key))]
+		req := mcp.CallToolRequest{}
+		req.Params.Name = tool
+		return handler(withCaller(context.Background(), caller{client: c, remote: "10.0.0.1:1234"}), req)
+	}
+
+	t.Run("request quota", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			if _, err := call("a", "spells"); err != nil {
+				t.Fatalf("call %d: unexpected error: %v", i, err)
+			}
+		}
+		res, err := call("a", "spells")
+		if !errors.Is(err, errRequestRejected) || !res.IsError || !strings.Contains(err.Error(), "quota of alice exceeded") {
+			t.Errorf("expected the third call to be rejected, got %v", err)
+		}
+	})
+
+	t.Run("concurrency limit", func(t *testing.T) {
+		done := make(chan error, 1)
+		go func() {
+			_, err := call("b", "slow")
+			done <- err
+		}()
+		<-started
+		if _, err := call("b", "spells"); err == nil || !strings.Contains(err.Error(), "already has 1 requests in progress") {
+			t.Errorf("expected a concurrency error, got %v", err)
+		}
+		close(release)
+		if err := <-done; err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := call("b", "spells"); err != nil {
+			t.Errorf("expected the slot to be released, got %v", err)
+		}
+	})
+
+	entries := auditEntries(t, &buf)
+	var outcomes []string
+	for _, e := range entries {
+		outcomes = append(outcomes, e["client"].(string)+":"+e["tool"].(string)+":"+e["outcome"].(string))
+		if e["remote"] != "10.0.0.1:1234" {
+			t.Errorf("expected the remote address in %v", e)
+		}
+	}
+	want := "alice:spells:ok alice:spells:ok alice:spells:quota_exceeded bob:spells:concurrency_limited bob:slow:ok bob:spells:ok"
+	if got := strings.Join(outcomes, " "); got != want {
+		t.Errorf("unexpected audit entries:\n got: %s\nwant: %s", got, want)
+	}
+}
+
+func TestServeHTTPAuth(t *testing.T) {
+	a, err := newAuthenticator([]apiKeyConfig{{Name: "alice", Key: "alice-secret"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	audit := logrus.New()
+	audit.SetOutput(&bytes.Buffer{})
+	base, stop := startTestTransport(t, transportConfig{Transport: transportHTTP, ShutdownTimeout: time.Second, Auth: a, Audit: audit})
+	defer stop()
+	body := `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`
+
+	resp, err := http.Post(base+"/mcp", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected 401 without a key, got %d", resp.StatusCode)
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, base+"/mcp", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer alice-secret")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected 200 with a key, got %d", resp.StatusCode)
+	}
+}
@@ -3,7 +3,6 @@ package main
 import (
 	"context"
 	"encoding/json"
-	"net/http"
 
 	"github.com/mark3labs/mcp-go/mcp"
 )
@@ -20,12 +19,34 @@ type backgroundListAPIResponse struct {
 	URL   string `json:"url"`
 }
 
+// backgroundFeature is the special feature a background grants.
+type backgroundFeature struct {
+	Name string   `json:"name"`
+	Desc []string `json:"desc"`
+}
+
 // backgroundDetail defines the structure for a detailed background response.
+// PersonalityTraits, Ideals, Bonds and Flaws are the background's suggested characteristics tables,
+// each modeled as a choice over its entries. The 2024 ruleset drops the feature and characteristics
+// and instead grants ability score increases, an origin feat and a choice of equipment packages.
 type backgroundDetail struct {
-	Index string `json:"index"`
-	Name  string `json:"name"`
-	URL   string `json:"url"`
-	// Add more fields as needed based on the API response
+	Index                    string              `json:"index"`
+	Name                     string              `json:"name"`
+	StartingProficiencies    []apiReference      `json:"starting_proficiencies,omitempty"`
+	LanguageOptions          *choice             `json:"language_options,omitempty"`
+	StartingEquipment        []startingEquipment `json:"starting_equipment,omitempty"`
+	StartingEquipmentOptions []choice            `json:"starting_equipment_options,omitempty"`
+	Feature                  *backgroundFeature  `json:"feature,omitempty"`
+	PersonalityTraits        *choice             `json:"personality_traits,omitempty"`
+	Ideals                   *choice             `json:"ideals,omitempty"`
+	Bonds                    *choice             `json:"bonds,omitempty"`
+	Flaws                    *choice             `json:"flaws,omitempty"`
+	AbilityScores            []apiReference      `json:"ability_scores,omitempty"`
+	Feat                     *apiReference       `json:"feat,omitempty"`
+	Proficiencies            []apiReference      `json:"proficiencies,omitempty"`
+	ProficiencyChoices       []choice            `json:"proficiency_choices,omitempty"`
+	EquipmentOptions         []choice            `json:"equipment_options,omitempty"`
+	URL                      string              `json:"url"`
 }
 
 // backgroundToolOutput defines the output structure for the backgrounds tool.
@@ -37,12 +58,12 @@ type backgroundToolOutput struct {
 
 // fetchBackgroundByNameResult fetches a background by index and returns an MCP tool result.
 func fetchBackgroundByNameResult(
-	client *http.Client,
+	ctx context.Context,
+	src dataSource,
 	input backgroundToolInput,
-	fetchByName func(*http.Client, endpoint, string, any) error,
 ) (*mcp.CallToolResult, error) {
 	background := &backgroundDetail{}
-	err := fetchByName(client, backgrounds, input.Name, background)
+	err := fetchByName(ctx, src, backgrounds, input.Name, background)
 	if err != nil {
 		return mcp.NewToolResultErrorFromErr("failed to fetch background", err), err
 	}
@@ -56,11 +77,11 @@ func fetchBackgroundByNameResult(
 
 // fetchBackgroundListResult fetches a list of backgrounds and returns an MCP tool result.
 func fetchBackgroundListResult(
-	client *http.Client,
-	fetchList func(*http.Client, endpoint, any, string) error,
+	ctx context.Context,
+	src dataSource,
 ) (*mcp.CallToolResult, error) {
 	var results []backgroundListAPIResponse
-	err := fetchList(client, backgrounds, &results, "")
+	err := fetchList(ctx, src, backgrounds, &results, "")
 	if err != nil {
 		return mcp.NewToolResultErrorFromErr("Failed to fetch background list", err), err
 	}
@@ -73,19 +94,16 @@ func fetchBackgroundListResult(
 }
 
 // runBackgroundTool executes the core logic for the backgrounds tool.
-func runBackgroundTool(
-	input backgroundToolInput,
-	fetchByName func(*http.Client, endpoint, string, any) error,
-	fetchList func(*http.Client, endpoint, any, string) error,
-) (*mcp.CallToolResult, error) {
-	client := http.DefaultClient
+func runBackgroundTool(ctx context.Context, src dataSource, input backgroundToolInput) (*mcp.CallToolResult, error) {
 	if input.Name != "" {
-		return fetchBackgroundByNameResult(client, input, fetchByName)
+		return fetchBackgroundByNameResult(ctx, src, input)
 	}
-	return fetchBackgroundListResult(client, fetchList)
+	return fetchBackgroundListResult(ctx, src)
 }
 
-// handleBackgroundTool is the MCP handler for the backgrounds tool.
-func handleBackgroundTool(ctx context.Context, req mcp.CallToolRequest, input backgroundToolInput) (*mcp.CallToolResult, error) {
-	return runBackgroundTool(input, fetchByName, fetchList)
+// handleBackgroundTool returns the MCP handler for the backgrounds tool.
+func handleBackgroundTool(src dataSource) mcp.TypedToolHandlerFunc[backgroundToolInput] {
+	return func(ctx context.Context, req mcp.CallToolRequest, input backgroundToolInput) (*mcp.CallToolResult, error) {
+		return runBackgroundTool(ctx, src, input)
+	}
 }
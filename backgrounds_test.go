@@ -1,9 +1,9 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
-	"net/http"
 	"strings"
 	"testing"
 
@@ -21,8 +21,8 @@ func TestRunBackgroundTool(t *testing.T) {
 	cases := []struct {
 		name       string
 		input      backgroundToolInput
-		mockByName func(*http.Client, endpoint, string, any) error
-		mockList   func(*http.Client, endpoint, any, string) error
+		mockByName func(context.Context, endpoint, string, any) error
+		mockList   func(context.Context, endpoint, string, any) error
 		wantOutput backgroundToolOutput
 		wantErr    bool
 		wantErrMsg string
@@ -30,7 +30,7 @@ func TestRunBackgroundTool(t *testing.T) {
 		{
 			name:  "by name",
 			input: backgroundToolInput{Name: "acolyte"},
-			mockByName: func(_ *http.Client, _ endpoint, name string, v any) error {
+			mockByName: func(_ context.Context, _ endpoint, name string, v any) error {
 				ptr, ok := v.(*backgroundDetail)
 				if !ok {
 					return errors.New("wrong type")
@@ -38,7 +38,7 @@ func TestRunBackgroundTool(t *testing.T) {
 				*ptr = background
 				return nil
 			},
-			mockList:   func(_ *http.Client, _ endpoint, v any, _ string) error { return nil },
+			mockList:   func(_ context.Context, _ endpoint, _ string, v any) error { return nil },
 			wantOutput: backgroundToolOutput{Background: &background},
 			wantErr:    false,
 			wantErrMsg: "",
@@ -46,8 +46,8 @@ func TestRunBackgroundTool(t *testing.T) {
 		{
 			name:       "list",
 			input:      backgroundToolInput{},
-			mockByName: func(_ *http.Client, _ endpoint, name string, v any) error { return nil },
-			mockList: func(_ *http.Client, _ endpoint, v any, _ string) error {
+			mockByName: func(_ context.Context, _ endpoint, name string, v any) error { return nil },
+			mockList: func(_ context.Context, _ endpoint, _ string, v any) error {
 				ptr, ok := v.(*[]backgroundListAPIResponse)
 				if !ok {
 					return errors.New("wrong type")
@@ -62,10 +62,10 @@ func TestRunBackgroundTool(t *testing.T) {
 		{
 			name:  "fetchByName error",
 			input: backgroundToolInput{Name: "fail"},
-			mockByName: func(_ *http.Client, _ endpoint, name string, v any) error {
+			mockByName: func(_ context.Context, _ endpoint, name string, v any) error {
 				return errors.New("fetchByName failed")
 			},
-			mockList:   func(_ *http.Client, _ endpoint, v any, _ string) error { return nil },
+			mockList:   func(_ context.Context, _ endpoint, _ string, v any) error { return nil },
 			wantOutput: backgroundToolOutput{},
 			wantErr:    true,
 			wantErrMsg: "fetchByName failed",
@@ -73,8 +73,8 @@ func TestRunBackgroundTool(t *testing.T) {
 		{
 			name:       "fetchList error",
 			input:      backgroundToolInput{},
-			mockByName: func(_ *http.Client, _ endpoint, name string, v any) error { return nil },
-			mockList: func(_ *http.Client, _ endpoint, v any, _ string) error {
+			mockByName: func(_ context.Context, _ endpoint, name string, v any) error { return nil },
+			mockList: func(_ context.Context, _ endpoint, _ string, v any) error {
 				return errors.New("fetchList failed")
 			},
 			wantOutput: backgroundToolOutput{},
@@ -85,7 +85,7 @@ func TestRunBackgroundTool(t *testing.T) {
 
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			res, err := runBackgroundTool(tc.input, tc.mockByName, tc.mockList)
+			res, err := runBackgroundTool(context.Background(), &mockDataSource{get: tc.mockByName, list: tc.mockList}, tc.input)
 			if tc.wantErr {
 				if err == nil {
 					t.Errorf("expected Go error, got nil")
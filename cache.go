@@ -0,0 +1,219 @@
+package main
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// cacheEntry is a cached API response body together with its HTTP validators.
+type cacheEntry struct {
+	Key          string    `json:"key"`
+	Body         []byte    `json:"body"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	StoredAt     time.Time `json:"stored_at"`
+}
+
+// cacheStats is a point-in-time view of the cache counters.
+type cacheStats struct {
+	Hits          uint64 `json:"hits"`
+	Misses        uint64 `json:"misses"`
+	Revalidations uint64 `json:"revalidations"`
+	Evictions     uint64 `json:"evictions"`
+	Entries       int    `json:"entries"`
+}
+
+// responseCache is an in-memory LRU cache of API responses with an optional on-disk store.
+// Entries older than the TTL are stale and must be revalidated with the upstream before use.
+type responseCache struct {
+	mu       sync.Mutex
+	capacity int
+	ttl      time.Duration
+	dir      string
+	ll       *list.List
+	items    map[string]*list.Element
+
+	hits          atomic.Uint64
+	misses        atomic.Uint64
+	revalidations atomic.Uint64
+	evictions     atomic.Uint64
+}
+
+// newResponseCache creates a cache holding up to capacity entries in memory.
+// If dir is non-empty, entries are also persisted there and survive restarts.
+func newResponseCache(capacity int, ttl time.Duration, dir string) (*responseCache, error) {
+	if capacity < 1 {
+		return nil, errors.New("cache capacity must be at least 1")
+	}
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, err
+		}
+	}
+	return &responseCache{
+		capacity: capacity,
+		ttl:      ttl,
+		dir:      dir,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+	}, nil
+}
+
+// get returns a copy of the entry stored under key, consulting the on-disk store when it is not in memory.
+// The copy may be modified freely; store changes with put.
+func (c *responseCache) get(ctx context.Context, key string) (*cacheEntry, bool) {
+	c.mu.Lock()
+	if el, ok := c.items[key]; ok {
+		c.ll.MoveToFront(el)
+		entry := *el.Value.(*cacheEntry)
+		c.mu.Unlock()
+		return &entry, true
+	}
+	c.mu.Unlock()
+
+	entry, err := c.readDisk(key)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			logFrom(ctx).WithError(err).WithField("key", key).Warn("Failed to read cache entry from disk")
+		}
+		return nil, false
+	}
+	c.putMemory(entry)
+	copied := *entry
+	return &copied, true
+}
+
+// put stores entry in memory and, if configured, on disk.
+func (c *responseCache) put(ctx context.Context, entry *cacheEntry) {
+	c.putMemory(entry)
+	if err := c.writeDisk(entry); err != nil {
+		logFrom(ctx).WithError(err).WithField("key", entry.Key).Warn("Failed to write cache entry to disk")
+	}
+}
+
+// fresh reports whether entry is younger than the cache TTL.
+func (c *responseCache) fresh(entry *cacheEntry) bool {
+	return time.Since(entry.StoredAt) < c.ttl
+}
+
+// stats returns the current cache counters.
+func (c *responseCache) stats() cacheStats {
+	c.mu.Lock()
+	entries := c.ll.Len()
+	c.mu.Unlock()
+	return cacheStats{
+		Hits:          c.hits.Load(),
+		Misses:        c.misses.Load(),
+		Revalidations: c.revalidations.Load(),
+		Evictions:     c.evictions.Load(),
+		Entries:       entries,
+	}
+}
+
+// logStats writes the current cache counters to the log of ctx at the given level.
+func (c *responseCache) logStats(ctx context.Context, level logrus.Level, msg string) {
+	st := c.stats()
+	logFrom(ctx).WithFields(logrus.Fields{
+		"hits":          st.Hits,
+		"misses":        st.Misses,
+		"revalidations": st.Revalidations,
+		"evictions":     st.Evictions,
+		"entries":       st.Entries,
+	}).Log(level, msg)
+}
+
+// putMemory inserts or replaces entry in the LRU, evicting the least recently used entry when full.
+func (c *responseCache) putMemory(entry *cacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[entry.Key]; ok {
+		el.Value = entry
+		c.ll.MoveToFront(el)
+		return
+	}
+	c.items[entry.Key] = c.ll.PushFront(entry)
+	for c.ll.Len() > c.capacity {
+		oldest := c.ll.Back()
+		c.ll.Remove(oldest)
+		delete(c.items, oldest.Value.(*cacheEntry).Key)
+		c.evictions.Add(1)
+	}
+}
+
+// diskPath returns the file that stores key in the on-disk cache.
+func (c *responseCache) diskPath(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// readDisk loads the entry for key from the on-disk store.
+func (c *responseCache) readDisk(key string) (*cacheEntry, error) {
+	if c.dir == "" {
+		return nil, fs.ErrNotExist
+	}
+	data, err := os.ReadFile(c.diskPath(key))
+	if err != nil {
+		return nil, err
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, err
+	}
+	if entry.Key != key {
+		return nil, fs.ErrNotExist
+	}
+	return &entry, nil
+}
+
+// writeDisk atomically persists entry to the on-disk store.
+func (c *responseCache) writeDisk(entry *cacheEntry) error {
+	if c.dir == "" {
+		return nil
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(c.diskPath(entry.Key), data)
+}
+
+// writeFileAtomic writes data to a temporary file next to p and renames it into place. The file keeps the
+// mode of the file it replaces, or is readable by everyone if it is new, and is synced to disk before the
+// rename so that a crash leaves either the old content or the new.
+func writeFileAtomic(p string, data []byte) error {
+	mode := fs.FileMode(0o644)
+	if info, err := os.Stat(p); err == nil {
+		mode = info.Mode().Perm()
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	err = tmp.Chmod(mode)
+	if err == nil {
+		_, err = tmp.Write(data)
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestResponseCache_LRUEviction(t *testing.T) {
+	c, err := newResponseCache(2, time.Hour, "")
+	if err != nil {
+		t.Fatalf("newResponseCache: %v", err)
+	}
+	c.put(context.Background(), &cacheEntry{Key: "a", Body: []byte("1"), StoredAt: time.Now()})
+	c.put(context.Background(), &cacheEntry{Key: "b", Body: []byte("2"), StoredAt: time.Now()})
+	if _, ok := c.get(context.Background(), "a"); !ok {
+		t.Fatalf("expected a to be cached")
+	}
+	c.put(context.Background(), &cacheEntry{Key: "c", Body: []byte("3"), StoredAt: time.Now()})
+
+	if _, ok := c.get(context.Background(), "b"); ok {
+		t.Errorf("expected least recently used entry b to be evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := c.get(context.Background(), key); !ok {
+			t.Errorf("expected %s to be cached", key)
+		}
+	}
+	if st := c.stats(); st.Evictions != 1 || st.Entries != 2 {
+		t.Errorf("unexpected stats: %+v", st)
+	}
+}
+
+func TestResponseCache_Fresh(t *testing.T) {
+	c, err := newResponseCache(1, time.Minute, "")
+	if err != nil {
+		t.Fatalf("newResponseCache: %v", err)
+	}
+	if !c.fresh(&cacheEntry{StoredAt: time.Now()}) {
+		t.Errorf("expected new entry to be fresh")
+	}
+	if c.fresh(&cacheEntry{StoredAt: time.Now().Add(-2 * time.Minute)}) {
+		t.Errorf("expected old entry to be stale")
+	}
+}
+
+func TestResponseCache_Disk(t *testing.T) {
+	dir := t.TempDir()
+	c, err := newResponseCache(1, time.Hour, dir)
+	if err != nil {
+		t.Fatalf("newResponseCache: %v", err)
+	}
+	c.put(context.Background(), &cacheEntry{Key: "spells/fireball", Body: []byte(`{"index":"fireball"}`), ETag: `"v1"`, StoredAt: time.Now()})
+
+	reopened, err := newResponseCache(1, time.Hour, dir)
+	if err != nil {
+		t.Fatalf("newResponseCache: %v", err)
+	}
+	entry, ok := reopened.get(context.Background(), "spells/fireball")
+	if !ok {
+		t.Fatalf("expected entry to be loaded from disk")
+	}
+	if string(entry.Body) != `{"index":"fireball"}` || entry.ETag != `"v1"` {
+		t.Errorf("unexpected entry: %+v", entry)
+	}
+	if _, ok := reopened.get(context.Background(), "spells/wish"); ok {
+		t.Errorf("expected missing key to miss")
+	}
+}
+
+func TestAPIDataSource_Cache(t *testing.T) {
+	var requests, notModified atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(`{"index":"fireball","name":"Fireball"}`))
+	}))
+	defer srv.Close()
+
+	cache, err := newResponseCache(10, time.Hour, "")
+	if err != nil {
+		t.Fatalf("newResponseCache: %v", err)
+	}
+	src := newAPIDataSource(srv.Client(), srv.URL, withCache(cache))
+
+	var spell spellAPIResponse
+	for i := 0; i < 3; i++ {
+		if err := src.Get(context.Background(), spells, "fireball", &spell); err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+	}
+	if requests.Load() != 1 {
+		t.Errorf("expected 1 upstream request while fresh, got %d", requests.Load())
+	}
+
+	cache.ttl = 0
+	spell = spellAPIResponse{}
+	if err := src.Get(context.Background(), spells, "fireball", &spell); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if spell.Name != "Fireball" {
+		t.Errorf("expected cached body after revalidation, got %+v", spell)
+	}
+	if notModified.Load() != 1 {
+		t.Errorf("expected a conditional request, got %d", notModified.Load())
+	}
+	if st := cache.stats(); st.Hits != 2 || st.Misses != 2 || st.Revalidations != 1 {
+		t.Errorf("unexpected stats: %+v", st)
+	}
+}
+
+func TestResponseCache_DiskEntryIsCopied(t *testing.T) {
+	dir := t.TempDir()
+	stored, err := newResponseCache(1, time.Hour, dir)
+	if err != nil {
+		t.Fatalf("newResponseCache: %v", err)
+	}
+	stored.put(context.Background(), &cacheEntry{Key: "spells/fireball", Body: []byte(`{"index":"fireball"}`), StoredAt: time.Now().Add(-2 * time.Hour)})
+
+	c, err := newResponseCache(1, time.Hour, dir)
+	if err != nil {
+		t.Fatalf("newResponseCache: %v", err)
+	}
+	entry, ok := c.get(context.Background(), "spells/fireball")
+	if !ok {
+		t.Fatalf("expected entry to be loaded from disk")
+	}
+	// Run with -race: the entry loaded from disk must not be shared with the cache, which other gets copy.
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		c.get(context.Background(), "spells/fireball")
+	}()
+	entry.StoredAt = time.Now()
+	wg.Wait()
+	if cached, _ := c.get(context.Background(), "spells/fireball"); c.fresh(cached) {
+		t.Errorf("expected changing the returned entry to leave the cached one stale")
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	p := filepath.Join(dir, "spells.yaml")
+	if err := writeFileAtomic(p, []byte("a")); err != nil {
+		t.Fatalf("writeFileAtomic: %v", err)
+	}
+	if info, err := os.Stat(p); err != nil || info.Mode().Perm() != 0o644 {
+		t.Errorf("expected a new file to be created with mode 0644, got %v, %v", info.Mode(), err)
+	}
+	if err := os.Chmod(p, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeFileAtomic(p, []byte("b")); err != nil {
+		t.Fatalf("writeFileAtomic: %v", err)
+	}
+	if info, err := os.Stat(p); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("expected a replaced file to keep mode 0600, got %v, %v", info.Mode(), err)
+	}
+	if data, _ := os.ReadFile(p); string(data) != "b" {
+		t.Errorf("expected the new content, got %q", data)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("expected no temporary files left behind, got %v", entries)
+	}
+}
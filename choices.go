@@ -0,0 +1,64 @@
+package main
+
+// choice is a decision the player makes when building a character, such as which skills to take
+// or which equipment bundle to start with: pick Choose options from From.
+type choice struct {
+	Desc   string    `json:"desc,omitempty"`
+	Choose int       `json:"choose"`
+	Type   string    `json:"type"`
+	From   optionSet `json:"from"`
+}
+
+// optionSet is the pool a choice picks from. Depending on OptionSetType it is an explicit list of
+// Options ("options_array"), every item in an EquipmentCategory ("equipment_category"),
+// or every resource listed at ResourceListURL ("resource_list").
+type optionSet struct {
+	OptionSetType     string        `json:"option_set_type"`
+	Options           []option      `json:"options,omitempty"`
+	EquipmentCategory *apiReference `json:"equipment_category,omitempty"`
+	ResourceListURL   string        `json:"resource_list_url,omitempty"`
+}
+
+// option is a single entry of an option set. OptionType selects which fields are present:
+//
+//   - "reference": Item
+//   - "counted_reference": Count of Of, with optional Prerequisites
+//   - "multiple": Items, all of which are taken together
+//   - "choice": a nested Choice
+//   - "string": String
+//   - "ideal": Desc and the Alignments it suits
+//   - "score_prerequisite": AbilityScore at MinimumScore or higher
+//   - "ability_bonus": Bonus to AbilityScore
+type option struct {
+	OptionType    string               `json:"option_type"`
+	Item          *apiReference        `json:"item,omitempty"`
+	Count         int                  `json:"count,omitempty"`
+	Of            *apiReference        `json:"of,omitempty"`
+	Prerequisites []optionPrerequisite `json:"prerequisites,omitempty"`
+	Items         []option             `json:"items,omitempty"`
+	Choice        *choice              `json:"choice,omitempty"`
+	String        string               `json:"string,omitempty"`
+	Desc          string               `json:"desc,omitempty"`
+	Alignments    []apiReference       `json:"alignments,omitempty"`
+	AbilityScore  *apiReference        `json:"ability_score,omitempty"`
+	MinimumScore  int                  `json:"minimum_score,omitempty"`
+	Bonus         int                  `json:"bonus,omitempty"`
+}
+
+// optionPrerequisite is a requirement for a counted reference option, such as proficiency with the item.
+type optionPrerequisite struct {
+	Type        string        `json:"type"`
+	Proficiency *apiReference `json:"proficiency,omitempty"`
+}
+
+// abilityScorePrerequisite is a minimum ability score, as required by feats and multiclassing.
+type abilityScorePrerequisite struct {
+	AbilityScore apiReference `json:"ability_score"`
+	MinimumScore int          `json:"minimum_score"`
+}
+
+// startingEquipment is an item granted at character creation.
+type startingEquipment struct {
+	Equipment apiReference `json:"equipment"`
+	Quantity  int          `json:"quantity"`
+}
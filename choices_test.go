@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+func TestDecodeClassDetail(t *testing.T) {
+	data, err := os.ReadFile("testdata/class_by_name.json")
+	if err != nil {
+		t.Fatalf("failed to read class_by_name.json: %v", err)
+	}
+	var class classDetail
+	if err := json.Unmarshal(data, &class); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if len(class.ProficiencyChoices) != 1 || class.ProficiencyChoices[0].Choose != 2 {
+		t.Errorf("unexpected proficiency choices: %+v", class.ProficiencyChoices)
+	}
+	if len(class.SavingThrows) != 2 || class.SavingThrows[1].Index != "con" {
+		t.Errorf("unexpected saving throws: %+v", class.SavingThrows)
+	}
+	bundle := class.StartingEquipmentOptions[0].From.Options[1]
+	if bundle.OptionType != "multiple" || len(bundle.Items) != 3 || bundle.Items[2].Count != 20 {
+		t.Errorf("unexpected equipment bundle: %+v", bundle)
+	}
+	nested := class.StartingEquipmentOptions[1].From.Options[0].Items[0].Choice
+	if nested == nil || nested.From.EquipmentCategory == nil || nested.From.EquipmentCategory.Index != "martial-weapons" {
+		t.Errorf("unexpected nested choice: %+v", nested)
+	}
+	opts := class.MultiClassing.PrerequisiteOptions
+	if opts == nil || len(opts.From.Options) != 2 || opts.From.Options[1].MinimumScore != 13 || opts.From.Options[1].AbilityScore.Index != "dex" {
+		t.Errorf("unexpected multiclass prerequisite options: %+v", opts)
+	}
+	if len(class.Subclasses) != 1 || class.Subclasses[0].Index != "champion" {
+		t.Errorf("unexpected subclasses: %+v", class.Subclasses)
+	}
+}
+
+func TestDecodeBackgroundDetail(t *testing.T) {
+	data, err := os.ReadFile("testdata/background_by_name.json")
+	if err != nil {
+		t.Fatalf("failed to read background_by_name.json: %v", err)
+	}
+	var bg backgroundDetail
+	if err := json.Unmarshal(data, &bg); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if bg.Feature.Name != "Shelter of the Faithful" || len(bg.Feature.Desc) != 1 {
+		t.Errorf("unexpected feature: %+v", bg.Feature)
+	}
+	if bg.LanguageOptions == nil || bg.LanguageOptions.From.ResourceListURL != "/api/2014/languages" {
+		t.Errorf("unexpected language options: %+v", bg.LanguageOptions)
+	}
+	if bg.PersonalityTraits == nil || bg.PersonalityTraits.Choose != 2 || bg.PersonalityTraits.From.Options[0].String == "" {
+		t.Errorf("unexpected personality traits: %+v", bg.PersonalityTraits)
+	}
+	ideal := bg.Ideals.From.Options[0]
+	if ideal.OptionType != "ideal" || len(ideal.Alignments) != 1 || ideal.Alignments[0].Index != "lawful-good" {
+		t.Errorf("unexpected ideal: %+v", ideal)
+	}
+	if len(bg.StartingEquipment) != 2 || bg.StartingEquipment[0].Quantity != 1 {
+		t.Errorf("unexpected starting equipment: %+v", bg.StartingEquipment)
+	}
+}
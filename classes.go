@@ -3,16 +3,23 @@ package main
 import (
 	"context"
 	"encoding/json"
-	"net/http"
+	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/mark3labs/mcp-go/mcp"
 )
 
 // classToolInput defines the input structure for the classes tool.
 type classToolInput struct {
-	Name string `json:"name" mcp:"description=The index of the class to retrieve (e.g., 'barbarian')."`
+	Name     string `json:"name" mcp:"description=The index of the class to retrieve (e.g., 'barbarian')."`
+	Level    int    `json:"level" mcp:"description=Return the class features gained up to this level (1-20), merged with the subclass features if a subclass is given. Requires name."`
+	Subclass string `json:"subclass" mcp:"description=The subclass whose features are merged into the level progression (e.g., 'devotion' or 'Oath of Devotion'). Requires name; defaults to level 20 if no level is given."`
 }
 
+// maxClassLevel is the highest level a character can reach in a class.
+const maxClassLevel = 20
+
 // classListAPIResponse defines the structure for a single class in the list response.
 type classListAPIResponse struct {
 	Index string `json:"index"`
@@ -20,39 +27,231 @@ type classListAPIResponse struct {
 	URL   string `json:"url"`
 }
 
+// classSpellcastingInfo is a titled section of a class's spellcasting rules.
+type classSpellcastingInfo struct {
+	Name string   `json:"name"`
+	Desc []string `json:"desc"`
+}
+
+// classSpellcasting describes how a spellcasting class casts spells.
+type classSpellcasting struct {
+	Level               int                     `json:"level"`
+	SpellcastingAbility apiReference            `json:"spellcasting_ability"`
+	Info                []classSpellcastingInfo `json:"info"`
+}
+
+// multiClassing lists what a character needs to multiclass into a class and what they gain from it.
+// Prerequisites must all be met; PrerequisiteOptions, when present, requires meeting one of its options.
+type multiClassing struct {
+	Prerequisites       []abilityScorePrerequisite `json:"prerequisites,omitempty"`
+	PrerequisiteOptions *choice                    `json:"prerequisite_options,omitempty"`
+	Proficiencies       []apiReference             `json:"proficiencies"`
+	ProficiencyChoices  []choice                   `json:"proficiency_choices,omitempty"`
+}
+
 // classDetail defines the structure for a detailed class response.
+// Spellcasting is only present for spellcasting classes.
 type classDetail struct {
-	Index                    string      `json:"index"`
-	Name                     string      `json:"name"`
-	HitDie                   int         `json:"hit_die"`
-	ProficiencyChoices       interface{} `json:"proficiency_choices"`
-	Proficiencies            interface{} `json:"proficiencies"`
-	SavingThrows             interface{} `json:"saving_throws"`
-	StartingEquipment        interface{} `json:"starting_equipment"`
-	StartingEquipmentOptions interface{} `json:"starting_equipment_options"`
-	ClassLevels              string      `json:"class_levels"`
-	MultiClassing            interface{} `json:"multi_classing"`
-	Subclasses               interface{} `json:"subclasses"`
-	URL                      string      `json:"url"`
-	UpdatedAt                string      `json:"updated_at"`
-	// Add more fields as needed based on the API response
+	Index                    string              `json:"index"`
+	Name                     string              `json:"name"`
+	HitDie                   int                 `json:"hit_die"`
+	ProficiencyChoices       []choice            `json:"proficiency_choices"`
+	Proficiencies            []apiReference      `json:"proficiencies"`
+	SavingThrows             []apiReference      `json:"saving_throws"`
+	StartingEquipment        []startingEquipment `json:"starting_equipment"`
+	StartingEquipmentOptions []choice            `json:"starting_equipment_options"`
+	ClassLevels              string              `json:"class_levels"`
+	MultiClassing            multiClassing       `json:"multi_classing"`
+	Subclasses               []apiReference      `json:"subclasses"`
+	Spellcasting             *classSpellcasting  `json:"spellcasting,omitempty"`
+	Spells                   string              `json:"spells,omitempty"`
+	URL                      string              `json:"url"`
+	UpdatedAt                string              `json:"updated_at"`
+}
+
+// levelSpellcasting is the number of cantrips, spells known and spell slots a caster has at a given level.
+type levelSpellcasting struct {
+	CantripsKnown    int `json:"cantrips_known,omitempty"`
+	SpellsKnown      int `json:"spells_known,omitempty"`
+	SpellSlotsLevel1 int `json:"spell_slots_level_1"`
+	SpellSlotsLevel2 int `json:"spell_slots_level_2"`
+	SpellSlotsLevel3 int `json:"spell_slots_level_3"`
+	SpellSlotsLevel4 int `json:"spell_slots_level_4"`
+	SpellSlotsLevel5 int `json:"spell_slots_level_5"`
+	SpellSlotsLevel6 int `json:"spell_slots_level_6,omitempty"`
+	SpellSlotsLevel7 int `json:"spell_slots_level_7,omitempty"`
+	SpellSlotsLevel8 int `json:"spell_slots_level_8,omitempty"`
+	SpellSlotsLevel9 int `json:"spell_slots_level_9,omitempty"`
+}
+
+// classLevel defines the structure for a single entry of the class or subclass levels response.
+// ClassSpecific and SubclassSpecific vary by class (e.g. rage count, sneak attack dice) and are passed through as-is.
+type classLevel struct {
+	Index               string             `json:"index"`
+	Level               int                `json:"level"`
+	AbilityScoreBonuses int                `json:"ability_score_bonuses"`
+	ProfBonus           int                `json:"prof_bonus"`
+	Features            []apiReference     `json:"features"`
+	Spellcasting        *levelSpellcasting `json:"spellcasting,omitempty"`
+	ClassSpecific       json.RawMessage    `json:"class_specific,omitempty"`
+	SubclassSpecific    json.RawMessage    `json:"subclass_specific,omitempty"`
+	Class               *apiReference      `json:"class,omitempty"`
+	Subclass            *apiReference      `json:"subclass,omitempty"`
+	URL                 string             `json:"url"`
+}
+
+// classProgression is what a character of a class, and optionally a subclass, has at a given level.
+// Features holds every class and subclass feature gained up to that level, ordered by the level it is gained.
+type classProgression struct {
+	Class               apiReference       `json:"class"`
+	Subclass            *apiReference      `json:"subclass,omitempty"`
+	Level               int                `json:"level"`
+	ProficiencyBonus    int                `json:"proficiency_bonus"`
+	AbilityScoreBonuses int                `json:"ability_score_bonuses"`
+	Spellcasting        *levelSpellcasting `json:"spellcasting,omitempty"`
+	ClassSpecific       json.RawMessage    `json:"class_specific,omitempty"`
+	SubclassSpecific    json.RawMessage    `json:"subclass_specific,omitempty"`
+	Features            []featureDetail    `json:"features"`
 }
 
 // classToolOutput defines the output structure for the classes tool.
 type classToolOutput struct {
-	Count   int                    `json:"count,omitempty"`
-	Results []classListAPIResponse `json:"results,omitempty"`
-	Class   *classDetail           `json:"class,omitempty"`
+	Count       int                    `json:"count,omitempty"`
+	Results     []classListAPIResponse `json:"results,omitempty"`
+	Class       *classDetail           `json:"class,omitempty"`
+	Progression *classProgression      `json:"progression,omitempty"`
+}
+
+// findSubclass returns the class's subclass matching name by index or name.
+// Names that include the subclass flavor, such as "Oath of Devotion", also match.
+func findSubclass(refs []apiReference, name string) (apiReference, bool) {
+	key := toKebabCase(name)
+	for _, r := range refs {
+		if r.Index == key || toKebabCase(r.Name) == key {
+			return r, true
+		}
+	}
+	for _, r := range refs {
+		if strings.HasSuffix(key, "-"+r.Index) {
+			return r, true
+		}
+	}
+	return apiReference{}, false
+}
+
+// buildClassProgression collects the class's levels, and the subclass's levels if one is given,
+// up to level and resolves every feature gained along the way.
+func buildClassProgression(ctx context.Context, src dataSource, class *classDetail, subclass string, level int) (*classProgression, error) {
+	prog := &classProgression{
+		Class: apiReference{Index: class.Index, Name: class.Name, URL: class.URL},
+		Level: level,
+	}
+	type gained struct {
+		level int
+		index string
+	}
+	var gains []gained
+
+	var levels []classLevel
+	if err := src.Get(ctx, classes, class.Index+"/levels", &levels); err != nil {
+		return nil, err
+	}
+	for _, l := range levels {
+		if l.Subclass != nil || l.Level > level {
+			continue
+		}
+		for _, f := range l.Features {
+			gains = append(gains, gained{l.Level, f.Index})
+		}
+		if l.Level == level {
+			prog.ProficiencyBonus = l.ProfBonus
+			prog.AbilityScoreBonuses = l.AbilityScoreBonuses
+			prog.Spellcasting = l.Spellcasting
+			prog.ClassSpecific = l.ClassSpecific
+		}
+	}
+
+	if subclass != "" {
+		ref, ok := findSubclass(class.Subclasses, subclass)
+		if !ok {
+			return nil, fmt.Errorf("%w: subclass %q of class %q", errNotFound, subclass, class.Index)
+		}
+		prog.Subclass = &ref
+		var subLevels []classLevel
+		if err := src.Get(ctx, subclasses, ref.Index+"/levels", &subLevels); err != nil {
+			return nil, err
+		}
+		latest := 0
+		for _, l := range subLevels {
+			if l.Level > level {
+				continue
+			}
+			for _, f := range l.Features {
+				gains = append(gains, gained{l.Level, f.Index})
+			}
+			if len(l.SubclassSpecific) > 0 && l.Level >= latest {
+				latest = l.Level
+				prog.SubclassSpecific = l.SubclassSpecific
+			}
+		}
+	}
+
+	sort.SliceStable(gains, func(i, j int) bool { return gains[i].level < gains[j].level })
+	seen := map[string]bool{}
+	var indexes []string
+	for _, g := range gains {
+		if !seen[g.index] {
+			seen[g.index] = true
+			indexes = append(indexes, g.index)
+		}
+	}
+	resolved, err := fetchAll[featureDetail](ctx, src, features, indexes)
+	if err != nil {
+		return nil, err
+	}
+	prog.Features = resolved
+	return prog, nil
+}
+
+// fetchClassProgressionResult fetches a class and its level progression and returns an MCP tool result.
+func fetchClassProgressionResult(
+	ctx context.Context,
+	src dataSource,
+	input classToolInput,
+) (*mcp.CallToolResult, error) {
+	level := input.Level
+	if level == 0 {
+		level = maxClassLevel
+	}
+	if level < 1 || level > maxClassLevel {
+		err := fmt.Errorf("level must be between 1 and %d, got %d", maxClassLevel, level)
+		return mcp.NewToolResultErrorFromErr("invalid level", err), err
+	}
+	class := &classDetail{}
+	err := fetchByName(ctx, src, classes, input.Name, class)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to fetch class", err), err
+	}
+	prog, err := buildClassProgression(ctx, src, class, input.Subclass, level)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to fetch class progression", err), err
+	}
+	output := classToolOutput{Progression: prog}
+	jsonData, err := json.Marshal(output)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal class progression output", err), err
+	}
+	return mcp.NewToolResultText(string(jsonData)), nil
 }
 
 // fetchClassByNameResult fetches a class by index and returns an MCP tool result.
 func fetchClassByNameResult(
-	client *http.Client,
+	ctx context.Context,
+	src dataSource,
 	input classToolInput,
-	fetchByName func(*http.Client, endpoint, string, any) error,
 ) (*mcp.CallToolResult, error) {
 	class := &classDetail{}
-	err := fetchByName(client, classes, input.Name, class)
+	err := fetchByName(ctx, src, classes, input.Name, class)
 	if err != nil {
 		return mcp.NewToolResultErrorFromErr("failed to fetch class", err), err
 	}
@@ -66,11 +265,11 @@ func fetchClassByNameResult(
 
 // fetchClassListResult fetches a list of classes and returns an MCP tool result.
 func fetchClassListResult(
-	client *http.Client,
-	fetchList func(*http.Client, endpoint, any, string) error,
+	ctx context.Context,
+	src dataSource,
 ) (*mcp.CallToolResult, error) {
 	var results []classListAPIResponse
-	err := fetchList(client, classes, &results, "")
+	err := fetchList(ctx, src, classes, &results, "")
 	if err != nil {
 		return mcp.NewToolResultErrorFromErr("Failed to fetch class list", err), err
 	}
@@ -83,19 +282,19 @@ func fetchClassListResult(
 }
 
 // runClassTool executes the core logic for the classes tool.
-func runClassTool(
-	input classToolInput,
-	fetchByName func(*http.Client, endpoint, string, any) error,
-	fetchList func(*http.Client, endpoint, any, string) error,
-) (*mcp.CallToolResult, error) {
-	client := http.DefaultClient
+func runClassTool(ctx context.Context, src dataSource, input classToolInput) (*mcp.CallToolResult, error) {
+	if input.Name != "" && (input.Level != 0 || input.Subclass != "") {
+		return fetchClassProgressionResult(ctx, src, input)
+	}
 	if input.Name != "" {
-		return fetchClassByNameResult(client, input, fetchByName)
+		return fetchClassByNameResult(ctx, src, input)
 	}
-	return fetchClassListResult(client, fetchList)
+	return fetchClassListResult(ctx, src)
 }
 
-// handleClassTool is the MCP handler for the classes tool.
-func handleClassTool(ctx context.Context, req mcp.CallToolRequest, input classToolInput) (*mcp.CallToolResult, error) {
-	return runClassTool(input, fetchByName, fetchList)
+// handleClassTool returns the MCP handler for the classes tool.
+func handleClassTool(src dataSource) mcp.TypedToolHandlerFunc[classToolInput] {
+	return func(ctx context.Context, req mcp.CallToolRequest, input classToolInput) (*mcp.CallToolResult, error) {
+		return runClassTool(ctx, src, input)
+	}
 }
@@ -1,9 +1,9 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
-	"net/http"
 	"strings"
 	"testing"
 
@@ -22,8 +22,8 @@ func TestRunClassTool(t *testing.T) {
 	cases := []struct {
 		name       string
 		input      classToolInput
-		mockByName func(*http.Client, endpoint, string, any) error
-		mockList   func(*http.Client, endpoint, any, string) error
+		mockByName func(context.Context, endpoint, string, any) error
+		mockList   func(context.Context, endpoint, string, any) error
 		wantOutput classToolOutput
 		wantErr    bool
 		wantErrMsg string
@@ -31,7 +31,7 @@ func TestRunClassTool(t *testing.T) {
 		{
 			name:  "by name",
 			input: classToolInput{Name: "barbarian"},
-			mockByName: func(_ *http.Client, _ endpoint, name string, v any) error {
+			mockByName: func(_ context.Context, _ endpoint, name string, v any) error {
 				ptr, ok := v.(*classDetail)
 				if !ok {
 					return errors.New("wrong type")
@@ -39,7 +39,7 @@ func TestRunClassTool(t *testing.T) {
 				*ptr = class
 				return nil
 			},
-			mockList:   func(_ *http.Client, _ endpoint, v any, _ string) error { return nil },
+			mockList:   func(_ context.Context, _ endpoint, _ string, v any) error { return nil },
 			wantOutput: classToolOutput{Class: &class},
 			wantErr:    false,
 			wantErrMsg: "",
@@ -47,8 +47,8 @@ func TestRunClassTool(t *testing.T) {
 		{
 			name:       "list",
 			input:      classToolInput{},
-			mockByName: func(_ *http.Client, _ endpoint, name string, v any) error { return nil },
-			mockList: func(_ *http.Client, _ endpoint, v any, _ string) error {
+			mockByName: func(_ context.Context, _ endpoint, name string, v any) error { return nil },
+			mockList: func(_ context.Context, _ endpoint, _ string, v any) error {
 				ptr, ok := v.(*[]classListAPIResponse)
 				if !ok {
 					return errors.New("wrong type")
@@ -63,10 +63,10 @@ func TestRunClassTool(t *testing.T) {
 		{
 			name:  "fetchByName error",
 			input: classToolInput{Name: "fail"},
-			mockByName: func(_ *http.Client, _ endpoint, name string, v any) error {
+			mockByName: func(_ context.Context, _ endpoint, name string, v any) error {
 				return errors.New("fetchByName failed")
 			},
-			mockList:   func(_ *http.Client, _ endpoint, v any, _ string) error { return nil },
+			mockList:   func(_ context.Context, _ endpoint, _ string, v any) error { return nil },
 			wantOutput: classToolOutput{},
 			wantErr:    true,
 			wantErrMsg: "fetchByName failed",
@@ -74,8 +74,8 @@ func TestRunClassTool(t *testing.T) {
 		{
 			name:       "fetchList error",
 			input:      classToolInput{},
-			mockByName: func(_ *http.Client, _ endpoint, name string, v any) error { return nil },
-			mockList: func(_ *http.Client, _ endpoint, v any, _ string) error {
+			mockByName: func(_ context.Context, _ endpoint, name string, v any) error { return nil },
+			mockList: func(_ context.Context, _ endpoint, _ string, v any) error {
 				return errors.New("fetchList failed")
 			},
 			wantOutput: classToolOutput{},
@@ -86,7 +86,7 @@ func TestRunClassTool(t *testing.T) {
 
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			res, err := runClassTool(tc.input, tc.mockByName, tc.mockList)
+			res, err := runClassTool(context.Background(), &mockDataSource{get: tc.mockByName, list: tc.mockList}, tc.input)
 			if tc.wantErr {
 				if err == nil {
 					t.Errorf("expected Go error, got nil")
@@ -138,3 +138,135 @@ func TestRunClassTool(t *testing.T) {
 		})
 	}
 }
+
+func TestRunClassToolProgression(t *testing.T) {
+	paladin := classDetail{
+		Index:      "paladin",
+		Name:       "Paladin",
+		HitDie:     10,
+		Subclasses: []apiReference{{Index: "devotion", Name: "Devotion", URL: "/api/2014/subclasses/devotion"}},
+	}
+	classLevels := []classLevel{
+		{Level: 1, ProfBonus: 2, Features: []apiReference{{Index: "divine-sense"}, {Index: "lay-on-hands"}}},
+		{Level: 2, ProfBonus: 2, Features: []apiReference{{Index: "divine-smite"}}, Spellcasting: &levelSpellcasting{SpellSlotsLevel1: 2}},
+		{Level: 3, ProfBonus: 2, Features: []apiReference{{Index: "sacred-oath"}}, Spellcasting: &levelSpellcasting{SpellSlotsLevel1: 3}},
+		{Level: 4, ProfBonus: 2, AbilityScoreBonuses: 1, Features: []apiReference{{Index: "paladin-ability-score-improvement-1"}}, Spellcasting: &levelSpellcasting{SpellSlotsLevel1: 3}},
+		{Level: 5, ProfBonus: 3, AbilityScoreBonuses: 1, Features: []apiReference{{Index: "extra-attack"}}, Spellcasting: &levelSpellcasting{SpellSlotsLevel1: 4, SpellSlotsLevel2: 2}},
+		{Level: 6, ProfBonus: 3, AbilityScoreBonuses: 1, Features: []apiReference{{Index: "aura-of-protection"}}},
+	}
+	subclassLevels := []classLevel{
+		{Level: 3, Features: []apiReference{{Index: "channel-divinity"}, {Index: "sacred-oath"}}, Subclass: &apiReference{Index: "devotion"}},
+		{Level: 7, Features: []apiReference{{Index: "aura-of-devotion"}}, Subclass: &apiReference{Index: "devotion"}},
+	}
+	featureLevels := map[string]int{}
+	for _, l := range append(append([]classLevel(nil), classLevels...), subclassLevels...) {
+		for _, f := range l.Features {
+			featureLevels[f.Index] = l.Level
+		}
+	}
+
+	getFn := func(_ context.Context, e endpoint, index string, v any) error {
+		switch {
+		case e == classes && index == "paladin":
+			*v.(*classDetail) = paladin
+		case e == classes && index == "paladin/levels":
+			*v.(*[]classLevel) = classLevels
+		case e == subclasses && index == "devotion/levels":
+			*v.(*[]classLevel) = subclassLevels
+		case e == features:
+			level, ok := featureLevels[index]
+			if !ok {
+				return errNotFound
+			}
+			*v.(*featureDetail) = featureDetail{Index: index, Name: index, Level: level, Desc: []string{index + " description"}}
+		default:
+			return errNotFound
+		}
+		return nil
+	}
+
+	decode := func(t *testing.T, res *mcp.CallToolResult) classToolOutput {
+		t.Helper()
+		txt, _ := mcp.AsTextContent(res.Content[0])
+		var out classToolOutput
+		if err := json.Unmarshal([]byte(txt.Text), &out); err != nil {
+			t.Fatalf("unmarshal output: %v", err)
+		}
+		return out
+	}
+
+	t.Run("level 5 oath of devotion", func(t *testing.T) {
+		input := classToolInput{Name: "paladin", Level: 5, Subclass: "Oath of Devotion"}
+		res, err := runClassTool(context.Background(), &mockDataSource{get: getFn}, input)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		prog := decode(t, res).Progression
+		if prog == nil {
+			t.Fatalf("expected progression in output")
+		}
+		if prog.Subclass == nil || prog.Subclass.Index != "devotion" {
+			t.Errorf("expected devotion subclass, got %+v", prog.Subclass)
+		}
+		if prog.ProficiencyBonus != 3 || prog.AbilityScoreBonuses != 1 {
+			t.Errorf("unexpected level 5 stats: %+v", prog)
+		}
+		if prog.Spellcasting == nil || prog.Spellcasting.SpellSlotsLevel2 != 2 {
+			t.Errorf("unexpected spellcasting: %+v", prog.Spellcasting)
+		}
+		var got []string
+		for _, f := range prog.Features {
+			if len(f.Desc) == 0 {
+				t.Errorf("feature %q not resolved", f.Index)
+			}
+			got = append(got, f.Index)
+		}
+		want := []string{"divine-sense", "lay-on-hands", "divine-smite", "sacred-oath", "channel-divinity", "paladin-ability-score-improvement-1", "extra-attack"}
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("expected features %v, got %v", want, got)
+		}
+	})
+
+	t.Run("class only defaults to subclass-free progression", func(t *testing.T) {
+		res, err := runClassTool(context.Background(), &mockDataSource{get: getFn}, classToolInput{Name: "paladin", Level: 2})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		prog := decode(t, res).Progression
+		if prog == nil || prog.Subclass != nil || len(prog.Features) != 3 {
+			t.Errorf("unexpected progression: %+v", prog)
+		}
+	})
+
+	t.Run("unknown subclass", func(t *testing.T) {
+		res, err := runClassTool(context.Background(), &mockDataSource{get: getFn}, classToolInput{Name: "paladin", Level: 3, Subclass: "vengeance"})
+		if !errors.Is(err, errNotFound) || res == nil || !res.IsError {
+			t.Errorf("expected not found error result, got %+v, %v", res, err)
+		}
+	})
+
+	t.Run("level out of range", func(t *testing.T) {
+		res, err := runClassTool(context.Background(), &mockDataSource{get: getFn}, classToolInput{Name: "paladin", Level: 21})
+		if err == nil || res == nil || !res.IsError {
+			t.Errorf("expected error result, got %+v, %v", res, err)
+		}
+	})
+}
+
+func TestFindSubclass(t *testing.T) {
+	refs := []apiReference{{Index: "devotion", Name: "Devotion"}, {Index: "open-hand", Name: "Open Hand"}}
+	cases := map[string]string{
+		"devotion":             "devotion",
+		"Devotion":             "devotion",
+		"Oath of Devotion":     "devotion",
+		"Way of the Open Hand": "open-hand",
+		"open hand":            "open-hand",
+		"vengeance":            "",
+	}
+	for name, want := range cases {
+		ref, ok := findSubclass(refs, name)
+		if ok != (want != "") || ref.Index != want {
+			t.Errorf("findSubclass(%q) = %q, %v; want %q", name, ref.Index, ok, want)
+		}
+	}
+}
@@ -0,0 +1,323 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// errCircuitOpen is returned when the circuit breaker rejects a request because the upstream API is failing.
+var errCircuitOpen = errors.New("upstream API unavailable: circuit breaker open")
+
+// clientConfig configures the resilience behaviour of the HTTP client used to reach the D&D 5e API.
+type clientConfig struct {
+	// MaxRetries is the number of times a failed request is retried. Zero disables retries.
+	MaxRetries int
+	// BaseBackoff is the initial backoff, doubled on every retry.
+	BaseBackoff time.Duration
+	// MaxBackoff caps the backoff and the longest Retry-After delay that will be honored.
+	MaxBackoff time.Duration
+	// RateLimit is the sustained number of requests per second. Zero disables rate limiting.
+	RateLimit float64
+	// RateBurst is the number of requests that may be made at once before rate limiting applies.
+	RateBurst int
+	// BreakerThreshold is the number of consecutive failures that opens the circuit. Zero disables the breaker.
+	BreakerThreshold int
+	// BreakerCooldown is how long the circuit stays open before a trial request is allowed.
+	BreakerCooldown time.Duration
+}
+
+// defaultClientConfig returns the client configuration used when none is specified.
+func defaultClientConfig() clientConfig {
+	return clientConfig{
+		MaxRetries:       3,
+		BaseBackoff:      200 * time.Millisecond,
+		MaxBackoff:       5 * time.Second,
+		RateLimit:        10,
+		RateBurst:        5,
+		BreakerThreshold: 5,
+		BreakerCooldown:  30 * time.Second,
+	}
+}
+
+// newAPIClient creates an HTTP client that sends requests with base and applies retries, rate limiting and
+// circuit breaking on top. The limiter and breaker are shared by every request made through the client.
+func newAPIClient(cfg clientConfig, base http.RoundTripper) *http.Client {
+	return &http.Client{Transport: newResilientTransport(base, cfg)}
+}
+
+// resilientTransport is an http.RoundTripper that retries transient failures with exponential
+// backoff and jitter, honors Retry-After, rate limits requests and fails fast while the upstream is down.
+type resilientTransport struct {
+	next    http.RoundTripper
+	cfg     clientConfig
+	limiter *tokenBucket
+	breaker *circuitBreaker
+}
+
+// newResilientTransport wraps next with the behaviour described by cfg.
+func newResilientTransport(next http.RoundTripper, cfg clientConfig) *resilientTransport {
+	t := &resilientTransport{next: next, cfg: cfg}
+	if cfg.RateLimit > 0 {
+		t.limiter = newTokenBucket(cfg.RateLimit, cfg.RateBurst)
+	}
+	if cfg.BreakerThreshold > 0 {
+		t.breaker = newCircuitBreaker(cfg.BreakerThreshold, cfg.BreakerCooldown)
+	}
+	return t
+}
+
+// RoundTrip sends the request, retrying idempotent requests on network errors, 429 and 5xx responses.
+func (t *resilientTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	retryable := req.Method == http.MethodGet || req.Method == http.MethodHead
+	for attempt := 0; ; attempt++ {
+		var trial bool
+		if t.breaker != nil {
+			var err error
+			if trial, err = t.breaker.allow(); err != nil {
+				return nil, err
+			}
+		}
+		if t.limiter != nil {
+			if err := t.limiter.wait(ctx); err != nil {
+				return nil, err
+			}
+		}
+
+		resp, err := t.next.RoundTrip(req)
+		if t.breaker != nil {
+			if ctx.Err() != nil {
+				t.breaker.abort(trial)
+			} else {
+				t.breaker.record(ctx, trial, err != nil || resp.StatusCode >= 500)
+			}
+		}
+		if !retryable || attempt >= t.cfg.MaxRetries || !shouldRetry(resp, err) {
+			return resp, err
+		}
+
+		delay := t.backoff(attempt)
+		if resp != nil {
+			if after, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+				if after > t.cfg.MaxBackoff {
+					return resp, nil
+				}
+				delay = after
+			}
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		logFrom(ctx).WithFields(logrus.Fields{
+			"url":     req.URL.String(),
+			"attempt": attempt + 1,
+			"delay":   delay,
+			"error":   err,
+			"status":  statusOf(resp),
+		}).Warn("Retrying API request")
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns the delay before the given retry attempt: exponential growth capped at
+// MaxBackoff, with full jitter so concurrent callers do not retry in lockstep.
+func (t *resilientTransport) backoff(attempt int) time.Duration {
+	d := t.cfg.BaseBackoff << attempt
+	if d <= 0 || d > t.cfg.MaxBackoff {
+		d = t.cfg.MaxBackoff
+	}
+	if d <= 0 {
+		return 0
+	}
+	return rand.N(d) + 1
+}
+
+// shouldRetry reports whether a request that produced resp or err is worth retrying.
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
+
+// statusOf returns the status code of resp, or 0 if there is no response.
+func statusOf(resp *http.Response) int {
+	if resp == nil {
+		return 0
+	}
+	return resp.StatusCode
+}
+
+// parseRetryAfter parses a Retry-After header given either as delay-seconds or as an HTTP date.
+func parseRetryAfter(v string, now time.Time) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	if at, err := http.ParseTime(v); err == nil {
+		d := at.Sub(now)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
+
+// tokenBucket is a token-bucket rate limiter safe for concurrent use.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// newTokenBucket creates a limiter allowing rate requests per second with bursts of up to burst requests.
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// refill adds the tokens accrued since the last refill. The caller must hold b.mu.
+func (b *tokenBucket) refill() {
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+}
+
+// allow takes a token if one is available and reports whether it did, without waiting.
+func (b *tokenBucket) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// wait blocks until a token is available or ctx is done.
+func (b *tokenBucket) wait(ctx context.Context) error {
+	for {
+		b.mu.Lock()
+		b.refill()
+		if b.tokens >= 1 {
+			b.tokens--
+			b.mu.Unlock()
+			return nil
+		}
+		delay := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+		b.mu.Unlock()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// circuitBreaker opens after a run of consecutive failures and rejects requests until a cooldown passes.
+// After the cooldown a single trial request is let through; its outcome closes or reopens the circuit.
+// While the circuit is open, the outcomes of requests that were let through before it opened are ignored.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openUntil time.Time
+	trial     bool
+}
+
+// newCircuitBreaker creates a breaker that opens after threshold consecutive failures.
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown}
+}
+
+// allow returns errCircuitOpen if the request must be rejected. It reports whether the request is the
+// trial request of an open circuit, which the caller passes back to record or abort.
+func (b *circuitBreaker) allow() (trial bool, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures < b.threshold {
+		return false, nil
+	}
+	if remaining := time.Until(b.openUntil); remaining > 0 {
+		return false, fmt.Errorf("%w (retry in %s)", errCircuitOpen, remaining.Round(time.Second))
+	}
+	if b.trial {
+		return false, fmt.Errorf("%w (trial request in progress)", errCircuitOpen)
+	}
+	b.trial = true
+	return true, nil
+}
+
+// record updates the breaker with the outcome of a request made within ctx, logging to ctx when the circuit
+// opens or closes. While the circuit is open only the trial request's outcome counts.
+func (b *circuitBreaker) record(ctx context.Context, trial, failed bool) {
+	b.mu.Lock()
+	if b.failures >= b.threshold && !trial {
+		b.mu.Unlock()
+		return
+	}
+	b.trial = false
+	wasOpen := b.failures >= b.threshold
+	if failed {
+		b.failures++
+	} else {
+		b.failures = 0
+	}
+	failures, open := b.failures, b.failures >= b.threshold
+	if open {
+		b.openUntil = time.Now().Add(b.cooldown)
+	}
+	b.mu.Unlock()
+
+	// Logged after unlocking, since the entry may be forwarded to the client.
+	switch {
+	case wasOpen && !open:
+		logFrom(ctx).Info("Circuit breaker closed: upstream API recovered")
+	case open:
+		logFrom(ctx).WithFields(logrus.Fields{"failures": failures, "cooldown": b.cooldown}).Warn("Circuit breaker open: upstream API failing")
+	}
+}
+
+// abort releases the trial request without counting its outcome, e.g. when the caller cancelled it.
+// Requests that are not the trial are ignored.
+func (b *circuitBreaker) abort(trial bool) {
+	if !trial {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus/hooks/test"
+)
+
+func testClientConfig() clientConfig {
+	return clientConfig{
+		MaxRetries:  3,
+		BaseBackoff: time.Millisecond,
+		MaxBackoff:  10 * time.Millisecond,
+	}
+}
+
+func TestResilientTransport_Retries(t *testing.T) {
+	cases := []struct {
+		name         string
+		statuses     []int
+		retryAfter   string
+		wantStatus   int
+		wantRequests int32
+	}{
+		{"success first try", []int{200}, "", 200, 1},
+		{"retry 503 then success", []int{503, 503, 200}, "", 200, 3},
+		{"retry 429 with Retry-After", []int{429, 200}, "0", 200, 2},
+		{"gives up after max retries", []int{500, 500, 500, 500, 500}, "", 500, 4},
+		{"does not retry 404", []int{404, 200}, "", 404, 1},
+		{"Retry-After beyond max backoff", []int{429, 200}, "120", 429, 1},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var requests atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := requests.Add(1)
+				if tc.retryAfter != "" {
+					w.Header().Set("Retry-After", tc.retryAfter)
+				}
+				w.WriteHeader(tc.statuses[n-1])
+			}))
+			defer srv.Close()
+
+			client := &http.Client{Transport: newResilientTransport(http.DefaultTransport, testClientConfig())}
+			resp, err := client.Get(srv.URL)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tc.wantStatus {
+				t.Errorf("expected status %d, got %d", tc.wantStatus, resp.StatusCode)
+			}
+			if requests.Load() != tc.wantRequests {
+				t.Errorf("expected %d requests, got %d", tc.wantRequests, requests.Load())
+			}
+		})
+	}
+}
+
+func TestResilientTransport_CancelDuringBackoff(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	cfg := testClientConfig()
+	cfg.BaseBackoff, cfg.MaxBackoff = time.Hour, time.Hour
+	client := &http.Client{Transport: newResilientTransport(http.DefaultTransport, cfg)}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	if _, err := client.Do(req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestResilientTransport_CircuitBreaker(t *testing.T) {
+	var healthy atomic.Bool
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer srv.Close()
+
+	cfg := testClientConfig()
+	cfg.MaxRetries = 0
+	cfg.BreakerThreshold = 2
+	cfg.BreakerCooldown = 30 * time.Millisecond
+	client := &http.Client{Transport: newResilientTransport(http.DefaultTransport, cfg)}
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		resp.Body.Close()
+	}
+	if _, err := client.Get(srv.URL); !errors.Is(err, errCircuitOpen) {
+		t.Fatalf("expected errCircuitOpen, got %v", err)
+	}
+	if requests.Load() != 2 {
+		t.Errorf("expected open circuit to skip upstream, got %d requests", requests.Load())
+	}
+
+	healthy.Store(true)
+	time.Sleep(40 * time.Millisecond)
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("expected trial request to succeed, got %v", err)
+	}
+	resp.Body.Close()
+	resp, err = client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("expected closed circuit, got %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestCircuitBreaker_OnlyTrialChangesOpenCircuit(t *testing.T) {
+	b := newCircuitBreaker(1, 10*time.Millisecond)
+	// A slow request is let through while the circuit is closed, then another request opens it.
+	slow, err := b.allow()
+	if slow || err != nil {
+		t.Fatalf("expected a closed circuit, got trial=%v err=%v", slow, err)
+	}
+	b.record(context.Background(), false, true)
+	if _, err := b.allow(); !errors.Is(err, errCircuitOpen) {
+		t.Fatalf("expected errCircuitOpen, got %v", err)
+	}
+
+	// The slow request succeeding does not close the circuit.
+	b.record(context.Background(), slow, false)
+	if _, err := b.allow(); !errors.Is(err, errCircuitOpen) {
+		t.Fatalf("expected the circuit to stay open, got %v", err)
+	}
+
+	time.Sleep(15 * time.Millisecond)
+	trial, err := b.allow()
+	if !trial || err != nil {
+		t.Fatalf("expected a trial request, got trial=%v err=%v", trial, err)
+	}
+	// Neither the slow request finishing nor being aborted releases the trial.
+	b.record(context.Background(), slow, false)
+	b.abort(slow)
+	if _, err := b.allow(); !errors.Is(err, errCircuitOpen) {
+		t.Fatalf("expected the trial to be in progress, got %v", err)
+	}
+	b.record(context.Background(), trial, false)
+	if trial, err := b.allow(); trial || err != nil {
+		t.Fatalf("expected the trial's success to close the circuit, got trial=%v err=%v", trial, err)
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	b := newTokenBucket(50, 2)
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if err := b.wait(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	// Two tokens are available immediately; the next two take 20ms each at 50/s.
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Errorf("expected rate limiting to delay requests, took %v", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	empty := newTokenBucket(0.001, 1)
+	empty.wait(context.Background())
+	if err := empty.wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{"", 0, false},
+		{"5", 5 * time.Second, true},
+		{"-1", 0, false},
+		{"Wed, 01 Jan 2025 00:00:10 GMT", 10 * time.Second, true},
+		{"Tue, 31 Dec 2024 23:59:00 GMT", 0, true},
+		{"soon", 0, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.value, func(t *testing.T) {
+			got, ok := parseRetryAfter(tc.value, now)
+			if got != tc.want || ok != tc.wantOK {
+				t.Errorf("parseRetryAfter(%q) = (%v, %v), want (%v, %v)", tc.value, got, ok, tc.want, tc.wantOK)
+			}
+		})
+	}
+}
+
+func TestCircuitBreaker_LogsToRequest(t *testing.T) {
+	saveStandardLogger(t)
+	hook := test.NewGlobal()
+	b := newCircuitBreaker(1, time.Hour)
+	ctx := withRequestLog(context.Background(), requestLog{id: "req-1", tool: "spells"})
+	b.record(ctx, false, true)
+	entry := hook.LastEntry()
+	if entry == nil || entry.Message != "Circuit breaker open: upstream API failing" || entry.Data["request_id"] != "req-1" {
+		t.Errorf("expected the breaker opening to be logged with the request ID, got %+v", entry)
+	}
+}
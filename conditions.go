@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// conditionToolInput defines the input structure for the conditions tool.
+type conditionToolInput struct {
+	Name     string   `json:"name" mcp:"description=The index of the condition to retrieve (e.g., 'grappled')."`
+	Monsters []string `json:"monsters" mcp:"description=Monster names or indexes to check for immunity to the condition (e.g., ['zombie', 'ghoul'])."`
+}
+
+// conditionListAPIResponse defines the structure for a single condition in the list response.
+type conditionListAPIResponse struct {
+	Index string `json:"index"`
+	Name  string `json:"name"`
+	URL   string `json:"url"`
+}
+
+// conditionDetail defines the structure for a detailed condition response.
+// The 2014 ruleset describes the condition in Desc, the 2024 ruleset in Description.
+type conditionDetail struct {
+	Index       string     `json:"index"`
+	Name        string     `json:"name"`
+	Desc        paragraphs `json:"desc,omitempty"`
+	Description string     `json:"description,omitempty"`
+	URL         string     `json:"url"`
+}
+
+// conditionToolOutput defines the output structure for the conditions tool.
+type conditionToolOutput struct {
+	Count           int                        `json:"count,omitempty"`
+	Results         []conditionListAPIResponse `json:"results,omitempty"`
+	Condition       *conditionDetail           `json:"condition,omitempty"`
+	CheckedMonsters int                        `json:"checked_monsters,omitempty"`
+	ImmuneMonsters  []monsterListAPIResponse   `json:"immune_monsters,omitempty"`
+	UnknownMonsters []unresolvedName           `json:"unknown_monsters,omitempty"`
+}
+
+// unresolvedName is a requested name that matches no resource, with the reason and any suggestions.
+type unresolvedName struct {
+	Name  string `json:"name"`
+	Error string `json:"error"`
+}
+
+// fetchMonstersByName fetches the monsters with the given names, resolving misspellings as the monsters tool
+// does. Names that match no monster are returned separately rather than failing the call.
+func fetchMonstersByName(ctx context.Context, src dataSource, names []string) ([]monsterDetail, []unresolvedName, error) {
+	details := make([]monsterDetail, len(names))
+	errs := make([]error, len(names))
+	err := forEachConcurrently(ctx, len(names), maxConcurrentFetches, func(ctx context.Context, i int) error {
+		err := getByName(ctx, src, monsters, names[i], &details[i])
+		if errors.Is(err, errNotFound) {
+			errs[i] = err
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	var found []monsterDetail
+	var unknown []unresolvedName
+	for i, name := range names {
+		if errs[i] != nil {
+			unknown = append(unknown, unresolvedName{Name: name, Error: errs[i].Error()})
+			continue
+		}
+		found = append(found, details[i])
+	}
+	return found, unknown, nil
+}
+
+// immuneMonsters returns the monsters that list the condition among their condition immunities.
+func immuneMonsters(condition string, candidates []monsterDetail) []monsterListAPIResponse {
+	var immune []monsterListAPIResponse
+	for _, m := range candidates {
+		for _, ci := range m.ConditionImmunities {
+			if ci.Index == condition {
+				immune = append(immune, monsterListAPIResponse{Index: m.Index, Name: m.Name, URL: m.URL})
+				break
+			}
+		}
+	}
+	return immune
+}
+
+// fetchConditionByNameResult fetches a condition by index, checks the requested monsters for immunity, and returns an MCP tool result.
+func fetchConditionByNameResult(
+	ctx context.Context,
+	src dataSource,
+	input conditionToolInput,
+) (*mcp.CallToolResult, error) {
+	condition := &conditionDetail{}
+	err := fetchByName(ctx, src, conditions, input.Name, condition)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to fetch condition", err), err
+	}
+	output := conditionToolOutput{Condition: condition}
+	if len(input.Monsters) > 0 {
+		candidates, unknown, err := fetchMonstersByName(ctx, src, input.Monsters)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("failed to fetch monsters", err), err
+		}
+		output.CheckedMonsters = len(candidates)
+		output.ImmuneMonsters = immuneMonsters(condition.Index, candidates)
+		output.UnknownMonsters = unknown
+	}
+	jsonData, err := json.Marshal(output)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal condition output", err), err
+	}
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+// fetchConditionListResult fetches a list of conditions and returns an MCP tool result.
+func fetchConditionListResult(
+	ctx context.Context,
+	src dataSource,
+) (*mcp.CallToolResult, error) {
+	var results []conditionListAPIResponse
+	err := fetchList(ctx, src, conditions, &results, "")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to fetch condition list", err), err
+	}
+	output := conditionToolOutput{Count: len(results), Results: results}
+	jsonData, err := json.Marshal(output)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal condition list output", err), err
+	}
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+// runConditionTool executes the core logic for the conditions tool.
+func runConditionTool(ctx context.Context, src dataSource, input conditionToolInput) (*mcp.CallToolResult, error) {
+	if input.Name != "" {
+		return fetchConditionByNameResult(ctx, src, input)
+	}
+	return fetchConditionListResult(ctx, src)
+}
+
+// handleConditionTool returns the MCP handler for the conditions tool.
+func handleConditionTool(src dataSource) mcp.TypedToolHandlerFunc[conditionToolInput] {
+	return func(ctx context.Context, req mcp.CallToolRequest, input conditionToolInput) (*mcp.CallToolResult, error) {
+		return runConditionTool(ctx, src, input)
+	}
+}
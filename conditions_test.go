@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestRunConditionTool(t *testing.T) {
+	condition := conditionDetail{
+		Index: "poisoned",
+		Name:  "Poisoned",
+		Desc:  []string{"- A poisoned creature has disadvantage on attack rolls and ability checks."},
+		URL:   "/api/2014/conditions/poisoned",
+	}
+	list := []conditionListAPIResponse{{Index: "poisoned", Name: "Poisoned", URL: "/api/2014/conditions/poisoned"}}
+	bestiary := map[string]monsterDetail{
+		"zombie": {Index: "zombie", Name: "Zombie", ConditionImmunities: []apiReference{{Index: "poisoned", Name: "Poisoned"}}},
+		"goblin": {Index: "goblin", Name: "Goblin"},
+		"ghoul":  {Index: "ghoul", Name: "Ghoul", ConditionImmunities: []apiReference{{Index: "charmed"}, {Index: "poisoned"}}},
+	}
+	getFn := func(_ context.Context, e endpoint, index string, v any) error {
+		switch e {
+		case conditions:
+			*v.(*conditionDetail) = condition
+			return nil
+		case monsters:
+			m, ok := bestiary[index]
+			if !ok {
+				return errNotFound
+			}
+			*v.(*monsterDetail) = m
+			return nil
+		}
+		return errors.New("unexpected endpoint")
+	}
+
+	cases := []struct {
+		name        string
+		input       conditionToolInput
+		mockByName  func(context.Context, endpoint, string, any) error
+		mockList    func(context.Context, endpoint, string, any) error
+		wantOutput  conditionToolOutput
+		wantErr     bool
+		wantErrMsg  string
+		wantImmune  []string
+		wantChecked int
+		wantUnknown []string
+	}{
+		{
+			name:       "by name",
+			input:      conditionToolInput{Name: "poisoned"},
+			mockByName: getFn,
+			mockList:   func(_ context.Context, _ endpoint, _ string, v any) error { return nil },
+			wantOutput: conditionToolOutput{Condition: &condition},
+		},
+		{
+			name:        "by name with monster immunities",
+			input:       conditionToolInput{Name: "poisoned", Monsters: []string{"zombie", "Goblin", "ghoul"}},
+			mockByName:  getFn,
+			mockList:    func(_ context.Context, _ endpoint, _ string, v any) error { return nil },
+			wantOutput:  conditionToolOutput{Condition: &condition},
+			wantImmune:  []string{"zombie", "ghoul"},
+			wantChecked: 3,
+		},
+		{
+			name:       "misspelled and unknown monsters",
+			input:      conditionToolInput{Name: "poisoned", Monsters: []string{"Zombie", "Ghoule", "tarrasque-prime"}},
+			mockByName: getFn,
+			mockList: func(_ context.Context, _ endpoint, _ string, v any) error {
+				var refs []apiReference
+				for _, m := range bestiary {
+					refs = append(refs, apiReference{Index: m.Index, Name: m.Name})
+				}
+				return setJSON(v, refs)
+			},
+			wantOutput:  conditionToolOutput{Condition: &condition},
+			wantImmune:  []string{"zombie", "ghoul"},
+			wantChecked: 2,
+			wantUnknown: []string{"tarrasque-prime"},
+		},
+		{
+			name:  "monster fetch error",
+			input: conditionToolInput{Name: "poisoned", Monsters: []string{"zombie"}},
+			mockByName: func(ctx context.Context, e endpoint, index string, v any) error {
+				if e == monsters {
+					return errors.New("upstream down")
+				}
+				return getFn(ctx, e, index, v)
+			},
+			wantErr:    true,
+			wantErrMsg: "upstream down",
+		},
+		{
+			name:       "list",
+			input:      conditionToolInput{},
+			mockByName: func(_ context.Context, _ endpoint, name string, v any) error { return nil },
+			mockList: func(_ context.Context, _ endpoint, _ string, v any) error {
+				ptr, ok := v.(*[]conditionListAPIResponse)
+				if !ok {
+					return errors.New("wrong type")
+				}
+				*ptr = list
+				return nil
+			},
+			wantOutput: conditionToolOutput{Count: len(list), Results: list},
+		},
+		{
+			name:  "fetchByName error",
+			input: conditionToolInput{Name: "fail"},
+			mockByName: func(_ context.Context, _ endpoint, name string, v any) error {
+				return errors.New("fetchByName failed")
+			},
+			mockList:   func(_ context.Context, _ endpoint, _ string, v any) error { return nil },
+			wantErr:    true,
+			wantErrMsg: "fetchByName failed",
+		},
+		{
+			name:       "fetchList error",
+			input:      conditionToolInput{},
+			mockByName: func(_ context.Context, _ endpoint, name string, v any) error { return nil },
+			mockList: func(_ context.Context, _ endpoint, _ string, v any) error {
+				return errors.New("fetchList failed")
+			},
+			wantErr:    true,
+			wantErrMsg: "fetchList failed",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := runConditionTool(context.Background(), &mockDataSource{get: tc.mockByName, list: tc.mockList}, tc.input)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("expected Go error, got nil")
+				}
+				if res == nil || !res.IsError {
+					t.Fatalf("expected MCP error result, got %+v", res)
+				}
+				if len(res.Content) > 0 {
+					txt, ok := mcp.AsTextContent(res.Content[0])
+					if !ok {
+						t.Fatalf("content is not TextContent, got %T", res.Content[0])
+					}
+					if tc.wantErrMsg != "" && !strings.Contains(txt.Text, tc.wantErrMsg) {
+						t.Errorf("expected error message to contain %q, got %q", tc.wantErrMsg, txt.Text)
+					}
+				}
+				return
+			}
+			if res == nil || len(res.Content) == 0 {
+				t.Fatalf("unexpected empty result: %+v", res)
+			}
+			txt, ok := mcp.AsTextContent(res.Content[0])
+			if !ok {
+				t.Fatalf("content is not TextContent, got %T", res.Content[0])
+			}
+			var out conditionToolOutput
+			if err := json.Unmarshal([]byte(txt.Text), &out); err != nil {
+				t.Fatalf("unmarshal output: %v", err)
+			}
+			if tc.input.Name != "" {
+				if out.Condition == nil || out.Condition.Name != tc.wantOutput.Condition.Name {
+					t.Errorf("expected condition name %q, got %+v", tc.wantOutput.Condition.Name, out.Condition)
+				}
+				if out.CheckedMonsters != tc.wantChecked {
+					t.Errorf("expected %d checked monsters, got %d", tc.wantChecked, out.CheckedMonsters)
+				}
+				var immune []string
+				for _, m := range out.ImmuneMonsters {
+					immune = append(immune, m.Index)
+				}
+				if strings.Join(immune, ",") != strings.Join(tc.wantImmune, ",") {
+					t.Errorf("expected immune monsters %v, got %v", tc.wantImmune, immune)
+				}
+				var unknown []string
+				for _, u := range out.UnknownMonsters {
+					unknown = append(unknown, u.Name)
+					if !strings.Contains(u.Error, "no monsters named") {
+						t.Errorf("expected the reason %s is unknown, got %q", u.Name, u.Error)
+					}
+				}
+				if strings.Join(unknown, ",") != strings.Join(tc.wantUnknown, ",") {
+					t.Errorf("expected unknown monsters %v, got %v", tc.wantUnknown, unknown)
+				}
+			} else {
+				if out.Count != tc.wantOutput.Count || len(out.Results) != len(tc.wantOutput.Results) {
+					t.Errorf("expected %d conditions, got count=%d, results=%d", tc.wantOutput.Count, out.Count, len(out.Results))
+				}
+			}
+		})
+	}
+}
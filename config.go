@@ -0,0 +1,414 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// configEnvPrefix prefixes the environment variable of every setting, e.g. DND5E_MCP_API_BASE_URL.
+	configEnvPrefix = "DND5E_MCP_"
+	// configFileEnv names the configuration file when the -config flag is not given.
+	configFileEnv = configEnvPrefix + "CONFIG"
+)
+
+// config is the server's configuration. It is built in layers, each overriding the one before: the
+// defaults, a YAML, JSON or TOML file, DND5E_MCP_* environment variables and command-line flags.
+//
+// Every leaf field is a setting. Its key in the file comes from the yaml and toml tags, its environment
+// variable from the section and key (server.name is DND5E_MCP_SERVER_NAME), and its flag from the flag tag.
+type config struct {
+	Server    serverSettings    `yaml:"server" toml:"server"`
+	API       apiSettings       `yaml:"api" toml:"api"`
+	Cache     cacheSettings     `yaml:"cache" toml:"cache"`
+	Transport transportSettings `yaml:"transport" toml:"transport"`
+	Log       logSettings       `yaml:"log" toml:"log"`
+	Metrics   metricsSettings   `yaml:"metrics" toml:"metrics"`
+	Tools     toolSettings      `yaml:"tools" toml:"tools"`
+}
+
+// serverSettings configure what the server serves.
+type serverSettings struct {
+	Name     string `yaml:"name" toml:"name" flag:"server-name" usage:"Server name reported to MCP clients."`
+	Version  string `yaml:"version" toml:"version" flag:"server-version" usage:"Server version reported to MCP clients."`
+	Ruleset  string `yaml:"ruleset" toml:"ruleset" flag:"ruleset" usage:"Default ruleset for tool calls, 2014 or 2024; calls can override it with the ruleset argument. Defaults to the snapshot's ruleset, or 2014."`
+	Snapshot string `yaml:"snapshot" toml:"snapshot" flag:"snapshot" usage:"Serve every tool from the SRD snapshot at this path (directory or .zip) instead of the live API."`
+	Homebrew string `yaml:"homebrew" toml:"homebrew" flag:"homebrew" usage:"Directory of homebrew spells, monsters and magic items (JSON or YAML) to merge into the results."`
+}
+
+// apiSettings configure requests to the upstream API.
+type apiSettings struct {
+	BaseURL          string   `yaml:"base_url" toml:"base_url" flag:"api-url" usage:"Root URL of the D&D 5e API, without the ruleset."`
+	Timeout          duration `yaml:"timeout" toml:"timeout" flag:"request-timeout" usage:"How long a single API request may take."`
+	MaxRetries       int      `yaml:"max_retries" toml:"max_retries" flag:"max-retries" usage:"Maximum number of retries for failed API requests."`
+	BaseBackoff      duration `yaml:"base_backoff" toml:"base_backoff" flag:"base-backoff" usage:"Backoff before the first retry, doubled on every retry."`
+	MaxBackoff       duration `yaml:"max_backoff" toml:"max_backoff" flag:"max-backoff" usage:"Longest backoff, and longest Retry-After delay honored."`
+	RateLimit        float64  `yaml:"rate_limit" toml:"rate_limit" flag:"rate-limit" usage:"Maximum sustained API requests per second; 0 disables rate limiting."`
+	RateBurst        int      `yaml:"rate_burst" toml:"rate_burst" flag:"rate-burst" usage:"Number of API requests allowed in a burst."`
+	BreakerThreshold int      `yaml:"breaker_threshold" toml:"breaker_threshold" flag:"breaker-threshold" usage:"Consecutive API failures that open the circuit breaker; 0 disables it."`
+	BreakerCooldown  duration `yaml:"breaker_cooldown" toml:"breaker_cooldown" flag:"breaker-cooldown" usage:"How long the circuit breaker stays open before retrying the API."`
+}
+
+// cacheSettings configure the API response cache.
+type cacheSettings struct {
+	Size int      `yaml:"size" toml:"size" flag:"cache-size" usage:"Maximum number of API responses kept in the in-memory cache; 0 disables caching."`
+	TTL  duration `yaml:"ttl" toml:"ttl" flag:"cache-ttl" usage:"How long cached API responses are served before being revalidated."`
+	Dir  string   `yaml:"dir" toml:"dir" flag:"cache-dir" usage:"Directory for the persistent on-disk response cache; empty keeps the cache in memory only."`
+}
+
+// transportSettings configure how clients reach the server.
+type transportSettings struct {
+	Type            string   `yaml:"type" toml:"type" flag:"transport" usage:"How clients connect: stdio, sse (HTTP with server-sent events) or http (streamable HTTP)."`
+	Addr            string   `yaml:"addr" toml:"addr" flag:"addr" usage:"Listen address of the sse and http transports."`
+	BasePath        string   `yaml:"base_path" toml:"base_path" flag:"base-path" usage:"URL path prefix of the sse and http transports' endpoints, e.g. /dnd."`
+	ShutdownTimeout duration `yaml:"shutdown_timeout" toml:"shutdown_timeout" flag:"shutdown-timeout" usage:"How long open requests of the sse and http transports get to finish on shutdown."`
+	APIKeys         string   `yaml:"api_keys" toml:"api_keys" flag:"api-keys" usage:"File of API keys (YAML or JSON) required to call the sse and http transports, with each key's quotas."`
+	AuditLog        string   `yaml:"audit_log" toml:"audit_log" flag:"audit-log" usage:"File to append audit entries of authenticated tool calls to, as JSON lines; empty writes them to the server log."`
+}
+
+// logSettings configure the server log.
+type logSettings struct {
+	Level  string `yaml:"level" toml:"level" flag:"log-level" usage:"Minimum level of log entries: trace, debug, info, warn or error."`
+	Format string `yaml:"format" toml:"format" flag:"log-format" usage:"Format of log entries: text or json."`
+	Output string `yaml:"output" toml:"output" flag:"log-output" usage:"Where log entries are written: stderr, stdout (sse and http transports only) or a file to append to."`
+}
+
+// metricsSettings configure the Prometheus metrics listener.
+type metricsSettings struct {
+	Addr string `yaml:"addr" toml:"addr" flag:"metrics-addr" usage:"Listen address serving Prometheus metrics at /metrics, e.g. localhost:9090; empty disables metrics."`
+}
+
+// toolSettings select which tools are registered.
+type toolSettings struct {
+	Enabled  []string `yaml:"enabled" toml:"enabled" flag:"tools" usage:"Comma-separated names of the only tools to register; empty registers every tool."`
+	Disabled []string `yaml:"disabled" toml:"disabled" flag:"disable-tools" usage:"Comma-separated names of tools not to register."`
+}
+
+// defaultConfig returns the configuration used for settings that are not configured anywhere.
+func defaultConfig() config {
+	client := defaultClientConfig()
+	return config{
+		Server: serverSettings{
+			Name:    "D&D 5e Knowledge Base",
+			Version: "1.0.0",
+		},
+		API: apiSettings{
+			BaseURL:          defaultAPIBaseURL,
+			Timeout:          duration(defaultRequestTimeout),
+			MaxRetries:       client.MaxRetries,
+			BaseBackoff:      duration(client.BaseBackoff),
+			MaxBackoff:       duration(client.MaxBackoff),
+			RateLimit:        client.RateLimit,
+			RateBurst:        client.RateBurst,
+			BreakerThreshold: client.BreakerThreshold,
+			BreakerCooldown:  duration(client.BreakerCooldown),
+		},
+		Cache: cacheSettings{
+			Size: 2048,
+			TTL:  duration(24 * time.Hour),
+		},
+		Transport: transportSettings{
+			Type:            string(transportStdio),
+			Addr:            defaultListenAddr,
+			ShutdownTimeout: duration(defaultShutdownTimeout),
+		},
+		Log: logSettings{
+			Level:  "info",
+			Format: "text",
+			Output: logOutputStderr,
+		},
+	}
+}
+
+// clientConfig returns the settings of the resilient API client.
+func (a apiSettings) clientConfig() clientConfig {
+	return clientConfig{
+		MaxRetries:       a.MaxRetries,
+		BaseBackoff:      time.Duration(a.BaseBackoff),
+		MaxBackoff:       time.Duration(a.MaxBackoff),
+		RateLimit:        a.RateLimit,
+		RateBurst:        a.RateBurst,
+		BreakerThreshold: a.BreakerThreshold,
+		BreakerCooldown:  time.Duration(a.BreakerCooldown),
+	}
+}
+
+// duration is a time.Duration written as a string such as "10s" or "1h30m" in configuration files.
+type duration time.Duration
+
+// UnmarshalText parses a Go duration string.
+func (d *duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = duration(v)
+	return nil
+}
+
+// MarshalText formats the duration as a Go duration string.
+func (d duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// setting is a single configurable value of a config.
+type setting struct {
+	key   string // dotted file key, e.g. api.base_url
+	env   string
+	flag  string
+	usage string
+	value reflect.Value
+}
+
+// settings returns every setting of c, with values that write through to c.
+func (c *config) settings() []setting {
+	var out []setting
+	sections := reflect.ValueOf(c).Elem()
+	for i := 0; i < sections.NumField(); i++ {
+		section := strings.Split(sections.Type().Field(i).Tag.Get("yaml"), ",")[0]
+		fields := sections.Field(i)
+		for j := 0; j < fields.NumField(); j++ {
+			f := fields.Type().Field(j)
+			name := strings.Split(f.Tag.Get("yaml"), ",")[0]
+			out = append(out, setting{
+				key:   section + "." + name,
+				env:   configEnvPrefix + strings.ToUpper(section+"_"+name),
+				flag:  f.Tag.Get("flag"),
+				usage: f.Tag.Get("usage"),
+				value: fields.Field(j),
+			})
+		}
+	}
+	return out
+}
+
+// settingValue adapts a setting to flag.Value.
+type settingValue struct {
+	v reflect.Value
+}
+
+// String formats the setting's value.
+func (s settingValue) String() string {
+	if !s.v.IsValid() {
+		return ""
+	}
+	switch v := s.v.Interface().(type) {
+	case duration:
+		return time.Duration(v).String()
+	case []string:
+		return strings.Join(v, ",")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// Set parses text into the setting. Lists are comma-separated.
+func (s settingValue) Set(text string) error {
+	switch p := s.v.Addr().Interface().(type) {
+	case *string:
+		*p = text
+	case *duration:
+		return p.UnmarshalText([]byte(text))
+	case *int:
+		v, err := strconv.Atoi(strings.TrimSpace(text))
+		if err != nil {
+			return err
+		}
+		*p = v
+	case *float64:
+		v, err := strconv.ParseFloat(strings.TrimSpace(text), 64)
+		if err != nil {
+			return err
+		}
+		*p = v
+	case *bool:
+		v, err := strconv.ParseBool(strings.TrimSpace(text))
+		if err != nil {
+			return err
+		}
+		*p = v
+	case *[]string:
+		*p = nil
+		for _, item := range strings.Split(text, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				*p = append(*p, item)
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported setting type %s", s.v.Type())
+	}
+	return nil
+}
+
+// IsBoolFlag lets boolean settings be given as a bare flag.
+func (s settingValue) IsBoolFlag() bool {
+	return s.v.IsValid() && s.v.Kind() == reflect.Bool
+}
+
+// newConfigFlagSet returns a flag set with the -config flag and a flag for every setting of c that has one.
+func newConfigFlagSet(name string, c *config, configPath *string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.StringVar(configPath, "config", "", "Configuration file (YAML, JSON or TOML); defaults to $"+configFileEnv+".")
+	for _, s := range c.settings() {
+		if s.flag != "" {
+			fs.Var(settingValue{s.value}, s.flag, s.usage+" ("+s.env+")")
+		}
+	}
+	return fs
+}
+
+// loadConfig builds the configuration from the defaults, the configuration file, the environment as
+// returned by lookupEnv and the command-line args, then validates it.
+func loadConfig(name string, args []string, lookupEnv func(string) (string, bool)) (config, error) {
+	c := defaultConfig()
+	var configPath string
+	fs := newConfigFlagSet(name, &c, &configPath)
+	fs.SetOutput(io.Discard)
+	// The first parse only finds the configuration file; the flags are parsed again once the file and
+	// environment have been applied so that they take precedence.
+	if err := fs.Parse(args); err != nil {
+		fs.SetOutput(os.Stderr)
+		if errors.Is(err, flag.ErrHelp) {
+			fs.PrintDefaults()
+		}
+		return config{}, err
+	}
+	if fs.NArg() > 0 {
+		return config{}, fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+	if configPath == "" {
+		configPath, _ = lookupEnv(configFileEnv)
+	}
+
+	c = defaultConfig()
+	if configPath != "" {
+		if err := c.loadFile(configPath); err != nil {
+			return config{}, err
+		}
+	}
+	if err := c.loadEnv(lookupEnv); err != nil {
+		return config{}, err
+	}
+	if err := fs.Parse(args); err != nil {
+		return config{}, err
+	}
+	if err := c.validate(); err != nil {
+		return config{}, err
+	}
+	return c, nil
+}
+
+// loadFile applies the settings in the configuration file at p. The format follows the file extension:
+// .toml for TOML, otherwise YAML, which includes JSON. Unknown keys are errors.
+func (c *config) loadFile(p string) error {
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return err
+	}
+	if strings.EqualFold(filepath.Ext(p), ".toml") {
+		md, err := toml.Decode(string(data), c)
+		if err != nil {
+			return fmt.Errorf("invalid configuration file %s: %w", p, err)
+		}
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			return fmt.Errorf("invalid configuration file %s: unknown setting %s", p, undecoded[0])
+		}
+		return nil
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("invalid configuration file %s: %w", p, err)
+	}
+	return nil
+}
+
+// loadEnv applies the settings given in environment variables.
+func (c *config) loadEnv(lookupEnv func(string) (string, bool)) error {
+	var errs []error
+	for _, s := range c.settings() {
+		if v, ok := lookupEnv(s.env); ok {
+			if err := (settingValue{s.value}).Set(v); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", s.env, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validate reports every setting with an invalid value.
+func (c *config) validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+	if c.Server.Ruleset != "" {
+		_, err := parseRuleset(c.Server.Ruleset)
+		check(err == nil, "server.ruleset: %v", err)
+	}
+	u, err := url.Parse(c.API.BaseURL)
+	check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "", "api.base_url: %q is not an http or https URL", c.API.BaseURL)
+	check(c.API.Timeout > 0, "api.timeout must be positive")
+	check(c.API.MaxRetries >= 0, "api.max_retries must not be negative")
+	check(c.API.BaseBackoff > 0 && c.API.MaxBackoff >= c.API.BaseBackoff, "api.base_backoff must be positive and at most api.max_backoff")
+	check(c.API.RateLimit >= 0, "api.rate_limit must not be negative")
+	check(c.API.RateBurst >= 0, "api.rate_burst must not be negative")
+	check(c.API.BreakerThreshold >= 0, "api.breaker_threshold must not be negative")
+	check(c.Cache.Size >= 0, "cache.size must not be negative")
+	check(c.Cache.TTL > 0, "cache.ttl must be positive")
+	t, err := parseTransport(c.Transport.Type)
+	check(err == nil, "transport.type: %v", err)
+	stdio := err == nil && t == transportStdio
+	check(c.Transport.ShutdownTimeout > 0, "transport.shutdown_timeout must be positive")
+	check(c.Transport.APIKeys == "" || !stdio, "transport.api_keys requires the sse or http transport")
+	_, err = logrus.ParseLevel(c.Log.Level)
+	check(err == nil, "log.level: %v", err)
+	check(c.Log.Format == "text" || c.Log.Format == "json", "log.format must be text or json, got %q", c.Log.Format)
+	check(c.Log.Output != "", "log.output must not be empty")
+	check(c.Log.Output != logOutputStdout || !stdio, "log.output stdout would corrupt the stdio transport's protocol stream")
+	if c.Metrics.Addr != "" {
+		_, _, err := net.SplitHostPort(c.Metrics.Addr)
+		check(err == nil, "metrics.addr: %v", err)
+	}
+	return errors.Join(errs...)
+}
+
+// print writes the configuration to w as YAML, in the format of the configuration file.
+func (c config) print(w io.Writer) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(c); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// runConfigCommand implements "dnd5e-mcp config print [flags]", which prints the configuration that the
+// server would run with given the same file, environment and flags.
+func runConfigCommand(args []string, stdout io.Writer) error {
+	if len(args) == 0 || args[0] != "print" {
+		return errors.New("usage: dnd5e-mcp config print [flags]")
+	}
+	c, err := loadConfig("config print", args[1:], os.LookupEnv)
+	if err != nil {
+		return err
+	}
+	return c.print(stdout)
+}
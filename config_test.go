@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// testEnv returns a lookupEnv function over vars.
+func testEnv(vars map[string]string) func(string) (string, bool) {
+	return func(k string) (string, bool) {
+		v, ok := vars[k]
+		return v, ok
+	}
+}
+
+func writeConfigFile(t *testing.T, name, data string) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(p, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestLoadConfigDefaults(t *testing.T) {
+	c, err := loadConfig("test", nil, testEnv(nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(c, defaultConfig()) {
+		t.Errorf("expected the defaults, got %+v", c)
+	}
+	if c.API.clientConfig() != defaultClientConfig() {
+		t.Errorf("expected the default client config, got %+v", c.API.clientConfig())
+	}
+}
+
+func TestLoadConfigLayers(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		file string
+		data string
+	}{
+		{"yaml", "config.yaml", "api:\n  timeout: 3s\n  rate_limit: 2\n  max_retries: 1\ncache:\n  size: 64\ntools:\n  disabled: [search]\n"},
+		{"json", "config.json", `{"api": {"timeout": "3s", "rate_limit": 2, "max_retries": 1}, "cache": {"size": 64}, "tools": {"disabled": ["search"]}}`},
+		{"toml", "config.toml", "[api]\ntimeout = \"3s\"\nrate_limit = 2\nmax_retries = 1\n\n[cache]\nsize = 64\n\n[tools]\ndisabled = [\"search\"]\n"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p := writeConfigFile(t, tc.file, tc.data)
+			env := testEnv(map[string]string{
+				configFileEnv:                 p,
+				"DND5E_MCP_API_MAX_RETRIES":   "5",
+				"DND5E_MCP_CACHE_SIZE":        "128",
+				"DND5E_MCP_TOOLS_ENABLED":     "spells, monsters",
+				"DND5E_MCP_TRANSPORT_ADDR":    ":9000",
+				"DND5E_MCP_UNRELATED_SETTING": "x",
+			})
+			c, err := loadConfig("test", []string{"-cache-size", "256", "-log-level", "debug"}, env)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if c.API.Timeout != duration(3*time.Second) || c.API.RateLimit != 2 {
+				t.Errorf("expected file settings, got %+v", c.API)
+			}
+			if c.API.MaxRetries != 5 || c.Transport.Addr != ":9000" || !reflect.DeepEqual(c.Tools.Enabled, []string{"spells", "monsters"}) {
+				t.Errorf("expected environment to override the file, got %+v", c)
+			}
+			if c.Cache.Size != 256 || c.Log.Level != "debug" {
+				t.Errorf("expected flags to override the environment, got %+v %+v", c.Cache, c.Log)
+			}
+			if !reflect.DeepEqual(c.Tools.Disabled, []string{"search"}) || c.API.BaseURL != defaultAPIBaseURL {
+				t.Errorf("expected unset settings to keep earlier values, got %+v", c)
+			}
+		})
+	}
+}
+
+func TestLoadConfigFlagSelectsFile(t *testing.T) {
+	fromEnv := writeConfigFile(t, "env.yaml", "cache:\n  size: 1\n")
+	fromFlag := writeConfigFile(t, "flag.yaml", "cache:\n  size: 2\n")
+	c, err := loadConfig("test", []string{"-config", fromFlag}, testEnv(map[string]string{configFileEnv: fromEnv}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.Cache.Size != 2 {
+		t.Errorf("expected the -config file to win, got size %d", c.Cache.Size)
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		file string
+		data string
+		env  map[string]string
+		args []string
+		want []string
+	}{
+		{name: "unknown yaml key", file: "c.yaml", data: "cache:\n  sise: 3\n", want: []string{"field sise not found"}},
+		{name: "unknown toml key", file: "c.toml", data: "[cache]\nsise = 3\n", want: []string{"unknown setting cache.sise"}},
+		{name: "bad duration", file: "c.yaml", data: "cache:\n  ttl: soon\n", want: []string{"invalid duration"}},
+		{name: "bad environment", env: map[string]string{"DND5E_MCP_CACHE_SIZE": "lots"}, want: []string{"DND5E_MCP_CACHE_SIZE"}},
+		{name: "bad flag", args: []string{"-cache-size", "lots"}, want: []string{"cache-size"}},
+		{name: "positional argument", args: []string{"serve"}, want: []string{"unexpected arguments: serve"}},
+		{
+			name: "invalid values",
+			args: []string{"-api-url", "ftp://example.com", "-request-timeout", "0s", "-transport", "ws", "-log-level", "loud", "-log-format", "xml", "-ruleset", "2020", "-cache-size", "-1"},
+			want: []string{"api.base_url", "api.timeout must be positive", "transport.type", "log.level", "log.format", "server.ruleset", "cache.size"},
+		},
+		{name: "api keys over stdio", args: []string{"-api-keys", "keys.yaml"}, want: []string{"transport.api_keys requires the sse or http transport"}},
+		{name: "logs on stdout over stdio", args: []string{"-log-output", "stdout"}, want: []string{"log.output stdout would corrupt"}},
+		{name: "metrics address without port", args: []string{"-metrics-addr", "localhost"}, want: []string{"metrics.addr"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			env := map[string]string{}
+			for k, v := range tc.env {
+				env[k] = v
+			}
+			if tc.file != "" {
+				env[configFileEnv] = writeConfigFile(t, tc.file, tc.data)
+			}
+			_, err := loadConfig("test", tc.args, testEnv(env))
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			for _, want := range tc.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("expected error to contain %q, got:\n%v", want, err)
+				}
+			}
+		})
+	}
+}
+
+func TestConfigPrintRoundTrip(t *testing.T) {
+	c, err := loadConfig("test", []string{"-cache-ttl", "90m", "-disable-tools", "search", "-transport", "http"}, testEnv(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := c.print(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "ttl: 1h30m0s") {
+		t.Errorf("expected durations printed as strings, got:\n%s", buf.String())
+	}
+	p := writeConfigFile(t, "printed.yaml", buf.String())
+	reloaded, err := loadConfig("test", []string{"-config", p}, testEnv(nil))
+	if err != nil {
+		t.Fatalf("reload printed config: %v", err)
+	}
+	var again bytes.Buffer
+	if err := reloaded.print(&again); err != nil {
+		t.Fatal(err)
+	}
+	if again.String() != buf.String() {
+		t.Errorf("printed config did not round-trip:\n got:\n%s\nwant:\n%s", again.String(), buf.String())
+	}
+}
+
+func TestSelectTools(t *testing.T) {
+	var tools []server.ServerTool
+	for _, name := range []string{"spells", "monsters", "search"} {
+		tools = append(tools, server.ServerTool{Tool: mcp.NewTool(name)})
+	}
+	names := func(tools []server.ServerTool) string {
+		var out []string
+		for _, tool := range tools {
+			out = append(out, tool.Tool.Name)
+		}
+		return strings.Join(out, ",")
+	}
+	for _, tc := range []struct {
+		settings toolSettings
+		want     string
+	}{
+		{toolSettings{}, "spells,monsters,search"},
+		{toolSettings{Enabled: []string{"search", "spells"}}, "spells,search"},
+		{toolSettings{Disabled: []string{"monsters"}}, "spells,search"},
+		{toolSettings{Enabled: []string{"spells", "monsters"}, Disabled: []string{"monsters"}}, "spells"},
+	} {
+		got, err := selectTools(tools, tc.settings)
+		if err != nil || names(got) != tc.want {
+			t.Errorf("selectTools(%+v) = %s, %v; want %s", tc.settings, names(got), err, tc.want)
+		}
+	}
+	if _, err := selectTools(tools, toolSettings{Enabled: []string{"spels"}, Disabled: []string{"feats"}}); err == nil ||
+		!strings.Contains(err.Error(), `tools.enabled: unknown tool "spels"`) || !strings.Contains(err.Error(), `tools.disabled: unknown tool "feats"`) {
+		t.Errorf("expected unknown tool errors, got %v", err)
+	}
+}
+
+func TestWithToolMiddleware(t *testing.T) {
+	var calls []string
+	mw := func(name string) server.ToolHandlerMiddleware {
+		return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+			return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				calls = append(calls, name)
+				return next(ctx, req)
+			}
+		}
+	}
+	tools := []server.ServerTool{{
+		Tool: mcp.NewTool("spells"),
+		Handler: func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			calls = append(calls, "handler")
+			return mcp.NewToolResultText("{}"), nil
+		},
+	}}
+	wrapped := withToolMiddleware(tools, mw("outer"), mw("inner"))
+	wrapped[0].Handler(context.Background(), mcp.CallToolRequest{})
+	if got := strings.Join(calls, ","); got != "outer,inner,handler" {
+		t.Errorf("expected the first middleware outermost, got %s", got)
+	}
+	calls = nil
+	tools[0].Handler(context.Background(), mcp.CallToolRequest{})
+	if got := strings.Join(calls, ","); got != "handler" {
+		t.Errorf("expected the original tools to be left unwrapped, got %s", got)
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// dataSource is the interface the tool handlers use to retrieve D&D 5e data.
+// Implementations decode the requested resource into v, which must be a pointer.
+type dataSource interface {
+	// Get fetches a single resource by endpoint and index.
+	Get(ctx context.Context, e endpoint, index string, v any) error
+	// List fetches the resources of an endpoint, optionally narrowed by a query-string filter.
+	List(ctx context.Context, e endpoint, filter string, v any) error
+	// Search fetches the resources of an endpoint whose name matches the query.
+	Search(ctx context.Context, e endpoint, query string, v any) error
+}
+
+// maxConcurrentFetches bounds the number of concurrent requests made by fetchAll.
+const maxConcurrentFetches = 8
+
+// fetchMatchingDetails fetches the details of the items with the given indexes and returns those that keep
+// accepts, in order; keep may also fill in derived fields. Every item is checked, at most maxConcurrentFetches
+// at a time; with the response cache enabled, repeated filtering of the same list makes no requests.
+func fetchMatchingDetails[T any](ctx context.Context, src dataSource, e endpoint, indexes []string, keep func(*T) bool) ([]T, error) {
+	details, err := fetchAll[T](ctx, src, e, indexes)
+	if err != nil {
+		return nil, err
+	}
+	var matched []T
+	for i := range details {
+		if keep(&details[i]) {
+			matched = append(matched, details[i])
+		}
+	}
+	return matched, nil
+}
+
+// errNotFound is returned by a dataSource when the requested resource does not exist.
+var errNotFound = errors.New("resource not found")
+
+// fetchByName fetches an item by name from the data source and unmarshals it into the provided variable.
+// Names that are not an exact index are resolved to the closest match; see getByName.
+func fetchByName(ctx context.Context, src dataSource, e endpoint, name string, v any) error {
+	logFrom(ctx).WithFields(logrus.Fields{"endpoint": e, "name": name}).Debug("fetchByName called")
+	if err := getByName(ctx, src, e, name, v); err != nil {
+		logFrom(ctx).WithError(err).Error("Get failed in fetchByName")
+		return err
+	}
+	logFrom(ctx).WithField("name", name).Debug("fetchByName succeeded")
+	return nil
+}
+
+// fetchList fetches a list of items from the data source and unmarshals it into the provided variable.
+func fetchList(ctx context.Context, src dataSource, e endpoint, v any, filter string) error {
+	logFrom(ctx).WithFields(logrus.Fields{"endpoint": e, "filter": filter}).Debug("fetchList called")
+	if err := src.List(ctx, e, filter, v); err != nil {
+		logFrom(ctx).WithError(err).Error("List failed in fetchList")
+		return err
+	}
+	logFrom(ctx).Debug("fetchList succeeded")
+	return nil
+}
+
+// forEachConcurrently calls fn for every i in [0, n), running at most limit calls at a time. The first error
+// cancels the context passed to the other calls, stops further calls from starting and is returned. If ctx is
+// done before every call has been made, its error is returned. Callers that tolerate failures log them in fn
+// and return nil.
+func forEachConcurrently(ctx context.Context, n, limit int, fn func(ctx context.Context, i int) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	sem := make(chan struct{}, max(limit, 1))
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	for i := 0; i < n; i++ {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := fn(ctx, i); err != nil {
+				once.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		}()
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+// fetchAll fetches the items with the given indexes concurrently and returns them in the same order.
+// The first error cancels the remaining fetches and is returned.
+func fetchAll[T any](ctx context.Context, src dataSource, e endpoint, indexes []string) ([]T, error) {
+	results := make([]T, len(indexes))
+	err := forEachConcurrently(ctx, len(indexes), maxConcurrentFetches, func(ctx context.Context, i int) error {
+		return src.Get(ctx, e, indexes[i], &results[i])
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// mockDataSource is a dataSource whose behaviour is provided by function fields.
+// A nil function field makes the corresponding method a no-op. A function that type-asserts v to a
+// different type than the request decodes into fails the request instead of panicking, so a mock written
+// for one kind of request does not break code that makes another, such as name resolution listing an endpoint.
+type mockDataSource struct {
+	get    func(ctx context.Context, e endpoint, index string, v any) error
+	list   func(ctx context.Context, e endpoint, filter string, v any) error
+	search func(ctx context.Context, e endpoint, query string, v any) error
+}
+
+func (m *mockDataSource) Get(ctx context.Context, e endpoint, index string, v any) error {
+	if m.get == nil {
+		return nil
+	}
+	return mockCall(func() error { return m.get(ctx, e, index, v) })
+}
+
+func (m *mockDataSource) List(ctx context.Context, e endpoint, filter string, v any) error {
+	if m.list == nil {
+		return nil
+	}
+	return mockCall(func() error { return m.list(ctx, e, filter, v) })
+}
+
+func (m *mockDataSource) Search(ctx context.Context, e endpoint, query string, v any) error {
+	if m.search == nil {
+		return nil
+	}
+	return mockCall(func() error { return m.search(ctx, e, query, v) })
+}
+
+// mockCall calls fn and turns a failed type assertion in it into an error.
+func mockCall(fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(*runtime.TypeAssertionError); !ok {
+				panic(r)
+			}
+			err = fmt.Errorf("mock cannot answer the request: %v", r)
+		}
+	}()
+	return fn()
+}
+
+// setJSON stores data in v via a JSON round trip, so a mock can answer a request whatever type v points to.
+func setJSON(v any, data any) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+func TestFetchByName(t *testing.T) {
+	var gotEndpoint endpoint
+	var gotIndex string
+	src := &mockDataSource{
+		get: func(_ context.Context, e endpoint, index string, v any) error {
+			gotEndpoint, gotIndex = e, index
+			*v.(*spellAPIResponse) = spellAPIResponse{Index: index}
+			return nil
+		},
+	}
+	var spell spellAPIResponse
+	if err := fetchByName(context.Background(), src, spells, "Magic Missile", &spell); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotEndpoint != spells || gotIndex != "magic-missile" {
+		t.Errorf("Get called with (%q, %q), want (%q, %q)", gotEndpoint, gotIndex, spells, "magic-missile")
+	}
+	if spell.Index != "magic-missile" {
+		t.Errorf("expected decoded index %q, got %q", "magic-missile", spell.Index)
+	}
+
+	src.get = func(_ context.Context, _ endpoint, _ string, _ any) error { return errNotFound }
+	if err := fetchByName(context.Background(), src, spells, "nope", &spell); !errors.Is(err, errNotFound) {
+		t.Errorf("expected errNotFound, got %v", err)
+	}
+}
+
+func TestFetchList(t *testing.T) {
+	var gotFilter string
+	src := &mockDataSource{
+		list: func(_ context.Context, _ endpoint, filter string, v any) error {
+			gotFilter = filter
+			*v.(*[]spellListAPIResponse) = []spellListAPIResponse{{Index: "fireball"}}
+			return nil
+		},
+	}
+	var results []spellListAPIResponse
+	if err := fetchList(context.Background(), src, spells, &results, "level=3"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotFilter != "level=3" {
+		t.Errorf("List called with filter %q, want %q", gotFilter, "level=3")
+	}
+	if len(results) != 1 || results[0].Index != "fireball" {
+		t.Errorf("unexpected results: %+v", results)
+	}
+}
+
+func TestFetchAll(t *testing.T) {
+	src := &mockDataSource{
+		get: func(_ context.Context, _ endpoint, index string, v any) error {
+			if index == "missing" {
+				return errNotFound
+			}
+			*v.(*monsterDetail) = monsterDetail{Index: index}
+			return nil
+		},
+	}
+	got, err := fetchAll[monsterDetail](context.Background(), src, monsters, []string{"goblin", "orc", "zombie"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i, want := range []string{"goblin", "orc", "zombie"} {
+		if got[i].Index != want {
+			t.Errorf("result %d: expected %q, got %q", i, want, got[i].Index)
+		}
+	}
+
+	if _, err := fetchAll[monsterDetail](context.Background(), src, monsters, []string{"goblin", "missing"}); !errors.Is(err, errNotFound) {
+		t.Errorf("expected errNotFound, got %v", err)
+	}
+}
+
+func TestForEachConcurrently(t *testing.T) {
+	var running, peak, calls atomic.Int32
+	err := forEachConcurrently(context.Background(), 20, 3, func(ctx context.Context, i int) error {
+		calls.Add(1)
+		n := running.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		running.Add(-1)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls.Load() != 20 || peak.Load() > 3 {
+		t.Errorf("expected 20 calls with at most 3 at a time, got %d calls and %d at once", calls.Load(), peak.Load())
+	}
+
+	calls.Store(0)
+	err = forEachConcurrently(context.Background(), 100, 1, func(ctx context.Context, i int) error {
+		calls.Add(1)
+		if i == 2 {
+			return errNotFound
+		}
+		return nil
+	})
+	if !errors.Is(err, errNotFound) || calls.Load() != 3 {
+		t.Errorf("expected the first error to stop further calls, got %v after %d calls", err, calls.Load())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := forEachConcurrently(ctx, 5, 2, func(context.Context, int) error { return nil }); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
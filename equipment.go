@@ -0,0 +1,281 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// copperPerUnit maps D&D 5e currency units to their value in copper pieces.
+var copperPerUnit = map[string]float64{
+	"cp": 1,
+	"sp": 10,
+	"ep": 50,
+	"gp": 100,
+	"pp": 1000,
+}
+
+// equipmentToolInput defines the input structure for the equipment tool.
+type equipmentToolInput struct {
+	Name           string  `json:"name" mcp:"description=The name of the equipment item to retrieve (e.g., 'Longsword')."`
+	Category       string  `json:"category" mcp:"description=The equipment category to list (e.g., 'weapon', 'armor', 'adventuring-gear')."`
+	MinCostCP      float64 `json:"min_cost_cp" mcp:"description=Only include items costing at least this many copper pieces (1 gp = 100 cp)."`
+	MaxCostCP      float64 `json:"max_cost_cp" mcp:"description=Only include items costing at most this many copper pieces (1 gp = 100 cp)."`
+	MaxWeight      float64 `json:"max_weight" mcp:"description=Only include items weighing at most this many pounds."`
+	WeaponCategory string  `json:"weapon_category" mcp:"description=Only include weapons of this category ('Simple' or 'Martial')."`
+	DamageDice     string  `json:"damage_dice" mcp:"description=Only include weapons with this damage dice (e.g., '1d8')."`
+	MinArmorClass  int     `json:"min_armor_class" mcp:"description=Only include armor with at least this base armor class."`
+}
+
+// hasFilters reports whether any client-side filter is set.
+func (f *equipmentToolInput) hasFilters() bool {
+	return f.MinCostCP != 0 || f.MaxCostCP != 0 || f.MaxWeight != 0 ||
+		f.WeaponCategory != "" || f.DamageDice != "" || f.MinArmorClass != 0
+}
+
+// impliedCategory returns "weapon" or "armor" when only weapons or armor can match the filters, so that
+// they need not be checked against all equipment, or "" otherwise.
+func (f *equipmentToolInput) impliedCategory() string {
+	switch {
+	case f.WeaponCategory != "" || f.DamageDice != "":
+		return "weapon"
+	case f.MinArmorClass != 0:
+		return "armor"
+	}
+	return ""
+}
+
+// findEquipmentCategory returns the index of the equipment category of the selected ruleset that holds the
+// given kind of item, singular or plural, e.g. "weapons" for "weapon", or "" if the ruleset has none.
+func findEquipmentCategory(ctx context.Context, src dataSource, kind string) (string, error) {
+	var categories []apiReference
+	if err := fetchList(ctx, src, equipmentCategories, &categories, ""); err != nil {
+		return "", err
+	}
+	for _, c := range categories {
+		if strings.TrimSuffix(c.Index, "s") == strings.TrimSuffix(kind, "s") {
+			return c.Index, nil
+		}
+	}
+	return "", nil
+}
+
+// matches reports whether an equipment item satisfies every filter that is set.
+func (f *equipmentToolInput) matches(item equipmentDetail) bool {
+	cost := item.Cost.copper()
+	if f.MinCostCP != 0 && cost < f.MinCostCP {
+		return false
+	}
+	if f.MaxCostCP != 0 && cost > f.MaxCostCP {
+		return false
+	}
+	if f.MaxWeight != 0 && item.Weight > f.MaxWeight {
+		return false
+	}
+	if f.WeaponCategory != "" && !strings.EqualFold(item.WeaponCategory, f.WeaponCategory) {
+		return false
+	}
+	if f.DamageDice != "" && (item.Damage == nil || !strings.EqualFold(item.Damage.DamageDice, f.DamageDice)) {
+		return false
+	}
+	if f.MinArmorClass != 0 && (item.ArmorClass == nil || item.ArmorClass.Base < f.MinArmorClass) {
+		return false
+	}
+	return true
+}
+
+// equipmentListAPIResponse defines the structure for a single equipment item in the list response.
+type equipmentListAPIResponse struct {
+	Index  string `json:"index"`
+	Name   string `json:"name"`
+	URL    string `json:"url"`
+	Source string `json:"source,omitempty"`
+}
+
+// equipmentCost is the price of an item in a single currency unit.
+type equipmentCost struct {
+	Quantity float64 `json:"quantity"`
+	Unit     string  `json:"unit"`
+}
+
+// copper returns the cost normalized to copper pieces.
+func (c equipmentCost) copper() float64 {
+	return c.Quantity * copperPerUnit[strings.ToLower(c.Unit)]
+}
+
+// equipmentDamage is the damage dealt by a weapon.
+type equipmentDamage struct {
+	DamageDice string       `json:"damage_dice"`
+	DamageType apiReference `json:"damage_type"`
+}
+
+// equipmentRange is the normal and long range of a weapon, in feet.
+type equipmentRange struct {
+	Normal int `json:"normal"`
+	Long   int `json:"long,omitempty"`
+}
+
+// equipmentArmorClass is the armor class granted by a piece of armor.
+type equipmentArmorClass struct {
+	Base     int  `json:"base"`
+	DexBonus bool `json:"dex_bonus"`
+	MaxBonus *int `json:"max_bonus,omitempty"`
+}
+
+// equipmentContent is an item bundled inside an equipment pack.
+type equipmentContent struct {
+	Item     apiReference `json:"item"`
+	Quantity int          `json:"quantity"`
+}
+
+// equipmentDetail defines the structure for a detailed equipment response.
+// Weapon, armor and gear specific fields are only present for items of that kind.
+// The 2024 ruleset lists several EquipmentCategories, gives weapons a Mastery property and uses Description.
+type equipmentDetail struct {
+	Index               string               `json:"index"`
+	Name                string               `json:"name"`
+	Desc                paragraphs           `json:"desc,omitempty"`
+	Description         string               `json:"description,omitempty"`
+	EquipmentCategory   *apiReference        `json:"equipment_category,omitempty"`
+	EquipmentCategories []apiReference       `json:"equipment_categories,omitempty"`
+	Cost                equipmentCost        `json:"cost"`
+	CostCP              float64              `json:"cost_cp"`
+	Weight              float64              `json:"weight,omitempty"`
+	WeaponCategory      string               `json:"weapon_category,omitempty"`
+	WeaponRange         string               `json:"weapon_range,omitempty"`
+	CategoryRange       string               `json:"category_range,omitempty"`
+	Damage              *equipmentDamage     `json:"damage,omitempty"`
+	TwoHandedDamage     *equipmentDamage     `json:"two_handed_damage,omitempty"`
+	Range               *equipmentRange      `json:"range,omitempty"`
+	ThrowRange          *equipmentRange      `json:"throw_range,omitempty"`
+	Properties          []apiReference       `json:"properties,omitempty"`
+	Mastery             *apiReference        `json:"mastery,omitempty"`
+	ArmorCategory       string               `json:"armor_category,omitempty"`
+	ArmorClass          *equipmentArmorClass `json:"armor_class,omitempty"`
+	StrMinimum          int                  `json:"str_minimum,omitempty"`
+	StealthDisadvantage bool                 `json:"stealth_disadvantage,omitempty"`
+	GearCategory        *apiReference        `json:"gear_category,omitempty"`
+	ToolCategory        string               `json:"tool_category,omitempty"`
+	VehicleCategory     string               `json:"vehicle_category,omitempty"`
+	Contents            []equipmentContent   `json:"contents,omitempty"`
+	Special             []string             `json:"special,omitempty"`
+	URL                 string               `json:"url"`
+}
+
+// equipmentCategoryDetail defines the structure for a detailed equipment category response.
+type equipmentCategoryDetail struct {
+	Index     string         `json:"index"`
+	Name      string         `json:"name"`
+	Equipment []apiReference `json:"equipment"`
+	URL       string         `json:"url"`
+}
+
+// equipmentToolOutput defines the output structure for the equipment tool.
+// Results holds plain list entries; Items holds full details when client-side filters were applied.
+type equipmentToolOutput struct {
+	Count     int                        `json:"count,omitempty"`
+	Results   []equipmentListAPIResponse `json:"results,omitempty"`
+	Items     []equipmentDetail          `json:"items,omitempty"`
+	Equipment *equipmentDetail           `json:"equipment,omitempty"`
+}
+
+// fetchEquipmentByNameResult fetches an equipment item by name and returns an MCP tool result.
+func fetchEquipmentByNameResult(
+	ctx context.Context,
+	src dataSource,
+	input equipmentToolInput,
+) (*mcp.CallToolResult, error) {
+	item := &equipmentDetail{}
+	err := fetchByName(ctx, src, equipment, input.Name, item)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to fetch equipment", err), err
+	}
+	item.CostCP = item.Cost.copper()
+	output := equipmentToolOutput{Equipment: item}
+	jsonData, err := json.Marshal(output)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal equipment output", err), err
+	}
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+// fetchEquipmentRefs returns the equipment in the requested category, or all equipment if no category is given.
+// Magic items listed under a category are skipped because they are served by a different endpoint.
+func fetchEquipmentRefs(ctx context.Context, src dataSource, category string) ([]equipmentListAPIResponse, error) {
+	if category == "" {
+		var results []equipmentListAPIResponse
+		err := fetchList(ctx, src, equipment, &results, "")
+		return results, err
+	}
+	cat := &equipmentCategoryDetail{}
+	if err := fetchByName(ctx, src, equipmentCategories, category, cat); err != nil {
+		return nil, err
+	}
+	var results []equipmentListAPIResponse
+	for _, ref := range cat.Equipment {
+		if strings.Contains(ref.URL, "/"+string(magicItems)+"/") {
+			continue
+		}
+		results = append(results, equipmentListAPIResponse(ref))
+	}
+	return results, nil
+}
+
+// fetchEquipmentListResult lists equipment, optionally by category, applying any client-side filters, and returns an MCP tool result.
+func fetchEquipmentListResult(
+	ctx context.Context,
+	src dataSource,
+	input equipmentToolInput,
+) (*mcp.CallToolResult, error) {
+	category := input.Category
+	if category == "" && input.hasFilters() {
+		if kind := input.impliedCategory(); kind != "" {
+			var err error
+			if category, err = findEquipmentCategory(ctx, src, kind); err != nil {
+				return mcp.NewToolResultErrorFromErr("Failed to fetch equipment categories", err), err
+			}
+		}
+	}
+	results, err := fetchEquipmentRefs(ctx, src, category)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to fetch equipment list", err), err
+	}
+	output := equipmentToolOutput{Count: len(results), Results: results}
+	if input.hasFilters() {
+		indexes := make([]string, len(results))
+		for i, r := range results {
+			indexes[i] = r.Index
+		}
+		items, err := fetchMatchingDetails(ctx, src, equipment, indexes,
+			func(item *equipmentDetail) bool {
+				item.CostCP = item.Cost.copper()
+				return input.matches(*item)
+			})
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Failed to fetch equipment details", err), err
+		}
+		output = equipmentToolOutput{Count: len(items), Items: items}
+	}
+	jsonData, err := json.Marshal(output)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal equipment list output", err), err
+	}
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+// runEquipmentTool executes the core logic for the equipment tool.
+func runEquipmentTool(ctx context.Context, src dataSource, input equipmentToolInput) (*mcp.CallToolResult, error) {
+	if input.Name != "" {
+		return fetchEquipmentByNameResult(ctx, src, input)
+	}
+	return fetchEquipmentListResult(ctx, src, input)
+}
+
+// handleEquipmentTool returns the MCP handler for the equipment tool.
+func handleEquipmentTool(src dataSource) mcp.TypedToolHandlerFunc[equipmentToolInput] {
+	return func(ctx context.Context, req mcp.CallToolRequest, input equipmentToolInput) (*mcp.CallToolResult, error) {
+		return runEquipmentTool(ctx, src, input)
+	}
+}
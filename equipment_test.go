@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestEquipmentCost_copper(t *testing.T) {
+	cases := []struct {
+		cost equipmentCost
+		want float64
+	}{
+		{equipmentCost{Quantity: 15, Unit: "gp"}, 1500},
+		{equipmentCost{Quantity: 2, Unit: "sp"}, 20},
+		{equipmentCost{Quantity: 1, Unit: "cp"}, 1},
+		{equipmentCost{Quantity: 1, Unit: "ep"}, 50},
+		{equipmentCost{Quantity: 1, Unit: "PP"}, 1000},
+		{equipmentCost{}, 0},
+	}
+	for _, tc := range cases {
+		if got := tc.cost.copper(); got != tc.want {
+			t.Errorf("%+v.copper() = %v, want %v", tc.cost, got, tc.want)
+		}
+	}
+}
+
+func TestRunEquipmentTool(t *testing.T) {
+	maxDex := 2
+	items := map[string]equipmentDetail{
+		"longsword": {
+			Index: "longsword", Name: "Longsword", Cost: equipmentCost{Quantity: 15, Unit: "gp"}, Weight: 3,
+			WeaponCategory: "Martial", Damage: &equipmentDamage{DamageDice: "1d8"},
+		},
+		"dagger": {
+			Index: "dagger", Name: "Dagger", Cost: equipmentCost{Quantity: 2, Unit: "gp"}, Weight: 1,
+			WeaponCategory: "Simple", Damage: &equipmentDamage{DamageDice: "1d4"},
+		},
+		"scale-mail": {
+			Index: "scale-mail", Name: "Scale Mail", Cost: equipmentCost{Quantity: 50, Unit: "gp"}, Weight: 45,
+			ArmorCategory: "Medium", ArmorClass: &equipmentArmorClass{Base: 14, DexBonus: true, MaxBonus: &maxDex},
+		},
+	}
+	// The 2024 ruleset names the weapon category in the plural.
+	weaponCategories := map[ruleset]string{ruleset2014: "weapon", ruleset2024: "weapons"}
+	weapons := equipmentCategoryDetail{
+		Index: "weapon",
+		Equipment: []apiReference{
+			{Index: "longsword", Name: "Longsword", URL: "/api/2014/equipment/longsword"},
+			{Index: "dagger", Name: "Dagger", URL: "/api/2014/equipment/dagger"},
+			{Index: "dagger-1", Name: "Dagger, +1", URL: "/api/2014/magic-items/dagger-1"},
+		},
+	}
+	armor := equipmentCategoryDetail{
+		Index:     "armor",
+		Equipment: []apiReference{{Index: "scale-mail", Name: "Scale Mail", URL: "/api/2014/equipment/scale-mail"}},
+	}
+	src := &mockDataSource{
+		get: func(ctx context.Context, e endpoint, index string, v any) error {
+			switch e {
+			case equipmentCategories:
+				switch index {
+				case weaponCategories[rulesetFrom(ctx)]:
+					*v.(*equipmentCategoryDetail) = weapons
+				case "armor":
+					*v.(*equipmentCategoryDetail) = armor
+				default:
+					return errNotFound
+				}
+				return nil
+			case equipment:
+				item, ok := items[index]
+				if !ok {
+					return errNotFound
+				}
+				*v.(*equipmentDetail) = item
+				return nil
+			}
+			return errors.New("unexpected endpoint")
+		},
+		list: func(ctx context.Context, e endpoint, _ string, v any) error {
+			if e == equipmentCategories {
+				*v.(*[]apiReference) = []apiReference{{Index: "armor"}, {Index: weaponCategories[rulesetFrom(ctx)]}}
+				return nil
+			}
+			*v.(*[]equipmentListAPIResponse) = []equipmentListAPIResponse{
+				{Index: "dagger"}, {Index: "longsword"}, {Index: "scale-mail"},
+			}
+			return nil
+		},
+	}
+
+	cases := []struct {
+		name        string
+		input       equipmentToolInput
+		wantErr     bool
+		wantErrMsg  string
+		wantName    string
+		wantCostCP  float64
+		wantResults []string
+		wantItems   []string
+	}{
+		{name: "by name", input: equipmentToolInput{Name: "Longsword"}, wantName: "Longsword", wantCostCP: 1500},
+		{name: "by name not found", input: equipmentToolInput{Name: "Vorpal Spoon"}, wantErr: true, wantErrMsg: "resource not found"},
+		{name: "list all", input: equipmentToolInput{}, wantResults: []string{"dagger", "longsword", "scale-mail"}},
+		{name: "list category skips magic items", input: equipmentToolInput{Category: "Weapon"}, wantResults: []string{"longsword", "dagger"}},
+		{name: "max cost", input: equipmentToolInput{MaxCostCP: 1000}, wantItems: []string{"dagger"}},
+		{name: "min cost", input: equipmentToolInput{MinCostCP: 1000}, wantItems: []string{"longsword", "scale-mail"}},
+		{name: "max weight", input: equipmentToolInput{MaxWeight: 5}, wantItems: []string{"dagger", "longsword"}},
+		{name: "weapon category", input: equipmentToolInput{Category: "weapon", WeaponCategory: "martial"}, wantItems: []string{"longsword"}},
+		{name: "damage dice lists weapons only", input: equipmentToolInput{DamageDice: "1d4"}, wantItems: []string{"dagger"}},
+		{name: "armor class lists armor only", input: equipmentToolInput{MinArmorClass: 13}, wantItems: []string{"scale-mail"}},
+		{name: "no matches", input: equipmentToolInput{MinArmorClass: 20}},
+		{name: "unknown category", input: equipmentToolInput{Category: "snacks"}, wantErr: true, wantErrMsg: "resource not found"},
+	}
+	// Every case runs in both rulesets, whose weapon categories have different indexes.
+	for _, r := range allRulesets {
+		for _, tc := range cases {
+			t.Run(string(r)+"/"+tc.name, func(t *testing.T) {
+				res, err := runEquipmentTool(withRuleset(context.Background(), r), src, tc.input)
+				if tc.wantErr {
+					if err == nil {
+						t.Errorf("expected Go error, got nil")
+					}
+					if res == nil || !res.IsError {
+						t.Fatalf("expected MCP error result, got %+v", res)
+					}
+					txt, _ := mcp.AsTextContent(res.Content[0])
+					if !strings.Contains(txt.Text, tc.wantErrMsg) {
+						t.Errorf("expected error message to contain %q, got %q", tc.wantErrMsg, txt.Text)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				txt, ok := mcp.AsTextContent(res.Content[0])
+				if !ok {
+					t.Fatalf("content is not TextContent, got %T", res.Content[0])
+				}
+				var out equipmentToolOutput
+				if err := json.Unmarshal([]byte(txt.Text), &out); err != nil {
+					t.Fatalf("unmarshal output: %v", err)
+				}
+				if tc.wantName != "" {
+					if out.Equipment == nil || out.Equipment.Name != tc.wantName || out.Equipment.CostCP != tc.wantCostCP {
+						t.Errorf("expected %q costing %v cp, got %+v", tc.wantName, tc.wantCostCP, out.Equipment)
+					}
+					return
+				}
+				var gotResults, gotItems []string
+				for _, r := range out.Results {
+					gotResults = append(gotResults, r.Index)
+				}
+				for _, i := range out.Items {
+					gotItems = append(gotItems, i.Index)
+				}
+				if strings.Join(gotResults, ",") != strings.Join(tc.wantResults, ",") {
+					t.Errorf("expected results %v, got %v", tc.wantResults, gotResults)
+				}
+				if strings.Join(gotItems, ",") != strings.Join(tc.wantItems, ",") {
+					t.Errorf("expected items %v, got %v", tc.wantItems, gotItems)
+				}
+				if out.Count != len(tc.wantResults)+len(tc.wantItems) {
+					t.Errorf("unexpected count %d", out.Count)
+				}
+			})
+		}
+	}
+}
+
+func TestRunEquipmentTool_FiltersWholeList(t *testing.T) {
+	var refs []equipmentListAPIResponse
+	for i := 0; i < 300; i++ {
+		refs = append(refs, equipmentListAPIResponse{Index: fmt.Sprintf("item-%d", i)})
+	}
+	var gets, inFlight, maxInFlight atomic.Int32
+	src := &mockDataSource{
+		get: func(_ context.Context, _ endpoint, index string, v any) error {
+			gets.Add(1)
+			n := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for m := maxInFlight.Load(); n > m && !maxInFlight.CompareAndSwap(m, n); m = maxInFlight.Load() {
+			}
+			weight := 1.0
+			if index == "item-299" {
+				weight = 5
+			}
+			return setJSON(v, equipmentDetail{Index: index, Weight: weight})
+		},
+		list: func(_ context.Context, _ endpoint, _ string, v any) error { return setJSON(v, refs) },
+	}
+	res, err := runEquipmentTool(context.Background(), src, equipmentToolInput{MaxWeight: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var out equipmentToolOutput
+	txt, _ := mcp.AsTextContent(res.Content[0])
+	if err := json.Unmarshal([]byte(txt.Text), &out); err != nil {
+		t.Fatal(err)
+	}
+	if gets.Load() != int32(len(refs)) || out.Count != len(refs)-1 {
+		t.Errorf("expected all %d items checked and %d matched, got %d checked and %d matched", len(refs), len(refs)-1, gets.Load(), out.Count)
+	}
+	if maxInFlight.Load() > maxConcurrentFetches {
+		t.Errorf("expected at most %d concurrent fetches, got %d", maxConcurrentFetches, maxInFlight.Load())
+	}
+}
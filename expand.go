@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	// expandParam is the argument, accepted by every tool, that sets how many levels of references to inline.
+	expandParam = "expand"
+	// maxExpandDepth caps the expand argument.
+	maxExpandDepth = 3
+	// maxExpandFetches caps the number of distinct references fetched for a single tool call,
+	// so expanding a long list cannot fan out into hundreds of requests.
+	maxExpandFetches = 100
+)
+
+// expandOption is the schema property added to every tool for the expand argument.
+var expandOption = mcp.WithNumber(expandParam,
+	mcp.Description("Inline the objects linked by {index, name, url} references in the result, following references up to this many levels deep (0-3, default 0)."),
+)
+
+// refSlot is the location of a reference inside a decoded JSON document.
+type refSlot struct {
+	parent any // map[string]any or []any
+	key    string
+	pos    int
+	url    string
+}
+
+// set replaces the reference with v.
+func (s refSlot) set(v any) {
+	switch p := s.parent.(type) {
+	case map[string]any:
+		p[s.key] = v
+	case []any:
+		p[s.pos] = v
+	}
+}
+
+// referenceKeys are the fields a reference object may carry. Objects with any other field are full resources.
+var referenceKeys = map[string]bool{"index": true, "name": true, "url": true, "level": true, "source": true}
+
+// referenceURL returns the URL of v if it is a reference object.
+func referenceURL(v any) (string, bool) {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return "", false
+	}
+	u, ok := m["url"].(string)
+	if !ok || u == "" {
+		return "", false
+	}
+	if _, ok := m["index"]; !ok {
+		return "", false
+	}
+	for k := range m {
+		if !referenceKeys[k] {
+			return "", false
+		}
+	}
+	return u, true
+}
+
+// collectReferences appends the location of every reference reachable from node.
+// References are not descended into.
+func collectReferences(node any, slots *[]refSlot) {
+	switch n := node.(type) {
+	case map[string]any:
+		for k, v := range n {
+			if u, ok := referenceURL(v); ok {
+				*slots = append(*slots, refSlot{parent: n, key: k, url: u})
+				continue
+			}
+			collectReferences(v, slots)
+		}
+	case []any:
+		for i, v := range n {
+			if u, ok := referenceURL(v); ok {
+				*slots = append(*slots, refSlot{parent: n, pos: i, url: u})
+				continue
+			}
+			collectReferences(v, slots)
+		}
+	}
+}
+
+// parseReferenceURL splits a reference URL such as "/api/2014/spells/fireball" into its endpoint and index.
+// The version segment is optional, renamed 2024 paths such as "species" map to their endpoint,
+// and the index may name a sub-resource ("fighter/levels").
+func parseReferenceURL(u string) (endpoint, string, bool) {
+	i := strings.Index(u, "/api/")
+	if i < 0 {
+		return "", "", false
+	}
+	parts := strings.Split(strings.Trim(u[i+len("/api/"):], "/"), "/")
+	if len(parts) > 0 && len(parts[0]) == 4 && strings.Trim(parts[0], "0123456789") == "" {
+		parts = parts[1:]
+	}
+	if len(parts) < 2 {
+		return "", "", false
+	}
+	e, ok := endpointForPath(parts[0])
+	if !ok {
+		return "", "", false
+	}
+	return e, strings.Join(parts[1:], "/"), true
+}
+
+// decodeJSON decodes data keeping numbers as json.Number so they are re-encoded unchanged.
+func decodeJSON(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	err := dec.Decode(&v)
+	return v, err
+}
+
+// expandReferences replaces the references in root with the objects they link to, up to depth levels.
+// Each URL is fetched once, concurrently with the others on the same level; references that cannot be
+// fetched are left as they are.
+func expandReferences(ctx context.Context, src dataSource, root any, depth int) {
+	fetched := map[string]json.RawMessage{}
+	attempted := map[string]bool{}
+	frontier := []any{root}
+	for level := 0; level < depth && len(frontier) > 0; level++ {
+		var slots []refSlot
+		for _, n := range frontier {
+			collectReferences(n, &slots)
+		}
+		var pending []string
+		for _, s := range slots {
+			if !attempted[s.url] && len(attempted) < maxExpandFetches {
+				attempted[s.url] = true
+				pending = append(pending, s.url)
+			}
+		}
+
+		bodies := make([]json.RawMessage, len(pending))
+		forEachConcurrently(ctx, len(pending), maxConcurrentFetches, func(ctx context.Context, i int) error {
+			e, index, ok := parseReferenceURL(pending[i])
+			if !ok {
+				return nil
+			}
+			if err := src.Get(ctx, e, index, &bodies[i]); err != nil {
+				logFrom(ctx).WithError(err).WithField("url", pending[i]).Warn("Failed to expand reference")
+			}
+			return nil
+		})
+		for i, u := range pending {
+			if bodies[i] != nil {
+				fetched[u] = bodies[i]
+			}
+		}
+
+		frontier = nil
+		for _, s := range slots {
+			raw, ok := fetched[s.url]
+			if !ok {
+				continue
+			}
+			// Decode each occurrence separately so shared references never alias, which keeps the tree acyclic.
+			v, err := decodeJSON(raw)
+			if err != nil {
+				continue
+			}
+			s.set(v)
+			frontier = append(frontier, v)
+		}
+	}
+}
+
+// expandMiddleware inlines referenced objects into a tool's JSON result when the call sets the expand argument.
+func expandMiddleware(src dataSource) server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			result, err := next(ctx, req)
+			depth := min(req.GetInt(expandParam, 0), maxExpandDepth)
+			if err != nil || result == nil || result.IsError || depth <= 0 || len(result.Content) == 0 {
+				return result, err
+			}
+			text, ok := mcp.AsTextContent(result.Content[0])
+			if !ok {
+				return result, err
+			}
+			root, derr := decodeJSON([]byte(text.Text))
+			if derr != nil {
+				return result, err
+			}
+			expandReferences(ctx, src, root, depth)
+			data, merr := json.Marshal(root)
+			if merr != nil {
+				return result, err
+			}
+			result.Content[0] = mcp.NewTextContent(string(data))
+			return result, err
+		}
+	}
+}
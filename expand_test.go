@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestParseReferenceURL(t *testing.T) {
+	cases := []struct {
+		url       string
+		wantE     endpoint
+		wantIndex string
+		wantOK    bool
+	}{
+		{"/api/2014/spells/fireball", spells, "fireball", true},
+		{"/api/classes/wizard", classes, "wizard", true},
+		{"https://www.dnd5eapi.co/api/2014/ability-scores/dex", abilityScores, "dex", true},
+		{"/api/2014/classes/fighter/levels", classes, "fighter/levels", true},
+		{"/api/2024/species/elf", races, "elf", true},
+		{"/api/2014/vehicles/cart", "", "", false},
+		{"/api/2014/spells", "", "", false},
+		{"/images/fireball.png", "", "", false},
+	}
+	for _, tc := range cases {
+		e, index, ok := parseReferenceURL(tc.url)
+		if e != tc.wantE || index != tc.wantIndex || ok != tc.wantOK {
+			t.Errorf("parseReferenceURL(%q) = %q, %q, %v; want %q, %q, %v", tc.url, e, index, ok, tc.wantE, tc.wantIndex, tc.wantOK)
+		}
+	}
+}
+
+// newExpandTestSource serves a small graph of linked resources and counts fetches per index.
+func newExpandTestSource() (*mockDataSource, map[string]int, *sync.Mutex) {
+	docs := map[string]string{
+		"wizard":    `{"index":"wizard","name":"Wizard","hit_die":6,"url":"/api/2014/classes/wizard","saving_throws":[{"index":"int","name":"INT","url":"/api/2014/ability-scores/int"}]}`,
+		"sorcerer":  `{"index":"sorcerer","name":"Sorcerer","hit_die":6,"url":"/api/2014/classes/sorcerer"}`,
+		"int":       `{"index":"int","name":"INT","full_name":"Intelligence","url":"/api/2014/ability-scores/int"}`,
+		"evocation": `{"index":"evocation","name":"Evocation","desc":"Evocation spells manipulate magical energy.","url":"/api/2014/magic-schools/evocation"}`,
+	}
+	counts := map[string]int{}
+	var mu sync.Mutex
+	src := &mockDataSource{get: func(_ context.Context, _ endpoint, index string, v any) error {
+		mu.Lock()
+		counts[index]++
+		mu.Unlock()
+		doc, ok := docs[index]
+		if !ok {
+			return errNotFound
+		}
+		return json.Unmarshal([]byte(doc), v)
+	}}
+	return src, counts, &mu
+}
+
+const expandTestSpell = `{"spell":{"index":"fireball","name":"Fireball","level":3,"school":{"index":"evocation","name":"Evocation","url":"/api/2014/magic-schools/evocation"},` +
+	`"classes":[{"index":"wizard","name":"Wizard","url":"/api/2014/classes/wizard"},{"index":"sorcerer","name":"Sorcerer","url":"/api/2014/classes/sorcerer"}],` +
+	`"subclasses":[{"index":"lore","name":"Lore","url":"/api/2014/subclasses/lore"}],` +
+	`"damage":{"damage_at_slot_level":{"3":"8d6"}},"url":"/api/2014/spells/fireball"}}`
+
+func TestExpandReferences(t *testing.T) {
+	t.Run("depth 1 inlines direct references", func(t *testing.T) {
+		src, counts, _ := newExpandTestSource()
+		root, _ := decodeJSON([]byte(expandTestSpell))
+		expandReferences(context.Background(), src, root, 1)
+		spell := root.(map[string]any)["spell"].(map[string]any)
+		school := spell["school"].(map[string]any)
+		if school["desc"] != "Evocation spells manipulate magical energy." {
+			t.Errorf("school not expanded: %v", school)
+		}
+		wizard := spell["classes"].([]any)[0].(map[string]any)
+		if wizard["hit_die"] != json.Number("6") {
+			t.Errorf("class not expanded: %v", wizard)
+		}
+		save := wizard["saving_throws"].([]any)[0].(map[string]any)
+		if _, ok := save["full_name"]; ok {
+			t.Errorf("expected nested reference to stay unexpanded at depth 1, got %v", save)
+		}
+		lore := spell["subclasses"].([]any)[0].(map[string]any)
+		if lore["url"] != "/api/2014/subclasses/lore" || len(lore) != 3 {
+			t.Errorf("expected unresolvable reference to be left as is, got %v", lore)
+		}
+		if spell["damage"].(map[string]any)["damage_at_slot_level"].(map[string]any)["3"] != "8d6" {
+			t.Errorf("non-reference fields changed: %v", spell["damage"])
+		}
+		if counts["fireball"] != 0 {
+			t.Errorf("the resource itself should not be fetched")
+		}
+	})
+
+	t.Run("depth 2 follows nested references", func(t *testing.T) {
+		src, _, _ := newExpandTestSource()
+		root, _ := decodeJSON([]byte(expandTestSpell))
+		expandReferences(context.Background(), src, root, 2)
+		wizard := root.(map[string]any)["spell"].(map[string]any)["classes"].([]any)[0].(map[string]any)
+		save := wizard["saving_throws"].([]any)[0].(map[string]any)
+		if save["full_name"] != "Intelligence" {
+			t.Errorf("nested reference not expanded: %v", save)
+		}
+	})
+
+	t.Run("deduplicates fetches", func(t *testing.T) {
+		src, counts, _ := newExpandTestSource()
+		root, _ := decodeJSON([]byte(`{"results":[` +
+			`{"index":"wizard","name":"Wizard","url":"/api/2014/classes/wizard"},` +
+			`{"index":"wizard","name":"Wizard","url":"/api/2014/classes/wizard"},` +
+			`{"index":"int","name":"INT","url":"/api/2014/ability-scores/int"}]}`))
+		expandReferences(context.Background(), src, root, 2)
+		if counts["wizard"] != 1 || counts["int"] != 1 {
+			t.Errorf("expected one fetch per URL across levels, got %v", counts)
+		}
+		results := root.(map[string]any)["results"].([]any)
+		a, b := results[0].(map[string]any), results[1].(map[string]any)
+		a["name"] = "changed"
+		if b["name"] != "Wizard" {
+			t.Errorf("expanded copies must not alias each other")
+		}
+	})
+
+	t.Run("caps fetches per call", func(t *testing.T) {
+		src, counts, _ := newExpandTestSource()
+		var refs []string
+		for i := 0; i < maxExpandFetches+20; i++ {
+			refs = append(refs, fmt.Sprintf(`{"index":"spell-%d","name":"Spell","url":"/api/2014/spells/spell-%d"}`, i, i))
+		}
+		root, _ := decodeJSON([]byte(`{"results":[` + joinJSON(refs) + `]}`))
+		expandReferences(context.Background(), src, root, 1)
+		total := 0
+		for _, n := range counts {
+			total += n
+		}
+		if total != maxExpandFetches {
+			t.Errorf("expected %d fetches, got %d", maxExpandFetches, total)
+		}
+	})
+}
+
+func joinJSON(items []string) string {
+	out := ""
+	for i, s := range items {
+		if i > 0 {
+			out += ","
+		}
+		out += s
+	}
+	return out
+}
+
+func TestExpandMiddleware(t *testing.T) {
+	src, _, _ := newExpandTestSource()
+	handler := expandMiddleware(src)(func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText(expandTestSpell), nil
+	})
+	call := func(args map[string]any) map[string]any {
+		t.Helper()
+		req := mcp.CallToolRequest{}
+		req.Params.Arguments = args
+		res, err := handler(context.Background(), req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		txt, _ := mcp.AsTextContent(res.Content[0])
+		var out map[string]any
+		if err := json.Unmarshal([]byte(txt.Text), &out); err != nil {
+			t.Fatalf("unmarshal output: %v", err)
+		}
+		return out
+	}
+
+	school := func(out map[string]any) map[string]any {
+		return out["spell"].(map[string]any)["school"].(map[string]any)
+	}
+	if s := school(call(nil)); len(s) != 3 {
+		t.Errorf("expected no expansion without the expand argument, got %v", s)
+	}
+	if s := school(call(map[string]any{"expand": float64(1)})); s["desc"] == nil {
+		t.Errorf("expected school to be expanded, got %v", s)
+	}
+
+	failing := expandMiddleware(src)(func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultError("boom"), nil
+	})
+	req := mcp.CallToolRequest{}
+	req.Params.Arguments = map[string]any{"expand": float64(2)}
+	res, _ := failing(context.Background(), req)
+	if txt, _ := mcp.AsTextContent(res.Content[0]); !res.IsError || txt.Text != "boom" {
+		t.Errorf("expected error results to pass through unchanged, got %+v", res)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// featToolInput defines the input structure for the feats tool.
+type featToolInput struct {
+	Name string `json:"name" mcp:"description=The index of the feat to retrieve (e.g., 'grappler')."`
+}
+
+// featListAPIResponse defines the structure for a single feat in the list response.
+type featListAPIResponse struct {
+	Index string `json:"index"`
+	Name  string `json:"name"`
+	URL   string `json:"url"`
+}
+
+// featDetail defines the structure for a detailed feat response.
+// Type ("origin", "general", ...) and Description are only returned by the 2024 ruleset.
+type featDetail struct {
+	Index         string                     `json:"index"`
+	Name          string                     `json:"name"`
+	Type          string                     `json:"type,omitempty"`
+	Prerequisites []abilityScorePrerequisite `json:"prerequisites,omitempty"`
+	Desc          paragraphs                 `json:"desc,omitempty"`
+	Description   string                     `json:"description,omitempty"`
+	URL           string                     `json:"url"`
+}
+
+// featToolOutput defines the output structure for the feats tool.
+type featToolOutput struct {
+	Count   int                   `json:"count,omitempty"`
+	Results []featListAPIResponse `json:"results,omitempty"`
+	Feat    *featDetail           `json:"feat,omitempty"`
+}
+
+// fetchFeatByNameResult fetches a feat by index and returns an MCP tool result.
+func fetchFeatByNameResult(
+	ctx context.Context,
+	src dataSource,
+	input featToolInput,
+) (*mcp.CallToolResult, error) {
+	feat := &featDetail{}
+	err := fetchByName(ctx, src, feats, input.Name, feat)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to fetch feat", err), err
+	}
+	output := featToolOutput{Feat: feat}
+	jsonData, err := json.Marshal(output)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal feat output", err), err
+	}
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+// fetchFeatListResult fetches a list of feats and returns an MCP tool result.
+func fetchFeatListResult(
+	ctx context.Context,
+	src dataSource,
+) (*mcp.CallToolResult, error) {
+	var results []featListAPIResponse
+	err := fetchList(ctx, src, feats, &results, "")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to fetch feat list", err), err
+	}
+	output := featToolOutput{Count: len(results), Results: results}
+	jsonData, err := json.Marshal(output)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal feat list output", err), err
+	}
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+// runFeatTool executes the core logic for the feats tool.
+func runFeatTool(ctx context.Context, src dataSource, input featToolInput) (*mcp.CallToolResult, error) {
+	if input.Name != "" {
+		return fetchFeatByNameResult(ctx, src, input)
+	}
+	return fetchFeatListResult(ctx, src)
+}
+
+// handleFeatTool returns the MCP handler for the feats tool.
+func handleFeatTool(src dataSource) mcp.TypedToolHandlerFunc[featToolInput] {
+	return func(ctx context.Context, req mcp.CallToolRequest, input featToolInput) (*mcp.CallToolResult, error) {
+		return runFeatTool(ctx, src, input)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestRunFeatTool(t *testing.T) {
+	grappler := featDetail{
+		Index:         "grappler",
+		Name:          "Grappler",
+		Prerequisites: []abilityScorePrerequisite{{AbilityScore: apiReference{Index: "str", Name: "STR"}, MinimumScore: 13}},
+		Desc:          []string{"You've developed the skills necessary to hold your own in close-quarters grappling."},
+	}
+	list := []featListAPIResponse{{Index: "grappler", Name: "Grappler"}}
+
+	t.Run("by name", func(t *testing.T) {
+		src := &mockDataSource{get: func(_ context.Context, e endpoint, index string, v any) error {
+			if e != feats || index != "grappler" {
+				return errNotFound
+			}
+			*v.(*featDetail) = grappler
+			return nil
+		}}
+		res, err := runFeatTool(context.Background(), src, featToolInput{Name: "Grappler"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		txt, _ := mcp.AsTextContent(res.Content[0])
+		var out featToolOutput
+		if err := json.Unmarshal([]byte(txt.Text), &out); err != nil {
+			t.Fatalf("unmarshal output: %v", err)
+		}
+		if out.Feat == nil || len(out.Feat.Prerequisites) != 1 || out.Feat.Prerequisites[0].MinimumScore != 13 {
+			t.Errorf("unexpected feat: %+v", out.Feat)
+		}
+	})
+
+	t.Run("by name error", func(t *testing.T) {
+		src := &mockDataSource{get: func(context.Context, endpoint, string, any) error { return errNotFound }}
+		res, err := runFeatTool(context.Background(), src, featToolInput{Name: "sentinel"})
+		if !errors.Is(err, errNotFound) || res == nil || !res.IsError {
+			t.Errorf("expected not found error result, got %+v, %v", res, err)
+		}
+	})
+
+	t.Run("list", func(t *testing.T) {
+		src := &mockDataSource{list: func(_ context.Context, _ endpoint, _ string, v any) error {
+			*v.(*[]featListAPIResponse) = list
+			return nil
+		}}
+		res, err := runFeatTool(context.Background(), src, featToolInput{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		txt, _ := mcp.AsTextContent(res.Content[0])
+		var out featToolOutput
+		if err := json.Unmarshal([]byte(txt.Text), &out); err != nil {
+			t.Fatalf("unmarshal output: %v", err)
+		}
+		if out.Count != 1 || len(out.Results) != 1 {
+			t.Errorf("expected 1 feat, got %+v", out)
+		}
+	})
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// featureToolInput defines the input structure for the features tool.
+type featureToolInput struct {
+	Name string `json:"name" mcp:"description=The index of the class feature to retrieve (e.g., 'divine-smite', 'rage')."`
+}
+
+// featureListAPIResponse defines the structure for a single feature in the list response.
+type featureListAPIResponse struct {
+	Index string `json:"index"`
+	Name  string `json:"name"`
+	URL   string `json:"url"`
+}
+
+// featurePrerequisite is a requirement that must be met before a feature can be taken.
+type featurePrerequisite struct {
+	Type    string `json:"type"`
+	Level   int    `json:"level,omitempty"`
+	Feature string `json:"feature,omitempty"`
+	Spell   string `json:"spell,omitempty"`
+}
+
+// featureDetail defines the structure for a detailed class or subclass feature response.
+// Subclass is only present for features granted by a subclass.
+type featureDetail struct {
+	Index         string                `json:"index"`
+	Name          string                `json:"name"`
+	Level         int                   `json:"level"`
+	Class         apiReference          `json:"class"`
+	Subclass      *apiReference         `json:"subclass,omitempty"`
+	Parent        *apiReference         `json:"parent,omitempty"`
+	Prerequisites []featurePrerequisite `json:"prerequisites,omitempty"`
+	Desc          []string              `json:"desc"`
+	URL           string                `json:"url"`
+}
+
+// featureToolOutput defines the output structure for the features tool.
+type featureToolOutput struct {
+	Count   int                      `json:"count,omitempty"`
+	Results []featureListAPIResponse `json:"results,omitempty"`
+	Feature *featureDetail           `json:"feature,omitempty"`
+}
+
+// fetchFeatureByNameResult fetches a feature by index and returns an MCP tool result.
+func fetchFeatureByNameResult(
+	ctx context.Context,
+	src dataSource,
+	input featureToolInput,
+) (*mcp.CallToolResult, error) {
+	feature := &featureDetail{}
+	err := fetchByName(ctx, src, features, input.Name, feature)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to fetch feature", err), err
+	}
+	output := featureToolOutput{Feature: feature}
+	jsonData, err := json.Marshal(output)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal feature output", err), err
+	}
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+// fetchFeatureListResult fetches a list of features and returns an MCP tool result.
+func fetchFeatureListResult(
+	ctx context.Context,
+	src dataSource,
+) (*mcp.CallToolResult, error) {
+	var results []featureListAPIResponse
+	err := fetchList(ctx, src, features, &results, "")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to fetch feature list", err), err
+	}
+	output := featureToolOutput{Count: len(results), Results: results}
+	jsonData, err := json.Marshal(output)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal feature list output", err), err
+	}
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+// runFeatureTool executes the core logic for the features tool.
+func runFeatureTool(ctx context.Context, src dataSource, input featureToolInput) (*mcp.CallToolResult, error) {
+	if input.Name != "" {
+		return fetchFeatureByNameResult(ctx, src, input)
+	}
+	return fetchFeatureListResult(ctx, src)
+}
+
+// handleFeatureTool returns the MCP handler for the features tool.
+func handleFeatureTool(src dataSource) mcp.TypedToolHandlerFunc[featureToolInput] {
+	return func(ctx context.Context, req mcp.CallToolRequest, input featureToolInput) (*mcp.CallToolResult, error) {
+		return runFeatureTool(ctx, src, input)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestRunFeatureTool(t *testing.T) {
+	smite := featureDetail{
+		Index:         "divine-smite",
+		Name:          "Divine Smite",
+		Level:         2,
+		Class:         apiReference{Index: "paladin", Name: "Paladin"},
+		Prerequisites: []featurePrerequisite{},
+		Desc:          []string{"Starting at 2nd level, when you hit a creature with a melee weapon attack..."},
+	}
+	list := []featureListAPIResponse{{Index: "divine-smite", Name: "Divine Smite"}, {Index: "rage", Name: "Rage"}}
+
+	t.Run("by name", func(t *testing.T) {
+		src := &mockDataSource{get: func(_ context.Context, e endpoint, index string, v any) error {
+			if e != features || index != "divine-smite" {
+				return errNotFound
+			}
+			*v.(*featureDetail) = smite
+			return nil
+		}}
+		res, err := runFeatureTool(context.Background(), src, featureToolInput{Name: "Divine Smite"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		txt, _ := mcp.AsTextContent(res.Content[0])
+		var out featureToolOutput
+		if err := json.Unmarshal([]byte(txt.Text), &out); err != nil {
+			t.Fatalf("unmarshal output: %v", err)
+		}
+		if out.Feature == nil || out.Feature.Level != 2 || out.Feature.Class.Index != "paladin" {
+			t.Errorf("unexpected feature: %+v", out.Feature)
+		}
+	})
+
+	t.Run("by name error", func(t *testing.T) {
+		src := &mockDataSource{get: func(context.Context, endpoint, string, any) error { return errNotFound }}
+		res, err := runFeatureTool(context.Background(), src, featureToolInput{Name: "unknown"})
+		if !errors.Is(err, errNotFound) || res == nil || !res.IsError {
+			t.Errorf("expected not found error result, got %+v, %v", res, err)
+		}
+	})
+
+	t.Run("list", func(t *testing.T) {
+		src := &mockDataSource{list: func(_ context.Context, _ endpoint, _ string, v any) error {
+			*v.(*[]featureListAPIResponse) = list
+			return nil
+		}}
+		res, err := runFeatureTool(context.Background(), src, featureToolInput{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		txt, _ := mcp.AsTextContent(res.Content[0])
+		var out featureToolOutput
+		if err := json.Unmarshal([]byte(txt.Text), &out); err != nil {
+			t.Fatalf("unmarshal output: %v", err)
+		}
+		if out.Count != 2 || len(out.Results) != 2 {
+			t.Errorf("expected 2 features, got %+v", out)
+		}
+	})
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// glossaryToolName is the name under which the glossary tool is registered.
+const glossaryToolName = "glossary"
+
+// glossaryCategories are the small reference sets covered by the glossary tool.
+var glossaryCategories = []endpoint{damageTypes, magicSchools, weaponProperties}
+
+// glossaryToolInput defines the input structure for the glossary tool.
+type glossaryToolInput struct {
+	Category string `json:"category" mcp:"description=The glossary to use: 'damage-types', 'magic-schools' or 'weapon-properties'. Searches all of them if omitted."`
+	Name     string `json:"name" mcp:"description=The term to look up, by index, name or unambiguous prefix (e.g., 'fire', 'Evocation', 'evo', 'finesse')."`
+}
+
+// glossaryListEntry defines the structure for a single glossary term in the list response.
+type glossaryListEntry struct {
+	Category endpoint `json:"category"`
+	Index    string   `json:"index"`
+	Name     string   `json:"name"`
+	URL      string   `json:"url"`
+}
+
+// glossaryEntry defines the structure for a detailed damage type, magic school or weapon property response.
+// In the 2014 ruleset magic schools describe themselves in a single string and the other terms in paragraphs;
+// the 2024 ruleset uses Description for all three.
+type glossaryEntry struct {
+	Category    endpoint   `json:"category"`
+	Index       string     `json:"index"`
+	Name        string     `json:"name"`
+	Desc        paragraphs `json:"desc,omitempty"`
+	Description string     `json:"description,omitempty"`
+	URL         string     `json:"url"`
+}
+
+// glossaryToolOutput defines the output structure for the glossary tool.
+type glossaryToolOutput struct {
+	Count   int                 `json:"count,omitempty"`
+	Results []glossaryListEntry `json:"results,omitempty"`
+	Entry   *glossaryEntry      `json:"entry,omitempty"`
+}
+
+// glossaryCategory resolves a category name, or a prefix of one such as "damage", to its endpoint.
+// An empty name selects every category.
+func glossaryCategory(name string) ([]endpoint, error) {
+	if name == "" {
+		return glossaryCategories, nil
+	}
+	key := toKebabCase(name)
+	for _, e := range glossaryCategories {
+		if strings.HasPrefix(string(e), key) {
+			return []endpoint{e}, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown glossary category %q: must be one of %v", name, glossaryCategories)
+}
+
+// fetchGlossaryEntries lists the terms of the given categories.
+func fetchGlossaryEntries(ctx context.Context, src dataSource, categories []endpoint) ([]glossaryListEntry, error) {
+	var entries []glossaryListEntry
+	for _, e := range categories {
+		var refs []apiReference
+		if err := fetchList(ctx, src, e, &refs, ""); err != nil {
+			return nil, err
+		}
+		for _, r := range refs {
+			entries = append(entries, glossaryListEntry{Category: e, Index: r.Index, Name: r.Name, URL: r.URL})
+		}
+	}
+	return entries, nil
+}
+
+// matchGlossaryTerm finds the entry matching term by index or name, ignoring case, or failing that
+// the single entry whose index or name starts with term. Ambiguous and unknown terms are reported with the candidates.
+func matchGlossaryTerm(entries []glossaryListEntry, term string) (glossaryListEntry, error) {
+	key := toKebabCase(term)
+	for _, e := range entries {
+		if e.Index == key || strings.EqualFold(e.Name, term) {
+			return e, nil
+		}
+	}
+	var candidates []glossaryListEntry
+	for _, e := range entries {
+		if strings.HasPrefix(e.Index, key) || strings.HasPrefix(strings.ToLower(e.Name), strings.ToLower(term)) {
+			candidates = append(candidates, e)
+		}
+	}
+	if len(candidates) == 1 {
+		return candidates[0], nil
+	}
+	names := func(es []glossaryListEntry) string {
+		n := make([]string, len(es))
+		for i, e := range es {
+			n[i] = e.Name
+		}
+		return strings.Join(n, ", ")
+	}
+	if len(candidates) > 1 {
+		return glossaryListEntry{}, fmt.Errorf("%w: %q is ambiguous, could be %s", errNotFound, term, names(candidates))
+	}
+	return glossaryListEntry{}, fmt.Errorf("%w: %q, expected one of %s", errNotFound, term, names(entries))
+}
+
+// normalizeMagicSchool resolves a school of magic given by index, name or prefix (e.g. "evo") to its index.
+func normalizeMagicSchool(ctx context.Context, src dataSource, school string) (string, error) {
+	entries, err := fetchGlossaryEntries(ctx, src, []endpoint{magicSchools})
+	if err != nil {
+		return "", err
+	}
+	entry, err := matchGlossaryTerm(entries, school)
+	if err != nil {
+		return "", fmt.Errorf("invalid school of magic: %w", err)
+	}
+	return entry.Index, nil
+}
+
+// fetchGlossaryByNameResult looks up a single glossary term and returns an MCP tool result.
+func fetchGlossaryByNameResult(
+	ctx context.Context,
+	src dataSource,
+	categories []endpoint,
+	input glossaryToolInput,
+) (*mcp.CallToolResult, error) {
+	entries, err := fetchGlossaryEntries(ctx, src, categories)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to fetch glossary", err), err
+	}
+	match, err := matchGlossaryTerm(entries, input.Name)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to find glossary term", err), err
+	}
+	entry := &glossaryEntry{}
+	if err := src.Get(ctx, match.Category, match.Index, entry); err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to fetch glossary term", err), err
+	}
+	entry.Category = match.Category
+	output := glossaryToolOutput{Entry: entry}
+	jsonData, err := json.Marshal(output)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal glossary output", err), err
+	}
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+// fetchGlossaryListResult lists the terms of the selected categories and returns an MCP tool result.
+func fetchGlossaryListResult(
+	ctx context.Context,
+	src dataSource,
+	categories []endpoint,
+) (*mcp.CallToolResult, error) {
+	entries, err := fetchGlossaryEntries(ctx, src, categories)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to fetch glossary", err), err
+	}
+	output := glossaryToolOutput{Count: len(entries), Results: entries}
+	jsonData, err := json.Marshal(output)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal glossary list output", err), err
+	}
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+// runGlossaryTool executes the core logic for the glossary tool.
+func runGlossaryTool(ctx context.Context, src dataSource, input glossaryToolInput) (*mcp.CallToolResult, error) {
+	categories, err := glossaryCategory(input.Category)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("invalid glossary category", err), err
+	}
+	if input.Name != "" {
+		return fetchGlossaryByNameResult(ctx, src, categories, input)
+	}
+	return fetchGlossaryListResult(ctx, src, categories)
+}
+
+// handleGlossaryTool returns the MCP handler for the glossary tool.
+func handleGlossaryTool(src dataSource) mcp.TypedToolHandlerFunc[glossaryToolInput] {
+	return func(ctx context.Context, req mcp.CallToolRequest, input glossaryToolInput) (*mcp.CallToolResult, error) {
+		return runGlossaryTool(ctx, src, input)
+	}
+}
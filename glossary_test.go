@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestRunGlossaryTool(t *testing.T) {
+	lists := map[endpoint][]apiReference{
+		damageTypes:      {{Index: "fire", Name: "Fire"}, {Index: "force", Name: "Force"}},
+		magicSchools:     {{Index: "evocation", Name: "Evocation"}, {Index: "illusion", Name: "Illusion"}},
+		weaponProperties: {{Index: "finesse", Name: "Finesse"}, {Index: "light", Name: "Light"}},
+	}
+	src := &mockDataSource{
+		get: func(_ context.Context, e endpoint, index string, v any) error {
+			for _, r := range lists[e] {
+				if r.Index == index {
+					*v.(*glossaryEntry) = glossaryEntry{Index: r.Index, Name: r.Name, Desc: []string{r.Name + " description"}}
+					return nil
+				}
+			}
+			return errNotFound
+		},
+		list: func(_ context.Context, e endpoint, _ string, v any) error {
+			*v.(*[]apiReference) = lists[e]
+			return nil
+		},
+	}
+	run := func(t *testing.T, input glossaryToolInput) (glossaryToolOutput, error) {
+		t.Helper()
+		res, err := runGlossaryTool(context.Background(), src, input)
+		if err != nil {
+			if res == nil || !res.IsError {
+				t.Fatalf("expected error result, got %+v", res)
+			}
+			return glossaryToolOutput{}, err
+		}
+		txt, _ := mcp.AsTextContent(res.Content[0])
+		var out glossaryToolOutput
+		if err := json.Unmarshal([]byte(txt.Text), &out); err != nil {
+			t.Fatalf("unmarshal output: %v", err)
+		}
+		return out, nil
+	}
+
+	t.Run("term across all categories", func(t *testing.T) {
+		out, err := run(t, glossaryToolInput{Name: "Finesse"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if out.Entry == nil || out.Entry.Category != weaponProperties || len(out.Entry.Desc) != 1 {
+			t.Errorf("unexpected entry: %+v", out.Entry)
+		}
+	})
+
+	t.Run("prefix within category", func(t *testing.T) {
+		out, err := run(t, glossaryToolInput{Category: "magic-schools", Name: "evo"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if out.Entry == nil || out.Entry.Index != "evocation" {
+			t.Errorf("unexpected entry: %+v", out.Entry)
+		}
+	})
+
+	t.Run("ambiguous prefix", func(t *testing.T) {
+		_, err := run(t, glossaryToolInput{Category: "damage", Name: "f"})
+		if !errors.Is(err, errNotFound) || !strings.Contains(err.Error(), "ambiguous") {
+			t.Errorf("expected ambiguous error, got %v", err)
+		}
+	})
+
+	t.Run("list one category", func(t *testing.T) {
+		out, err := run(t, glossaryToolInput{Category: "weapon properties"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if out.Count != 2 || out.Results[0].Category != weaponProperties {
+			t.Errorf("unexpected list: %+v", out)
+		}
+	})
+
+	t.Run("list all categories", func(t *testing.T) {
+		out, err := run(t, glossaryToolInput{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if out.Count != 6 {
+			t.Errorf("expected 6 terms, got %+v", out)
+		}
+	})
+
+	t.Run("unknown category", func(t *testing.T) {
+		if _, err := run(t, glossaryToolInput{Category: "conditions"}); err == nil {
+			t.Errorf("expected error for unknown category")
+		}
+	})
+}
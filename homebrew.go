@@ -0,0 +1,587 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// homebrewSource is the value of the "source" field added to every homebrew entry.
+const homebrewSource = "homebrew"
+
+// homebrewEntry is a resource type that can be defined in the homebrew store.
+// validate reports the first problem that would make the entry unusable by its tool.
+type homebrewEntry interface {
+	validate() error
+}
+
+// homebrewEndpoints lists the endpoints that accept homebrew entries, each with a constructor for the
+// type the entries are validated against. Entries use the same shape as the API's detail responses.
+var homebrewEndpoints = map[endpoint]func() homebrewEntry{
+	spells:     func() homebrewEntry { return &spellAPIResponse{} },
+	monsters:   func() homebrewEntry { return &monsterDetail{} },
+	magicItems: func() homebrewEntry { return &magicItemDetail{} },
+}
+
+// homebrewListKeys are the fields of a homebrew entry copied into its list entry.
+var homebrewListKeys = []string{"index", "name", "level", "url", "source"}
+
+// homebrewKey identifies a homebrew entry.
+type homebrewKey struct {
+	e     endpoint
+	index string
+}
+
+// homebrewDataSource is a dataSource that serves homebrew entries on top of another data source.
+// Homebrew entries are added to list and search results and shadow base entries with the same index.
+// Entries are kept with their "url" and "source" fields filled in, and remember the file they were read
+// from so that changes can be written back to it.
+type homebrewDataSource struct {
+	base dataSource
+	dir  string // empty if the store was not loaded from a directory and cannot be changed
+
+	mu      sync.RWMutex
+	entries map[endpoint]map[string]map[string]any
+	files   map[string][]homebrewKey // slash-separated path relative to dir -> entries in file order
+	origin  map[homebrewKey]string
+	gen     atomic.Uint64
+}
+
+// loadHomebrew reads every homebrew file under dir and returns a data source that merges them into base.
+// Entries live in a subdirectory named after their endpoint, e.g. spells/frost-fingers.yaml, and each
+// .json, .yaml or .yml file holds a single entry or a list of entries. Every problem found is reported.
+func loadHomebrew(dir string, base dataSource) (*homebrewDataSource, error) {
+	s, err := loadHomebrewFS(os.DirFS(dir), base)
+	if err != nil {
+		return nil, err
+	}
+	s.dir = dir
+	return s, nil
+}
+
+// loadHomebrewFS is loadHomebrew over an fs.FS. The returned store is read-only.
+func loadHomebrewFS(fsys fs.FS, base dataSource) (*homebrewDataSource, error) {
+	s := &homebrewDataSource{
+		base:    base,
+		entries: map[endpoint]map[string]map[string]any{},
+		files:   map[string][]homebrewKey{},
+		origin:  map[homebrewKey]string{},
+	}
+	defined := map[homebrewKey]string{}
+	var errs []error
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		ext := strings.ToLower(path.Ext(p))
+		if d.IsDir() || (ext != ".json" && ext != ".yaml" && ext != ".yml") {
+			return nil
+		}
+		e := endpoint(strings.SplitN(p, "/", 2)[0])
+		if _, ok := homebrewEndpoints[e]; !ok || !strings.Contains(p, "/") {
+			errs = append(errs, fmt.Errorf("%s: homebrew files must be in one of the directories %s", p, strings.Join(homebrewDirs(), ", ")))
+			return nil
+		}
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		items, err := decodeHomebrewFile(data, ext)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", p, err))
+			return nil
+		}
+		for i, item := range items {
+			where := p
+			if len(items) > 1 {
+				where = fmt.Sprintf("%s[%d]", p, i)
+			}
+			index, err := validateHomebrewEntry(e, item)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", where, err))
+				continue
+			}
+			key := homebrewKey{e, index}
+			if prev, ok := defined[key]; ok {
+				errs = append(errs, fmt.Errorf("%s: duplicate %s index %q, already defined in %s", where, e, index, prev))
+				continue
+			}
+			defined[key] = where
+			s.set(key, p, item)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid homebrew content: %w", errors.Join(errs...))
+	}
+	for e, items := range s.entries {
+		logrus.WithFields(logrus.Fields{"endpoint": e, "count": len(items)}).Info("Loaded homebrew entries")
+	}
+	return s, nil
+}
+
+// set stores item as the entry key, read from or written to file, and fills in its "url" and "source" fields.
+// The caller must hold s.mu for writing.
+func (s *homebrewDataSource) set(key homebrewKey, file string, item map[string]any) {
+	if _, ok := item["url"]; !ok {
+		item["url"] = homebrewURL(key)
+	}
+	item["source"] = homebrewSource
+	if s.entries[key.e] == nil {
+		s.entries[key.e] = map[string]map[string]any{}
+	}
+	if _, ok := s.origin[key]; !ok {
+		s.files[file] = append(s.files[file], key)
+	}
+	s.entries[key.e][key.index] = item
+	s.origin[key] = file
+}
+
+// homebrewURL returns the URL given to a homebrew entry that does not set one.
+func homebrewURL(key homebrewKey) string {
+	return "/api/" + string(key.e) + "/" + key.index
+}
+
+// generation returns a number that changes whenever a homebrew entry is created, updated or deleted.
+func (s *homebrewDataSource) generation() uint64 {
+	return s.gen.Load()
+}
+
+// homebrewDirs returns the sorted directory names that hold homebrew entries.
+func homebrewDirs() []string {
+	var dirs []string
+	for e := range homebrewEndpoints {
+		dirs = append(dirs, string(e))
+	}
+	sort.Strings(dirs)
+	return dirs
+}
+
+// decodeHomebrewFile decodes a JSON or YAML file holding one entry or a list of entries.
+func decodeHomebrewFile(data []byte, ext string) ([]map[string]any, error) {
+	var doc any
+	if ext == ".json" {
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+	} else {
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+		// Round-trip through JSON so YAML values take the same types as JSON ones.
+		j, err := json.Marshal(doc)
+		if err != nil {
+			return nil, err
+		}
+		doc = nil
+		if err := json.Unmarshal(j, &doc); err != nil {
+			return nil, err
+		}
+	}
+	switch d := doc.(type) {
+	case map[string]any:
+		return []map[string]any{d}, nil
+	case []any:
+		items := make([]map[string]any, len(d))
+		for i, v := range d {
+			m, ok := v.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("entry %d is not an object", i)
+			}
+			items[i] = m
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("expected an object or a list of objects")
+	}
+}
+
+// validateHomebrewEntry checks that item decodes as the endpoint's detail type and is usable, and returns its index.
+// Indexes must already be in API form (lowercase words joined by hyphens) so they can shadow SRD entries.
+func validateHomebrewEntry(e endpoint, item map[string]any) (string, error) {
+	index, _ := item["index"].(string)
+	name, _ := item["name"].(string)
+	switch {
+	case index == "":
+		return "", fmt.Errorf("index is required")
+	case normalizeName(index) != index:
+		return "", fmt.Errorf("index %q must be lowercase words joined by hyphens, e.g. %q", index, normalizeName(index))
+	case strings.TrimSpace(name) == "":
+		return "", fmt.Errorf("%s: name is required", index)
+	}
+	data, err := json.Marshal(item)
+	if err != nil {
+		return "", err
+	}
+	detail := homebrewEndpoints[e]()
+	if err := json.Unmarshal(data, detail); err != nil {
+		return "", fmt.Errorf("%s: %w", index, err)
+	}
+	if err := detail.validate(); err != nil {
+		return "", fmt.Errorf("%s: %w", index, err)
+	}
+	return index, nil
+}
+
+// Get returns the homebrew entry with the given index, or else fetches it from the base data source.
+// Equipment categories also list the homebrew entries in the category.
+func (s *homebrewDataSource) Get(ctx context.Context, e endpoint, index string, v any) error {
+	s.mu.RLock()
+	item, ok := s.entries[e][index]
+	s.mu.RUnlock()
+	if ok {
+		return remarshal(item, v)
+	}
+	if e == equipmentCategories {
+		return s.getEquipmentCategory(ctx, index, v)
+	}
+	return s.base.Get(ctx, e, index, v)
+}
+
+// List lists the base entries of an endpoint that are not shadowed, followed by the homebrew entries
+// that match the filter.
+func (s *homebrewDataSource) List(ctx context.Context, e endpoint, filter string, v any) error {
+	if !s.has(e) {
+		return s.base.List(ctx, e, filter, v)
+	}
+	params, err := url.ParseQuery(filter)
+	if err != nil {
+		return fmt.Errorf("invalid filter %q: %w", filter, err)
+	}
+	return s.merge(ctx, e, v, func(list *[]map[string]any) error {
+		return s.base.List(ctx, e, filter, list)
+	}, func(item map[string]any) bool {
+		return matchesFilter(item, params)
+	})
+}
+
+// Search searches the base entries of an endpoint that are not shadowed, followed by the homebrew entries
+// whose name contains the query.
+func (s *homebrewDataSource) Search(ctx context.Context, e endpoint, query string, v any) error {
+	if !s.has(e) {
+		return s.base.Search(ctx, e, query, v)
+	}
+	return s.merge(ctx, e, v, func(list *[]map[string]any) error {
+		return s.base.Search(ctx, e, query, list)
+	}, func(item map[string]any) bool {
+		name, _ := item["name"].(string)
+		return containsFold(name, []string{query})
+	})
+}
+
+// merge combines a base list with the homebrew entries of e that satisfy keep and decodes the result into v.
+// A base endpoint that does not exist, such as spells in the 2024 ruleset, contributes no entries.
+func (s *homebrewDataSource) merge(
+	ctx context.Context,
+	e endpoint,
+	v any,
+	baseList func(*[]map[string]any) error,
+	keep func(map[string]any) bool,
+) error {
+	var list []map[string]any
+	if err := baseList(&list); err != nil && !errors.Is(err, errNotFound) {
+		return err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	merged := []map[string]any{}
+	for _, ref := range list {
+		if index, _ := ref["index"].(string); s.entries[e][index] == nil {
+			merged = append(merged, ref)
+		}
+	}
+	for _, index := range s.indexes(e) {
+		item := s.entries[e][index]
+		if keep(item) {
+			merged = append(merged, homebrewListEntry(item))
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return remarshal(merged, v)
+}
+
+// getEquipmentCategory fetches an equipment category and adds the homebrew entries in it.
+// A category that only homebrew entries belong to is reported as not found.
+func (s *homebrewDataSource) getEquipmentCategory(ctx context.Context, index string, v any) error {
+	var cat map[string]any
+	if err := s.base.Get(ctx, equipmentCategories, index, &cat); err != nil {
+		return err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	refs, _ := cat["equipment"].([]any)
+	seen := map[string]bool{}
+	for _, r := range refs {
+		if m, ok := r.(map[string]any); ok {
+			u, _ := m["url"].(string)
+			seen[u] = true
+		}
+	}
+	for _, e := range sortedEndpoints(s.entries) {
+		for _, i := range s.indexes(e) {
+			item := s.entries[e][i]
+			category, _ := item["equipment_category"].(map[string]any)
+			if category["index"] != index {
+				continue
+			}
+			ref := homebrewListEntry(item)
+			if u, _ := ref["url"].(string); !seen[u] {
+				refs = append(refs, ref)
+			}
+		}
+	}
+	cat["equipment"] = refs
+	return remarshal(cat, v)
+}
+
+// has reports whether there are homebrew entries for e.
+func (s *homebrewDataSource) has(e endpoint) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.entries[e]) > 0
+}
+
+// indexes returns the indexes of the homebrew entries of e in sorted order. The caller must hold s.mu.
+func (s *homebrewDataSource) indexes(e endpoint) []string {
+	indexes := make([]string, 0, len(s.entries[e]))
+	for index := range s.entries[e] {
+		indexes = append(indexes, index)
+	}
+	sort.Strings(indexes)
+	return indexes
+}
+
+// sortedEndpoints returns the endpoints with homebrew entries in sorted order.
+func sortedEndpoints(entries map[endpoint]map[string]map[string]any) []endpoint {
+	es := make([]endpoint, 0, len(entries))
+	for e := range entries {
+		es = append(es, e)
+	}
+	sort.Slice(es, func(i, j int) bool { return es[i] < es[j] })
+	return es
+}
+
+// homebrewListEntry returns the list entry for a homebrew entry.
+func homebrewListEntry(item map[string]any) map[string]any {
+	ref := map[string]any{}
+	for _, k := range homebrewListKeys {
+		if val, ok := item[k]; ok {
+			ref[k] = val
+		}
+	}
+	return ref
+}
+
+// remarshal copies a decoded JSON value into v by encoding and decoding it.
+func remarshal(from, v any) error {
+	data, err := json.Marshal(from)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// homebrewHistoryFile is the append-only change log kept in the homebrew directory, one JSON record per line.
+const homebrewHistoryFile = ".history.jsonl"
+
+// homebrewChange is a record in the homebrew change history.
+type homebrewChange struct {
+	Time     time.Time      `json:"time"`
+	Action   string         `json:"action"`
+	Category endpoint       `json:"category"`
+	Index    string         `json:"index"`
+	File     string         `json:"file"`
+	Before   map[string]any `json:"before,omitempty"`
+	After    map[string]any `json:"after,omitempty"`
+}
+
+// errHomebrewReadOnly is returned when changing a homebrew store that was not loaded from a directory.
+var errHomebrewReadOnly = errors.New("homebrew store is read-only; start the server with -homebrew <dir> to edit it")
+
+// put validates item and stores it as a homebrew entry of e. With replace unset the entry must not exist yet
+// and is written to <e>/<index>.json; otherwise it must exist and its file is rewritten in place.
+func (s *homebrewDataSource) put(ctx context.Context, e endpoint, item map[string]any, replace bool) (homebrewChange, error) {
+	if s.dir == "" {
+		return homebrewChange{}, errHomebrewReadOnly
+	}
+	if err := checkHomebrewCategory(e); err != nil {
+		return homebrewChange{}, err
+	}
+	delete(item, "source")
+	index, err := validateHomebrewEntry(e, item)
+	if err != nil {
+		return homebrewChange{}, err
+	}
+	key := homebrewKey{e, index}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	before, exists := s.entries[e][index]
+	action := "update"
+	if !replace {
+		if exists {
+			return homebrewChange{}, fmt.Errorf("homebrew %s %q already exists; update it instead", e, index)
+		}
+		action = "create"
+	} else if !exists {
+		return homebrewChange{}, fmt.Errorf("%w: no homebrew %s with index %q", errNotFound, e, index)
+	}
+	file, ok := s.origin[key]
+	if !ok {
+		file = path.Join(string(e), index+".json")
+		if _, taken := s.files[file]; taken {
+			return homebrewChange{}, fmt.Errorf("homebrew file %s already exists", file)
+		}
+	}
+	keys := s.files[file]
+	if !exists {
+		keys = append(append([]homebrewKey(nil), keys...), key)
+	}
+	if err := s.writeEntries(file, keys, key, item); err != nil {
+		return homebrewChange{}, err
+	}
+	s.set(key, file, item)
+	s.gen.Add(1)
+	change := homebrewChange{Action: action, Category: e, Index: index, File: file, After: storedHomebrewEntry(key, item)}
+	if exists {
+		change.Before = storedHomebrewEntry(key, before)
+	}
+	return s.record(ctx, change), nil
+}
+
+// remove deletes the homebrew entry e/index, removing its file if no other entries are left in it.
+func (s *homebrewDataSource) remove(ctx context.Context, e endpoint, index string) (homebrewChange, error) {
+	if s.dir == "" {
+		return homebrewChange{}, errHomebrewReadOnly
+	}
+	if err := checkHomebrewCategory(e); err != nil {
+		return homebrewChange{}, err
+	}
+	key := homebrewKey{e, index}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	before, exists := s.entries[e][index]
+	if !exists {
+		return homebrewChange{}, fmt.Errorf("%w: no homebrew %s with index %q", errNotFound, e, index)
+	}
+	file := s.origin[key]
+	var keys []homebrewKey
+	for _, k := range s.files[file] {
+		if k != key {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		if err := os.Remove(filepath.Join(s.dir, filepath.FromSlash(file))); err != nil {
+			return homebrewChange{}, err
+		}
+		delete(s.files, file)
+	} else {
+		if err := s.writeEntries(file, keys, homebrewKey{}, nil); err != nil {
+			return homebrewChange{}, err
+		}
+		s.files[file] = keys
+	}
+	delete(s.entries[e], index)
+	delete(s.origin, key)
+	s.gen.Add(1)
+	return s.record(ctx, homebrewChange{Action: "delete", Category: e, Index: index, File: file, Before: storedHomebrewEntry(key, before)}), nil
+}
+
+// writeEntries atomically rewrites file with the entries keys, in the file's format. The entry changed is
+// written as item rather than its stored value. A file that held a single entry keeps holding an object.
+// The caller must hold s.mu.
+func (s *homebrewDataSource) writeEntries(file string, keys []homebrewKey, changed homebrewKey, item map[string]any) error {
+	docs := make([]map[string]any, len(keys))
+	for i, k := range keys {
+		v := s.entries[k.e][k.index]
+		if k == changed {
+			v = item
+		}
+		docs[i] = storedHomebrewEntry(k, v)
+	}
+	var doc any = docs
+	if len(docs) == 1 {
+		doc = docs[0]
+	}
+	var data []byte
+	var err error
+	if ext := strings.ToLower(path.Ext(file)); ext == ".yaml" || ext == ".yml" {
+		data, err = yaml.Marshal(doc)
+	} else {
+		data, err = json.MarshalIndent(doc, "", "  ")
+		data = append(data, '\n')
+	}
+	if err != nil {
+		return err
+	}
+	p := filepath.Join(s.dir, filepath.FromSlash(file))
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	return writeFileAtomic(p, data)
+}
+
+// record stamps change, appends it to the change history and logs it to ctx. A failure to write the history is logged
+// rather than returned because the change itself has already been saved.
+func (s *homebrewDataSource) record(ctx context.Context, change homebrewChange) homebrewChange {
+	change.Time = time.Now().UTC()
+	log := logFrom(ctx).WithFields(logrus.Fields{"action": change.Action, "category": change.Category, "index": change.Index, "file": change.File})
+	data, err := json.Marshal(change)
+	if err == nil {
+		var f *os.File
+		f, err = os.OpenFile(filepath.Join(s.dir, homebrewHistoryFile), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+		if err == nil {
+			_, err = f.Write(append(data, '\n'))
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+		}
+	}
+	if err != nil {
+		log.WithError(err).Warn("Failed to record homebrew change history")
+	}
+	log.Info("Homebrew entry changed")
+	return change
+}
+
+// checkHomebrewCategory returns an error if e does not accept homebrew entries.
+func checkHomebrewCategory(e endpoint) error {
+	if _, ok := homebrewEndpoints[e]; !ok {
+		return fmt.Errorf("homebrew category must be one of %s, got %q", strings.Join(homebrewDirs(), ", "), e)
+	}
+	return nil
+}
+
+// storedHomebrewEntry returns item as it is written to disk, without the fields added when it was loaded.
+func storedHomebrewEntry(key homebrewKey, item map[string]any) map[string]any {
+	out := make(map[string]any, len(item))
+	for k, v := range item {
+		out[k] = v
+	}
+	delete(out, "source")
+	if out["url"] == homebrewURL(key) {
+		delete(out, "url")
+	}
+	return out
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	homebrewFireball = `{"index":"fireball","name":"Fireball","level":3,"desc":["A hotter fireball."],` +
+		`"school":{"index":"evocation","name":"Evocation"},"damage":{"damage_at_slot_level":{"3":"10d6"}}}`
+	homebrewSpellsYAML = `
+- index: frost-fingers
+  name: Frost Fingers
+  level: 1
+  desc: ["Freezing cold blasts from your fingertips."]
+  school: {index: evocation, name: Evocation}
+- index: mind-spike-lite
+  name: Mind Spike Lite
+  level: 2
+  desc: ["A weaker mind spike."]
+  school: {index: divination, name: Divination}
+`
+	homebrewMonsterYAML = `
+index: bog-troll
+name: Bog Troll
+size: Large
+type: giant
+armor_class: [{type: natural, value: 15}]
+hit_points: 84
+challenge_rating: 5
+`
+	homebrewItemJSON = `{"index":"lantern-of-embers","name":"Lantern of Embers","rarity":{"name":"Uncommon"},` +
+		`"equipment_category":{"index":"wondrous-items","name":"Wondrous Items"},"desc":["Wondrous item, uncommon (requires attunement)"]}`
+)
+
+// newHomebrewTestBase serves a small SRD for the homebrew store to merge into.
+func newHomebrewTestBase() *mockDataSource {
+	srdSpells := []map[string]any{
+		{"index": "fireball", "name": "Fireball", "level": 3, "url": "/api/2014/spells/fireball"},
+		{"index": "sleep", "name": "Sleep", "level": 1, "url": "/api/2014/spells/sleep"},
+	}
+	return &mockDataSource{
+		get: func(_ context.Context, e endpoint, index string, v any) error {
+			switch {
+			case e == spells && index == "fireball":
+				return setJSON(v, map[string]any{"index": "fireball", "name": "Fireball", "level": 3, "desc": []string{"SRD fireball."}})
+			case e == magicItems && index == "bag-of-holding":
+				return setJSON(v, map[string]any{"index": "bag-of-holding", "name": "Bag of Holding", "rarity": map[string]any{"name": "Uncommon"}, "desc": []string{"Wondrous item, uncommon"}})
+			case e == equipmentCategories && index == "wondrous-items":
+				return setJSON(v, map[string]any{"index": "wondrous-items", "name": "Wondrous Items", "equipment": []map[string]any{
+					{"index": "bag-of-holding", "name": "Bag of Holding", "url": "/api/2014/magic-items/bag-of-holding"},
+				}})
+			}
+			return errNotFound
+		},
+		list: func(_ context.Context, e endpoint, filter string, v any) error {
+			if e != spells {
+				return errNotFound
+			}
+			var out []map[string]any
+			for _, s := range srdSpells {
+				if filter == "" || filter == "level=1" && s["level"] == 1 {
+					out = append(out, s)
+				}
+			}
+			return setJSON(v, out)
+		},
+		search: func(_ context.Context, e endpoint, query string, v any) error {
+			return setJSON(v, srdSpells[:1])
+		},
+	}
+}
+
+func newHomebrewTestSource(t *testing.T) *homebrewDataSource {
+	t.Helper()
+	fsys := fstest.MapFS{
+		"spells/fireball.json":               {Data: []byte(homebrewFireball)},
+		"spells/cold.yaml":                   {Data: []byte(homebrewSpellsYAML)},
+		"monsters/bog-troll.yml":             {Data: []byte(homebrewMonsterYAML)},
+		"magic-items/lantern-of-embers.json": {Data: []byte(homebrewItemJSON)},
+		"README.md":                          {Data: []byte("notes")},
+	}
+	hb, err := loadHomebrewFS(fsys, newHomebrewTestBase())
+	if err != nil {
+		t.Fatalf("loadHomebrewFS: %v", err)
+	}
+	return hb
+}
+
+func TestLoadHomebrew(t *testing.T) {
+	hb := newHomebrewTestSource(t)
+	if len(hb.entries[spells]) != 3 || len(hb.entries[monsters]) != 1 || len(hb.entries[magicItems]) != 1 {
+		t.Fatalf("unexpected entries: %v", hb.entries)
+	}
+	var troll monsterDetail
+	if err := hb.Get(context.Background(), monsters, "bog-troll", &troll); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if troll.Source != homebrewSource || troll.HitPoints != 84 || troll.URL != "/api/monsters/bog-troll" {
+		t.Errorf("unexpected homebrew monster: %+v", troll)
+	}
+}
+
+func TestLoadHomebrewValidation(t *testing.T) {
+	fsys := fstest.MapFS{
+		"spells/bad.json":     {Data: []byte(`[{"index":"Bad Index","name":"Bad"},{"index":"no-name"},{"index":"too-high","name":"Too High","level":12,"desc":["x"],"school":{"index":"evocation"}}]`)},
+		"spells/dupe.json":    {Data: []byte(homebrewFireball)},
+		"spells/dupe2.json":   {Data: []byte(homebrewFireball)},
+		"spells/broken.yaml":  {Data: []byte("index: [unclosed")},
+		"monsters/weak.yaml":  {Data: []byte("index: weakling\nname: Weakling\nsize: Tiny\ntype: beast\narmor_class: [{value: 10}]\nhit_points: seven\n")},
+		"vehicles/cart.json":  {Data: []byte(`{"index":"cart","name":"Cart"}`)},
+		"top-level-file.json": {Data: []byte(homebrewFireball)},
+	}
+	_, err := loadHomebrewFS(fsys, newHomebrewTestBase())
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+	for _, want := range []string{
+		`spells/bad.json[0]: index "Bad Index" must be lowercase`,
+		"spells/bad.json[1]: no-name: name is required",
+		"spells/bad.json[2]: too-high: level must be between 0 and 9",
+		`spells/dupe2.json: duplicate spells index "fireball", already defined in spells/dupe.json`,
+		"spells/broken.yaml:",
+		"monsters/weak.yaml: weakling: json: cannot unmarshal",
+		"vehicles/cart.json: homebrew files must be in one of the directories magic-items, monsters, spells",
+		"top-level-file.json: homebrew files must be in one of the directories",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to contain %q, got:\n%v", want, err)
+		}
+	}
+}
+
+func TestHomebrewDataSource(t *testing.T) {
+	hb := newHomebrewTestSource(t)
+	ctx := context.Background()
+
+	t.Run("homebrew shadows SRD entry", func(t *testing.T) {
+		var spell spellAPIResponse
+		if err := hb.Get(ctx, spells, "fireball", &spell); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if spell.Source != homebrewSource || spell.Desc[0] != "A hotter fireball." {
+			t.Errorf("expected homebrew fireball, got %+v", spell)
+		}
+	})
+
+	t.Run("list merges and filters", func(t *testing.T) {
+		var all []spellListAPIResponse
+		if err := hb.List(ctx, spells, "", &all); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(all) != 4 || all[0].Index != "sleep" || all[1].Index != "fireball" || all[1].Source != homebrewSource {
+			t.Errorf("unexpected merged list: %+v", all)
+		}
+		var level1 []spellListAPIResponse
+		if err := hb.List(ctx, spells, "level=1", &level1); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(level1) != 2 || level1[0].Index != "sleep" || level1[1].Index != "frost-fingers" {
+			t.Errorf("unexpected filtered list: %+v", level1)
+		}
+	})
+
+	t.Run("list of endpoint missing upstream", func(t *testing.T) {
+		var results []monsterListAPIResponse
+		if err := hb.List(ctx, monsters, "challenge_rating=5", &results); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(results) != 1 || results[0].Index != "bog-troll" || results[0].Source != homebrewSource {
+			t.Errorf("unexpected monsters: %+v", results)
+		}
+	})
+
+	t.Run("search", func(t *testing.T) {
+		var results []apiReference
+		if err := hb.Search(ctx, spells, "fi", &results); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(results) != 2 || results[0].Index != "fireball" || results[0].Source != homebrewSource || results[1].Index != "frost-fingers" {
+			t.Errorf("unexpected search results: %+v", results)
+		}
+	})
+
+	t.Run("equipment category lists homebrew items", func(t *testing.T) {
+		var cat equipmentCategoryDetail
+		if err := hb.Get(ctx, equipmentCategories, "wondrous-items", &cat); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(cat.Equipment) != 2 || cat.Equipment[1].Index != "lantern-of-embers" || cat.Equipment[1].URL != "/api/magic-items/lantern-of-embers" {
+			t.Errorf("unexpected category equipment: %+v", cat.Equipment)
+		}
+	})
+
+	t.Run("other endpoints pass through", func(t *testing.T) {
+		var cond conditionDetail
+		if err := hb.Get(ctx, conditions, "poisoned", &cond); err != errNotFound {
+			t.Errorf("expected base errNotFound, got %v", err)
+		}
+	})
+}
+
+func TestRunMagicItemToolHomebrew(t *testing.T) {
+	hb := newHomebrewTestSource(t)
+	attuned := true
+	res, err := runMagicItemTool(context.Background(), hb, magicItemToolInput{Category: "wondrous-items", Rarity: "Uncommon", RequiresAttunement: &attuned})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	txt, _ := mcp.AsTextContent(res.Content[0])
+	var out magicItemToolOutput
+	if err := json.Unmarshal([]byte(txt.Text), &out); err != nil {
+		t.Fatalf("unmarshal output: %v", err)
+	}
+	if out.Count != 1 || out.Items[0].Index != "lantern-of-embers" || out.Items[0].Source != homebrewSource || !out.Items[0].RequiresAttunement {
+		t.Errorf("unexpected magic items: %+v", out)
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Names under which the homebrew write tools are registered.
+const (
+	homebrewCreateToolName = "homebrew_create"
+	homebrewUpdateToolName = "homebrew_update"
+	homebrewDeleteToolName = "homebrew_delete"
+)
+
+// homebrewEntryToolInput defines the input structure for the homebrew create and update tools.
+type homebrewEntryToolInput struct {
+	Category string         `json:"category" mcp:"description=The category of the entry: 'spells', 'monsters' or 'magic-items'."`
+	Entry    map[string]any `json:"entry" mcp:"description=The complete entry, in the same shape as the API's detail response for the category (e.g., a spell needs index, name, level, school and desc). The index must be lowercase and hyphenated."`
+}
+
+// homebrewDeleteToolInput defines the input structure for the homebrew delete tool.
+type homebrewDeleteToolInput struct {
+	Category string `json:"category" mcp:"description=The category of the entry: 'spells', 'monsters' or 'magic-items'."`
+	Index    string `json:"index" mcp:"description=The index of the homebrew entry to delete (e.g., 'bog-troll')."`
+}
+
+// homebrewToolOutput defines the output structure for the homebrew write tools.
+type homebrewToolOutput struct {
+	Action   string         `json:"action"`
+	Category endpoint       `json:"category"`
+	Index    string         `json:"index"`
+	File     string         `json:"file"`
+	Entry    map[string]any `json:"entry,omitempty"`
+}
+
+// homebrewTools returns the tools that create, update and delete entries of the homebrew store hb.
+func homebrewTools(hb *homebrewDataSource) []server.ServerTool {
+	return []server.ServerTool{
+		newWriteTool(
+			homebrewCreateToolName,
+			"Creates a homebrew spell, monster or magic item. It is validated like homebrew files, saved to the homebrew directory and returned by the other tools alongside the SRD content.",
+			homebrewEntryToolInput{},
+			handleHomebrewCreateTool(hb),
+			false, false,
+		),
+		newWriteTool(
+			homebrewUpdateToolName,
+			"Replaces an existing homebrew spell, monster or magic item with the given entry, matched by its index.",
+			homebrewEntryToolInput{},
+			handleHomebrewUpdateTool(hb),
+			true, true,
+		),
+		newWriteTool(
+			homebrewDeleteToolName,
+			"Deletes a homebrew spell, monster or magic item. SRD entries cannot be deleted.",
+			homebrewDeleteToolInput{},
+			handleHomebrewDeleteTool(hb),
+			true, true,
+		),
+	}
+}
+
+// runHomebrewPutTool executes the core logic for the homebrew create and update tools.
+func runHomebrewPutTool(ctx context.Context, hb *homebrewDataSource, input homebrewEntryToolInput, replace bool) (*mcp.CallToolResult, error) {
+	if len(input.Entry) == 0 {
+		err := fmt.Errorf("entry must not be empty")
+		return mcp.NewToolResultErrorFromErr("invalid homebrew entry", err), err
+	}
+	change, err := hb.put(ctx, endpoint(strings.TrimSpace(input.Category)), input.Entry, replace)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to save homebrew entry", err), err
+	}
+	return homebrewToolResult(homebrewToolOutput{
+		Action:   change.Action,
+		Category: change.Category,
+		Index:    change.Index,
+		File:     change.File,
+		Entry:    change.After,
+	})
+}
+
+// runHomebrewDeleteTool executes the core logic for the homebrew delete tool.
+func runHomebrewDeleteTool(ctx context.Context, hb *homebrewDataSource, input homebrewDeleteToolInput) (*mcp.CallToolResult, error) {
+	change, err := hb.remove(ctx, endpoint(strings.TrimSpace(input.Category)), strings.TrimSpace(input.Index))
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to delete homebrew entry", err), err
+	}
+	return homebrewToolResult(homebrewToolOutput{
+		Action:   change.Action,
+		Category: change.Category,
+		Index:    change.Index,
+		File:     change.File,
+	})
+}
+
+// homebrewToolResult marshals the output of a homebrew write tool.
+func homebrewToolResult(output homebrewToolOutput) (*mcp.CallToolResult, error) {
+	jsonData, err := json.Marshal(output)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal homebrew output", err), err
+	}
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+// handleHomebrewCreateTool returns the MCP handler for the homebrew create tool.
+func handleHomebrewCreateTool(hb *homebrewDataSource) mcp.TypedToolHandlerFunc[homebrewEntryToolInput] {
+	return func(ctx context.Context, req mcp.CallToolRequest, input homebrewEntryToolInput) (*mcp.CallToolResult, error) {
+		return runHomebrewPutTool(ctx, hb, input, false)
+	}
+}
+
+// handleHomebrewUpdateTool returns the MCP handler for the homebrew update tool.
+func handleHomebrewUpdateTool(hb *homebrewDataSource) mcp.TypedToolHandlerFunc[homebrewEntryToolInput] {
+	return func(ctx context.Context, req mcp.CallToolRequest, input homebrewEntryToolInput) (*mcp.CallToolResult, error) {
+		return runHomebrewPutTool(ctx, hb, input, true)
+	}
+}
+
+// handleHomebrewDeleteTool returns the MCP handler for the homebrew delete tool.
+func handleHomebrewDeleteTool(hb *homebrewDataSource) mcp.TypedToolHandlerFunc[homebrewDeleteToolInput] {
+	return func(ctx context.Context, req mcp.CallToolRequest, input homebrewDeleteToolInput) (*mcp.CallToolResult, error) {
+		return runHomebrewDeleteTool(ctx, hb, input)
+	}
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"gopkg.in/yaml.v3"
+)
+
+// newHomebrewTestDir writes the test homebrew files to a temporary directory and loads it.
+func newHomebrewTestDir(t *testing.T) (*homebrewDataSource, string) {
+	t.Helper()
+	dir := t.TempDir()
+	for p, data := range map[string]string{
+		"spells/cold.yaml":       homebrewSpellsYAML,
+		"monsters/bog-troll.yml": homebrewMonsterYAML,
+	} {
+		full := filepath.Join(dir, filepath.FromSlash(p))
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	hb, err := loadHomebrew(dir, newHomebrewTestBase())
+	if err != nil {
+		t.Fatalf("loadHomebrew: %v", err)
+	}
+	return hb, dir
+}
+
+func homebrewTestSpell(index, name string, level int) map[string]any {
+	return map[string]any{
+		"index":  index,
+		"name":   name,
+		"level":  float64(level),
+		"desc":   []any{"A homebrew spell."},
+		"school": map[string]any{"index": "evocation", "name": "Evocation"},
+	}
+}
+
+func decodeHomebrewToolOutput(t *testing.T, res *mcp.CallToolResult) homebrewToolOutput {
+	t.Helper()
+	txt, _ := mcp.AsTextContent(res.Content[0])
+	var out homebrewToolOutput
+	if err := json.Unmarshal([]byte(txt.Text), &out); err != nil {
+		t.Fatalf("unmarshal output: %v", err)
+	}
+	return out
+}
+
+func readHomebrewHistory(t *testing.T, dir string) []homebrewChange {
+	t.Helper()
+	f, err := os.Open(filepath.Join(dir, homebrewHistoryFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var changes []homebrewChange
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var c homebrewChange
+		if err := json.Unmarshal(sc.Bytes(), &c); err != nil {
+			t.Fatalf("unmarshal history line %q: %v", sc.Text(), err)
+		}
+		changes = append(changes, c)
+	}
+	return changes
+}
+
+func TestHomebrewCreateTool(t *testing.T) {
+	hb, dir := newHomebrewTestDir(t)
+	ctx := context.Background()
+	gen := hb.generation()
+
+	res, err := runHomebrewPutTool(ctx, hb, homebrewEntryToolInput{Category: "spells", Entry: homebrewTestSpell("ember-lash", "Ember Lash", 2)}, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := decodeHomebrewToolOutput(t, res)
+	if out.Action != "create" || out.Index != "ember-lash" || out.File != "spells/ember-lash.json" || out.Entry["source"] != nil {
+		t.Errorf("unexpected output: %+v", out)
+	}
+	if hb.generation() == gen {
+		t.Error("expected the generation to change")
+	}
+
+	var spell spellAPIResponse
+	if err := hb.Get(ctx, spells, "ember-lash", &spell); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if spell.Source != homebrewSource || spell.Level != 2 || spell.URL != "/api/spells/ember-lash" {
+		t.Errorf("unexpected stored spell: %+v", spell)
+	}
+
+	reloaded, err := loadHomebrew(dir, newHomebrewTestBase())
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if _, ok := reloaded.entries[spells]["ember-lash"]; !ok {
+		t.Error("expected the new spell to be saved to disk")
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "spells", "ember-lash.json"))
+	if strings.Contains(string(data), "url") || strings.Contains(string(data), "source") {
+		t.Errorf("expected no derived fields in the saved file, got:\n%s", data)
+	}
+
+	history := readHomebrewHistory(t, dir)
+	if len(history) != 1 || history[0].Action != "create" || history[0].Before != nil || history[0].After["name"] != "Ember Lash" {
+		t.Errorf("unexpected history: %+v", history)
+	}
+
+	t.Run("existing entry", func(t *testing.T) {
+		res, err := runHomebrewPutTool(ctx, hb, homebrewEntryToolInput{Category: "spells", Entry: homebrewTestSpell("frost-fingers", "Frost Fingers", 1)}, false)
+		if err == nil || !res.IsError || !strings.Contains(err.Error(), "already exists") {
+			t.Errorf("expected an already exists error, got %v", err)
+		}
+	})
+
+	t.Run("invalid entry is not saved", func(t *testing.T) {
+		bad := homebrewTestSpell("overload", "Overload", 12)
+		res, err := runHomebrewPutTool(ctx, hb, homebrewEntryToolInput{Category: "spells", Entry: bad}, false)
+		if err == nil || !res.IsError || !strings.Contains(err.Error(), "level must be between 0 and 9") {
+			t.Errorf("expected a validation error, got %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "spells", "overload.json")); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("expected no file for an invalid entry, got %v", err)
+		}
+		if len(readHomebrewHistory(t, dir)) != 1 {
+			t.Error("expected no history for an invalid entry")
+		}
+	})
+
+	t.Run("unknown category", func(t *testing.T) {
+		_, err := runHomebrewPutTool(ctx, hb, homebrewEntryToolInput{Category: "feats", Entry: map[string]any{"index": "x", "name": "X"}}, false)
+		if err == nil || !strings.Contains(err.Error(), "homebrew category must be one of magic-items, monsters, spells") {
+			t.Errorf("expected a category error, got %v", err)
+		}
+	})
+}
+
+func TestHomebrewUpdateTool(t *testing.T) {
+	hb, dir := newHomebrewTestDir(t)
+	ctx := context.Background()
+
+	updated := homebrewTestSpell("mind-spike-lite", "Mind Spike Lite", 3)
+	res, err := runHomebrewPutTool(ctx, hb, homebrewEntryToolInput{Category: "spells", Entry: updated}, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out := decodeHomebrewToolOutput(t, res); out.Action != "update" || out.File != "spells/cold.yaml" {
+		t.Errorf("unexpected output: %+v", out)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "spells", "cold.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var docs []map[string]any
+	if err := yaml.Unmarshal(data, &docs); err != nil {
+		t.Fatalf("expected the file to stay YAML: %v\n%s", err, data)
+	}
+	if len(docs) != 2 || docs[0]["index"] != "frost-fingers" || docs[1]["level"] != 3 {
+		t.Errorf("unexpected rewritten file: %v", docs)
+	}
+
+	history := readHomebrewHistory(t, dir)
+	if len(history) != 1 || history[0].Before["level"] != float64(2) || history[0].After["level"] != float64(3) {
+		t.Errorf("unexpected history: %+v", history)
+	}
+
+	t.Run("missing entry", func(t *testing.T) {
+		res, err := runHomebrewPutTool(ctx, hb, homebrewEntryToolInput{Category: "spells", Entry: homebrewTestSpell("ember-lash", "Ember Lash", 2)}, true)
+		if !errors.Is(err, errNotFound) || !res.IsError {
+			t.Errorf("expected errNotFound, got %v", err)
+		}
+	})
+}
+
+func TestHomebrewDeleteTool(t *testing.T) {
+	hb, dir := newHomebrewTestDir(t)
+	ctx := context.Background()
+
+	if _, err := runHomebrewDeleteTool(ctx, hb, homebrewDeleteToolInput{Category: "spells", Index: "frost-fingers"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "spells", "cold.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc map[string]any
+	if err := yaml.Unmarshal(data, &doc); err != nil || doc["index"] != "mind-spike-lite" {
+		t.Errorf("expected the remaining entry as a single document, got %v:\n%s", err, data)
+	}
+
+	res, err := runHomebrewDeleteTool(ctx, hb, homebrewDeleteToolInput{Category: "monsters", Index: "bog-troll"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out := decodeHomebrewToolOutput(t, res); out.Action != "delete" || out.File != "monsters/bog-troll.yml" {
+		t.Errorf("unexpected output: %+v", out)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "monsters", "bog-troll.yml")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected the emptied file to be removed, got %v", err)
+	}
+	var troll monsterDetail
+	if err := hb.Get(ctx, monsters, "bog-troll", &troll); err != errNotFound {
+		t.Errorf("expected the deleted monster to be gone, got %v", err)
+	}
+
+	history := readHomebrewHistory(t, dir)
+	if len(history) != 2 || history[1].Action != "delete" || history[1].Before["name"] != "Bog Troll" || history[1].After != nil {
+		t.Errorf("unexpected history: %+v", history)
+	}
+
+	t.Run("SRD entries cannot be deleted", func(t *testing.T) {
+		_, err := runHomebrewDeleteTool(ctx, hb, homebrewDeleteToolInput{Category: "spells", Index: "sleep"})
+		if !errors.Is(err, errNotFound) {
+			t.Errorf("expected errNotFound, got %v", err)
+		}
+	})
+}
+
+func TestHomebrewReadOnly(t *testing.T) {
+	hb, err := loadHomebrewFS(fstest.MapFS{}, newHomebrewTestBase())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := hb.put(context.Background(), spells, homebrewTestSpell("ember-lash", "Ember Lash", 2), false); err != errHomebrewReadOnly {
+		t.Errorf("expected errHomebrewReadOnly, got %v", err)
+	}
+}
+
+func TestHomebrewSearchSeesChanges(t *testing.T) {
+	hb, _ := newHomebrewTestDir(t)
+	ctx := context.Background()
+	idx := newSearchIndex(hb)
+	search := func() searchToolOutput {
+		res, err := runSearchTool(ctx, idx, searchToolInput{Query: "ember lash", Categories: []string{"spells"}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		txt, _ := mcp.AsTextContent(res.Content[0])
+		var out searchToolOutput
+		if err := json.Unmarshal([]byte(txt.Text), &out); err != nil {
+			t.Fatalf("unmarshal output: %v", err)
+		}
+		return out
+	}
+	if out := search(); out.Count != 0 {
+		t.Fatalf("expected no hits before the spell exists, got %+v", out)
+	}
+	if _, err := hb.put(context.Background(), spells, homebrewTestSpell("ember-lash", "Ember Lash", 2), false); err != nil {
+		t.Fatal(err)
+	}
+	if out := search(); out.Count != 1 || out.Results[0].Index != "ember-lash" {
+		t.Errorf("expected the new spell to be found, got %+v", out)
+	}
+}
+
+func TestHomebrewToolAnnotations(t *testing.T) {
+	hb, _ := newHomebrewTestDir(t)
+	want := map[string][2]bool{
+		homebrewCreateToolName: {false, false},
+		homebrewUpdateToolName: {true, true},
+		homebrewDeleteToolName: {true, true},
+	}
+	for _, tool := range homebrewTools(hb) {
+		a := tool.Tool.Annotations
+		w, ok := want[tool.Tool.Name]
+		if !ok {
+			t.Errorf("unexpected tool %q", tool.Tool.Name)
+			continue
+		}
+		if *a.ReadOnlyHint || *a.OpenWorldHint || *a.DestructiveHint != w[0] || *a.IdempotentHint != w[1] {
+			t.Errorf("%s: unexpected annotations %+v", tool.Tool.Name, a)
+		}
+		if _, ok := tool.Tool.InputSchema.Properties[rulesetParam]; ok {
+			t.Errorf("%s: write tools should not take a ruleset", tool.Tool.Name)
+		}
+	}
+}
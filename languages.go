@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// languageToolInput defines the input structure for the languages tool.
+type languageToolInput struct {
+	Name string `json:"name" mcp:"description=The index of the language to retrieve (e.g., 'elvish')."`
+	Type string `json:"type" mcp:"description=Only list languages of this type ('Standard' or 'Exotic')."`
+}
+
+// languageListAPIResponse defines the structure for a single language in the list response.
+type languageListAPIResponse struct {
+	Index string `json:"index"`
+	Name  string `json:"name"`
+	URL   string `json:"url"`
+}
+
+// languageDetail defines the structure for a detailed language response.
+type languageDetail struct {
+	Index           string   `json:"index"`
+	Name            string   `json:"name"`
+	Desc            string   `json:"desc,omitempty"`
+	Type            string   `json:"type,omitempty"`
+	IsRare          *bool    `json:"is_rare,omitempty"`
+	Note            string   `json:"note,omitempty"`
+	TypicalSpeakers []string `json:"typical_speakers,omitempty"`
+	Script          string   `json:"script,omitempty"`
+	URL             string   `json:"url"`
+}
+
+// languageToolOutput defines the output structure for the languages tool.
+// Results holds plain list entries; Languages holds full details when a type filter was applied.
+type languageToolOutput struct {
+	Count     int                       `json:"count,omitempty"`
+	Results   []languageListAPIResponse `json:"results,omitempty"`
+	Languages []languageDetail          `json:"languages,omitempty"`
+	Language  *languageDetail           `json:"language,omitempty"`
+}
+
+// fetchLanguageByNameResult fetches a language by index and returns an MCP tool result.
+func fetchLanguageByNameResult(
+	ctx context.Context,
+	src dataSource,
+	input languageToolInput,
+) (*mcp.CallToolResult, error) {
+	language := &languageDetail{}
+	err := fetchByName(ctx, src, languages, input.Name, language)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to fetch language", err), err
+	}
+	output := languageToolOutput{Language: language}
+	jsonData, err := json.Marshal(output)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal language output", err), err
+	}
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+// fetchLanguageListResult fetches a list of languages, optionally of a single type, and returns an MCP tool result.
+func fetchLanguageListResult(
+	ctx context.Context,
+	src dataSource,
+	input languageToolInput,
+) (*mcp.CallToolResult, error) {
+	var results []languageListAPIResponse
+	err := fetchList(ctx, src, languages, &results, "")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to fetch language list", err), err
+	}
+	output := languageToolOutput{Count: len(results), Results: results}
+	if input.Type != "" {
+		indexes := make([]string, len(results))
+		for i, r := range results {
+			indexes[i] = r.Index
+		}
+		matched, err := fetchMatchingDetails(ctx, src, languages, indexes,
+			func(l *languageDetail) bool { return strings.EqualFold(l.Type, input.Type) })
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Failed to fetch language details", err), err
+		}
+		output = languageToolOutput{Count: len(matched), Languages: matched}
+	}
+	jsonData, err := json.Marshal(output)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal language list output", err), err
+	}
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+// runLanguageTool executes the core logic for the languages tool.
+func runLanguageTool(ctx context.Context, src dataSource, input languageToolInput) (*mcp.CallToolResult, error) {
+	if input.Name != "" {
+		return fetchLanguageByNameResult(ctx, src, input)
+	}
+	return fetchLanguageListResult(ctx, src, input)
+}
+
+// handleLanguageTool returns the MCP handler for the languages tool.
+func handleLanguageTool(src dataSource) mcp.TypedToolHandlerFunc[languageToolInput] {
+	return func(ctx context.Context, req mcp.CallToolRequest, input languageToolInput) (*mcp.CallToolResult, error) {
+		return runLanguageTool(ctx, src, input)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestRunLanguageTool(t *testing.T) {
+	details := map[string]languageDetail{
+		"common":   {Index: "common", Name: "Common", Type: "Standard", TypicalSpeakers: []string{"Humans"}, Script: "Common"},
+		"elvish":   {Index: "elvish", Name: "Elvish", Type: "Standard", TypicalSpeakers: []string{"Elves"}, Script: "Elvish"},
+		"draconic": {Index: "draconic", Name: "Draconic", Type: "Exotic", TypicalSpeakers: []string{"Dragons", "Dragonborn"}, Script: "Draconic"},
+	}
+	list := []languageListAPIResponse{{Index: "common"}, {Index: "elvish"}, {Index: "draconic"}}
+	src := &mockDataSource{
+		get: func(_ context.Context, _ endpoint, index string, v any) error {
+			d, ok := details[index]
+			if !ok {
+				return errNotFound
+			}
+			*v.(*languageDetail) = d
+			return nil
+		},
+		list: func(_ context.Context, _ endpoint, _ string, v any) error {
+			*v.(*[]languageListAPIResponse) = list
+			return nil
+		},
+	}
+	decode := func(t *testing.T, res *mcp.CallToolResult) languageToolOutput {
+		t.Helper()
+		txt, _ := mcp.AsTextContent(res.Content[0])
+		var out languageToolOutput
+		if err := json.Unmarshal([]byte(txt.Text), &out); err != nil {
+			t.Fatalf("unmarshal output: %v", err)
+		}
+		return out
+	}
+
+	t.Run("by name", func(t *testing.T) {
+		res, err := runLanguageTool(context.Background(), src, languageToolInput{Name: "Elvish"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if out := decode(t, res); out.Language == nil || out.Language.Script != "Elvish" {
+			t.Errorf("unexpected language: %+v", out.Language)
+		}
+	})
+
+	t.Run("by name error", func(t *testing.T) {
+		res, err := runLanguageTool(context.Background(), src, languageToolInput{Name: "thieves-cant"})
+		if !errors.Is(err, errNotFound) || res == nil || !res.IsError {
+			t.Errorf("expected not found error result, got %+v, %v", res, err)
+		}
+	})
+
+	t.Run("list", func(t *testing.T) {
+		res, err := runLanguageTool(context.Background(), src, languageToolInput{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if out := decode(t, res); out.Count != 3 || len(out.Results) != 3 {
+			t.Errorf("expected 3 languages, got %+v", out)
+		}
+	})
+
+	t.Run("list by type", func(t *testing.T) {
+		res, err := runLanguageTool(context.Background(), src, languageToolInput{Type: "exotic"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		out := decode(t, res)
+		if out.Count != 1 || len(out.Languages) != 1 || out.Languages[0].Index != "draconic" {
+			t.Errorf("expected only draconic, got %+v", out)
+		}
+	})
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// logOutputStderr and logOutputStdout name the standard streams as log outputs; any other output is a file.
+	logOutputStderr = "stderr"
+	logOutputStdout = "stdout"
+	// loggerName identifies the server in log notifications sent to MCP clients.
+	loggerName = "dnd5e-mcp"
+	// logNotificationMethod is the MCP notification that carries a log entry to the client.
+	logNotificationMethod = "notifications/message"
+	// clientLogLevel is the most detailed logrus level forwarded to MCP clients.
+	clientLogLevel = logrus.DebugLevel
+	// defaultClientLogLevel is the level of the log messages sent to a client until it sets one with logging/setLevel.
+	defaultClientLogLevel = mcp.LoggingLevelError
+)
+
+// setupLogging configures the standard logger from l. The log never goes to stdout unless asked to, since
+// stdout carries the protocol stream of the stdio transport. It returns a function that closes the log file.
+//
+// The logger itself records entries down to clientLogLevel even when l.Level is less detailed, so that the
+// client log hook sees every entry a client can ask for whatever the server's level; entries below l.Level
+// are dropped when written to the output.
+func setupLogging(l logSettings) (func() error, error) {
+	level, err := logrus.ParseLevel(l.Level)
+	if err != nil {
+		return nil, err
+	}
+	logger := logrus.StandardLogger()
+	closeOutput := func() error { return nil }
+	switch l.Output {
+	case "", logOutputStderr:
+		logger.SetOutput(os.Stderr)
+	case logOutputStdout:
+		logger.SetOutput(os.Stdout)
+	default:
+		f, err := os.OpenFile(l.Output, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+		if err != nil {
+			return nil, err
+		}
+		logger.SetOutput(f)
+		closeOutput = f.Close
+	}
+	var formatter logrus.Formatter = &logrus.TextFormatter{FullTimestamp: true}
+	if l.Format == "json" {
+		formatter = &logrus.JSONFormatter{}
+	}
+	logger.SetFormatter(levelFilterFormatter{next: formatter, level: level})
+	logger.SetLevel(max(level, clientLogLevel))
+	logger.ReplaceHooks(logrus.LevelHooks{})
+	logger.AddHook(clientLogHook{})
+
+	// Libraries that use the standard log package, mcp-go among them, log through logrus too.
+	log.SetFlags(0)
+	log.SetOutput(logger.WriterLevel(logrus.WarnLevel))
+	return closeOutput, nil
+}
+
+// levelFilterFormatter formats entries at or above level with next and drops the others.
+type levelFilterFormatter struct {
+	next  logrus.Formatter
+	level logrus.Level
+}
+
+// Format implements logrus.Formatter.
+func (f levelFilterFormatter) Format(e *logrus.Entry) ([]byte, error) {
+	if e.Level > f.level {
+		return nil, nil
+	}
+	return f.next.Format(e)
+}
+
+// requestLog identifies the tool call a log entry belongs to.
+type requestLog struct {
+	id   string
+	tool string
+}
+
+// requestLogKey is the context key under which the tool call of a request is stored.
+type requestLogKey struct{}
+
+// withRequestLog returns a copy of ctx whose log entries are tagged with r.
+func withRequestLog(ctx context.Context, r requestLog) context.Context {
+	return context.WithValue(ctx, requestLogKey{}, r)
+}
+
+// requestIDFrom returns the correlation ID of the tool call in ctx, or "" outside a tool call.
+func requestIDFrom(ctx context.Context) string {
+	r, _ := ctx.Value(requestLogKey{}).(requestLog)
+	return r.id
+}
+
+// logFrom returns a log entry for ctx. Within a tool call it carries the call's correlation ID and tool
+// name, and is forwarded to the calling client if the client asked for log messages at its level.
+func logFrom(ctx context.Context) *logrus.Entry {
+	entry := logrus.WithContext(ctx)
+	if r, ok := ctx.Value(requestLogKey{}).(requestLog); ok {
+		entry = entry.WithFields(logrus.Fields{"request_id": r.id, "tool": r.tool})
+	}
+	return entry
+}
+
+// newRequestID returns a random correlation ID.
+func newRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// requestLogMiddleware gives every tool call a correlation ID, logs its start and end, and returns the ID
+// in the result's metadata so that clients can quote it.
+func requestLogMiddleware() server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			id := newRequestID()
+			ctx = withRequestLog(ctx, requestLog{id: id, tool: req.Params.Name})
+			log := logFrom(ctx)
+			log.WithField("arguments", req.Params.Arguments).Debug("Tool call started")
+			start := time.Now()
+			result, err := next(ctx, req)
+			log = log.WithField("duration", time.Since(start))
+			if err != nil {
+				log.WithError(err).Warn("Tool call failed")
+			} else {
+				log.Info("Tool call finished")
+			}
+			if result != nil {
+				if result.Meta == nil {
+					result.Meta = map[string]any{}
+				}
+				result.Meta["request_id"] = id
+			}
+			return result, err
+		}
+	}
+}
+
+// mcpLogLevels orders the MCP log levels from least to most severe.
+var mcpLogLevels = []mcp.LoggingLevel{
+	mcp.LoggingLevelDebug,
+	mcp.LoggingLevelInfo,
+	mcp.LoggingLevelNotice,
+	mcp.LoggingLevelWarning,
+	mcp.LoggingLevelError,
+	mcp.LoggingLevelCritical,
+	mcp.LoggingLevelAlert,
+	mcp.LoggingLevelEmergency,
+}
+
+// mcpLogLevel returns the MCP log level of a logrus level.
+func mcpLogLevel(l logrus.Level) mcp.LoggingLevel {
+	switch l {
+	case logrus.PanicLevel:
+		return mcp.LoggingLevelEmergency
+	case logrus.FatalLevel:
+		return mcp.LoggingLevelCritical
+	case logrus.ErrorLevel:
+		return mcp.LoggingLevelError
+	case logrus.WarnLevel:
+		return mcp.LoggingLevelWarning
+	case logrus.InfoLevel:
+		return mcp.LoggingLevelInfo
+	default:
+		return mcp.LoggingLevelDebug
+	}
+}
+
+// mcpLogLevelEnabled reports whether an entry at level passes the minimum level min.
+func mcpLogLevelEnabled(min, level mcp.LoggingLevel) bool {
+	rank := func(l mcp.LoggingLevel) int {
+		for i, v := range mcpLogLevels {
+			if v == l {
+				return i
+			}
+		}
+		return len(mcpLogLevels)
+	}
+	return rank(level) >= rank(min)
+}
+
+// clientLogHook forwards log entries made within a tool call to the calling client as notifications/message,
+// if the client's session supports logging and the entry is at or above the level it set with logging/setLevel.
+// Sessions start at defaultClientLogLevel; see clientLogHooks.
+type clientLogHook struct{}
+
+// Levels implements logrus.Hook.
+func (clientLogHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire implements logrus.Hook. Failures to notify are ignored; logging them would recurse.
+func (clientLogHook) Fire(e *logrus.Entry) error {
+	if e.Context == nil {
+		return nil
+	}
+	srv := server.ServerFromContext(e.Context)
+	session, ok := server.ClientSessionFromContext(e.Context).(server.SessionWithLogging)
+	if srv == nil || !ok {
+		return nil
+	}
+	level := mcpLogLevel(e.Level)
+	if !mcpLogLevelEnabled(session.GetLogLevel(), level) {
+		return nil
+	}
+	data := map[string]any{"message": e.Message}
+	for k, v := range e.Data {
+		switch v := v.(type) {
+		case error:
+			data[k] = v.Error()
+		case fmt.Stringer:
+			data[k] = v.String()
+		default:
+			data[k] = v
+		}
+	}
+	_ = srv.SendNotificationToClient(e.Context, logNotificationMethod, map[string]any{
+		"level":  level,
+		"logger": loggerName,
+		"data":   data,
+	})
+	return nil
+}
+
+// clientLogHooks returns server hooks that start every session at defaultClientLogLevel once it is initialized.
+func clientLogHooks() *server.Hooks {
+	hooks := &server.Hooks{}
+	hooks.AddAfterInitialize(func(ctx context.Context, _ any, _ *mcp.InitializeRequest, _ *mcp.InitializeResult) {
+		if session, ok := server.ClientSessionFromContext(ctx).(server.SessionWithLogging); ok {
+			session.SetLogLevel(defaultClientLogLevel)
+		}
+	})
+	return hooks
+}
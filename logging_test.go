@@ -0,0 +1,238 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+)
+
+// testLoggingSession is a client session that supports logging/setLevel.
+type testLoggingSession struct {
+	notifications chan mcp.JSONRPCNotification
+	level         atomic.Value
+}
+
+func newTestLoggingSession(level mcp.LoggingLevel) *testLoggingSession {
+	s := &testLoggingSession{notifications: make(chan mcp.JSONRPCNotification, 10)}
+	s.level.Store(level)
+	return s
+}
+
+func (s *testLoggingSession) Initialize()       {}
+func (s *testLoggingSession) Initialized() bool { return true }
+func (s *testLoggingSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return s.notifications
+}
+func (s *testLoggingSession) SessionID() string                  { return "test-session" }
+func (s *testLoggingSession) SetLogLevel(level mcp.LoggingLevel) { s.level.Store(level) }
+func (s *testLoggingSession) GetLogLevel() mcp.LoggingLevel      { return s.level.Load().(mcp.LoggingLevel) }
+
+// saveStandardLogger restores the standard logger's configuration when the test ends.
+func saveStandardLogger(t *testing.T) {
+	t.Helper()
+	l := logrus.StandardLogger()
+	out, formatter, level, hooks := l.Out, l.Formatter, l.GetLevel(), l.Hooks
+	t.Cleanup(func() {
+		l.SetOutput(out)
+		l.SetFormatter(formatter)
+		l.SetLevel(level)
+		l.ReplaceHooks(hooks)
+	})
+}
+
+func TestSetupLogging(t *testing.T) {
+	saveStandardLogger(t)
+	p := filepath.Join(t.TempDir(), "server.log")
+	closeLog, err := setupLogging(logSettings{Level: "info", Format: "json", Output: p})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	logrus.Debug("hidden detail")
+	logrus.WithField("answer", 42).Info("visible entry")
+	if err := closeLog(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("expected only the info entry in the log, got:\n%s", data)
+	}
+	var entry map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatalf("expected a JSON entry: %v", err)
+	}
+	if entry["msg"] != "visible entry" || entry["answer"] != float64(42) {
+		t.Errorf("unexpected entry: %v", entry)
+	}
+	if !logrus.IsLevelEnabled(logrus.DebugLevel) {
+		t.Error("expected debug entries to be recorded for forwarding to clients")
+	}
+
+	if _, err := setupLogging(logSettings{Level: "loud"}); err == nil {
+		t.Error("expected an error for an invalid level")
+	}
+}
+
+func TestMCPLogLevel(t *testing.T) {
+	for l, want := range map[logrus.Level]mcp.LoggingLevel{
+		logrus.TraceLevel: mcp.LoggingLevelDebug,
+		logrus.DebugLevel: mcp.LoggingLevelDebug,
+		logrus.InfoLevel:  mcp.LoggingLevelInfo,
+		logrus.WarnLevel:  mcp.LoggingLevelWarning,
+		logrus.ErrorLevel: mcp.LoggingLevelError,
+		logrus.FatalLevel: mcp.LoggingLevelCritical,
+	} {
+		if got := mcpLogLevel(l); got != want {
+			t.Errorf("mcpLogLevel(%s) = %s, want %s", l, got, want)
+		}
+	}
+	if !mcpLogLevelEnabled(mcp.LoggingLevelWarning, mcp.LoggingLevelError) || mcpLogLevelEnabled(mcp.LoggingLevelWarning, mcp.LoggingLevelInfo) {
+		t.Error("unexpected level ordering")
+	}
+}
+
+func TestRequestLogMiddleware(t *testing.T) {
+	saveStandardLogger(t)
+	logrus.SetLevel(logrus.DebugLevel)
+	hook := test.NewGlobal()
+	handler := requestLogMiddleware()(func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		logFrom(ctx).Info("fetching")
+		return mcp.NewToolResultText("{}"), nil
+	})
+	req := mcp.CallToolRequest{}
+	req.Params.Name = "spells"
+	res, err := handler(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	id, _ := res.Meta["request_id"].(string)
+	if len(id) != 16 {
+		t.Fatalf("expected a request ID in the result metadata, got %v", res.Meta)
+	}
+	var tagged int
+	for _, e := range hook.AllEntries() {
+		if e.Data["request_id"] == id && e.Data["tool"] == "spells" {
+			tagged++
+		}
+	}
+	if tagged != 3 {
+		t.Errorf("expected the start, handler and finish entries to carry request ID %s, got %d of %d", id, tagged, len(hook.AllEntries()))
+	}
+
+	res2, _ := handler(context.Background(), req)
+	if res2.Meta["request_id"] == id {
+		t.Error("expected a new request ID for every call")
+	}
+}
+
+func TestClientLogForwarding(t *testing.T) {
+	saveStandardLogger(t)
+	var out bytes.Buffer
+	logrus.SetOutput(&out)
+	logrus.SetLevel(logrus.DebugLevel)
+	logrus.StandardLogger().ReplaceHooks(logrus.LevelHooks{})
+	logrus.AddHook(clientLogHook{})
+
+	s := server.NewMCPServer("test", "1.0.0", server.WithLogging(), server.WithToolHandlerMiddleware(requestLogMiddleware()))
+	s.AddTool(mcp.NewTool("noisy"), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		logFrom(ctx).Debug("debug detail")
+		logFrom(ctx).WithField("index", "fireball").Warn("something odd")
+		return mcp.NewToolResultText("{}"), nil
+	})
+	logrus.Warn("outside any tool call")
+
+	call := func(session *testLoggingSession) []map[string]any {
+		ctx := s.WithContext(context.Background(), session)
+		s.HandleMessage(ctx, []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"noisy"}}`))
+		close(session.notifications)
+		var data []map[string]any
+		for n := range session.notifications {
+			if n.Method != logNotificationMethod {
+				t.Errorf("unexpected notification %s", n.Method)
+				continue
+			}
+			params := n.Params.AdditionalFields
+			if params["logger"] != loggerName {
+				t.Errorf("unexpected logger in %v", params)
+			}
+			d := params["data"].(map[string]any)
+			d["level"] = params["level"]
+			data = append(data, d)
+		}
+		return data
+	}
+
+	warnings := call(newTestLoggingSession(mcp.LoggingLevelWarning))
+	if len(warnings) != 1 || warnings[0]["message"] != "something odd" || warnings[0]["index"] != "fireball" ||
+		warnings[0]["level"] != mcp.LoggingLevelWarning || warnings[0]["request_id"] == nil {
+		t.Errorf("expected only the warning to be forwarded, got %v", warnings)
+	}
+	var messages []string
+	for _, d := range call(newTestLoggingSession(mcp.LoggingLevelDebug)) {
+		messages = append(messages, d["message"].(string))
+	}
+	if got := strings.Join(messages, "|"); got != "Tool call started|debug detail|something odd|Tool call finished" {
+		t.Errorf("unexpected forwarded entries at debug level: %s", got)
+	}
+}
+
+func TestClientLogForwardingBelowServerLevel(t *testing.T) {
+	saveStandardLogger(t)
+	p := filepath.Join(t.TempDir(), "server.log")
+	closeLog, err := setupLogging(logSettings{Level: "info", Output: p})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer closeLog()
+
+	s := server.NewMCPServer("test", "1.0.0", server.WithLogging())
+	s.AddTool(mcp.NewTool("noisy"), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		logFrom(ctx).Debug("debug detail")
+		return mcp.NewToolResultText("{}"), nil
+	})
+	session := newTestLoggingSession(mcp.LoggingLevelDebug)
+	s.HandleMessage(s.WithContext(context.Background(), session),
+		[]byte(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"noisy"}}`))
+	close(session.notifications)
+
+	var forwarded bool
+	for n := range session.notifications {
+		if d, ok := n.Params.AdditionalFields["data"].(map[string]any); ok && d["message"] == "debug detail" {
+			forwarded = true
+		}
+	}
+	if !forwarded {
+		t.Error("expected the debug entry to be forwarded to a client at the debug level")
+	}
+	data, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "debug detail") {
+		t.Errorf("expected the debug entry to stay out of the info log, got:\n%s", data)
+	}
+}
+
+func TestClientLogHooks(t *testing.T) {
+	s := server.NewMCPServer("test", "1.0.0", server.WithLogging(), server.WithHooks(clientLogHooks()))
+	session := newTestLoggingSession(mcp.LoggingLevelDebug)
+	s.HandleMessage(s.WithContext(context.Background(), session), []byte(
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","clientInfo":{"name":"test","version":"1.0.0"},"capabilities":{}}}`))
+	if got := session.GetLogLevel(); got != defaultClientLogLevel {
+		t.Errorf("expected an initialized session to start at %s, got %s", defaultClientLogLevel, got)
+	}
+}
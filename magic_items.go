@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// attunementPattern matches the attunement clause in the header line of a magic item description,
+// e.g. "Wondrous item, rare (requires attunement by a cleric or paladin)" in the 2014 ruleset or
+// "Staff, Very Rare (Requires Attunement by a Sorcerer, Warlock, or Wizard)" in the 2024 ruleset.
+var attunementPattern = regexp.MustCompile(`(?i)\(requires attunement(?: by ([^)]*))?\)`)
+
+// magicItemToolInput defines the input structure for the magic items tool.
+type magicItemToolInput struct {
+	Name               string `json:"name" mcp:"description=The name of the magic item to retrieve (e.g., 'Bag of Holding')."`
+	Rarity             string `json:"rarity" mcp:"description=Only include items of this rarity (e.g., 'Common', 'Uncommon', 'Rare', 'Very Rare', 'Legendary', 'Artifact')."`
+	Category           string `json:"category" mcp:"description=Only include items in this equipment category (e.g., 'wondrous-items', 'ring', 'weapon')."`
+	RequiresAttunement *bool  `json:"requires_attunement" mcp:"description=If set, only include items that do (true) or do not (false) require attunement."`
+}
+
+// hasFilters reports whether any filter that needs item details is set.
+func (f *magicItemToolInput) hasFilters() bool {
+	return f.Rarity != "" || f.RequiresAttunement != nil
+}
+
+// matches reports whether a magic item satisfies every filter that is set.
+func (f *magicItemToolInput) matches(item magicItemDetail) bool {
+	if f.Rarity != "" && !strings.EqualFold(item.Rarity.Name, f.Rarity) {
+		return false
+	}
+	if f.RequiresAttunement != nil && item.RequiresAttunement != *f.RequiresAttunement {
+		return false
+	}
+	return true
+}
+
+// magicItemListAPIResponse defines the structure for a single magic item in the list response.
+type magicItemListAPIResponse struct {
+	Index  string `json:"index"`
+	Name   string `json:"name"`
+	URL    string `json:"url"`
+	Source string `json:"source,omitempty"`
+}
+
+// magicItemRarity is the rarity of a magic item.
+type magicItemRarity struct {
+	Name string `json:"name"`
+}
+
+// magicItemDetail defines the structure for a detailed magic item response.
+// RequiresAttunement and AttunementBy are parsed from the description rather than returned by the API.
+type magicItemDetail struct {
+	Index              string          `json:"index"`
+	Name               string          `json:"name"`
+	EquipmentCategory  apiReference    `json:"equipment_category"`
+	Rarity             magicItemRarity `json:"rarity"`
+	RequiresAttunement bool            `json:"requires_attunement"`
+	AttunementBy       string          `json:"attunement_by,omitempty"`
+	Desc               paragraphs      `json:"desc"`
+	Variant            bool            `json:"variant"`
+	Variants           []apiReference  `json:"variants,omitempty"`
+	Image              string          `json:"image,omitempty"`
+	URL                string          `json:"url"`
+	Source             string          `json:"source,omitempty"`
+}
+
+// validate checks the fields the magic items tool relies on, for items defined as homebrew.
+func (d *magicItemDetail) validate() error {
+	switch {
+	case d.EquipmentCategory.Index == "":
+		return fmt.Errorf("equipment_category.index is required")
+	case d.Rarity.Name == "":
+		return fmt.Errorf("rarity.name is required")
+	case len(d.Desc) == 0:
+		return fmt.Errorf("desc is required")
+	}
+	return nil
+}
+
+// descHeader returns the header line of the item's description, which gives its type, rarity and attunement.
+// The 2014 ruleset puts it in the first paragraph; the 2024 ruleset returns the whole description as one
+// string that starts with it, sometimes in italics.
+func (d *magicItemDetail) descHeader() string {
+	if len(d.Desc) == 0 {
+		return ""
+	}
+	header, _, _ := strings.Cut(strings.TrimSpace(d.Desc[0]), "\n")
+	return strings.Trim(header, "*_ \t\r")
+}
+
+// parseAttunement sets RequiresAttunement and AttunementBy from the item's description header.
+// Mentions of attunement in the rest of the description are ignored.
+func (d *magicItemDetail) parseAttunement() {
+	d.RequiresAttunement, d.AttunementBy = false, ""
+	m := attunementPattern.FindStringSubmatch(d.descHeader())
+	if m == nil {
+		return
+	}
+	d.RequiresAttunement = true
+	d.AttunementBy = strings.TrimSpace(m[1])
+}
+
+// magicItemToolOutput defines the output structure for the magic items tool.
+// Results holds plain list entries; Items holds full details when rarity or attunement filters were applied.
+type magicItemToolOutput struct {
+	Count     int                        `json:"count,omitempty"`
+	Results   []magicItemListAPIResponse `json:"results,omitempty"`
+	Items     []magicItemDetail          `json:"items,omitempty"`
+	MagicItem *magicItemDetail           `json:"magic_item,omitempty"`
+}
+
+// fetchMagicItemByNameResult fetches a magic item by name and returns an MCP tool result.
+func fetchMagicItemByNameResult(
+	ctx context.Context,
+	src dataSource,
+	input magicItemToolInput,
+) (*mcp.CallToolResult, error) {
+	item := &magicItemDetail{}
+	err := fetchByName(ctx, src, magicItems, input.Name, item)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to fetch magic item", err), err
+	}
+	item.parseAttunement()
+	output := magicItemToolOutput{MagicItem: item}
+	jsonData, err := json.Marshal(output)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal magic item output", err), err
+	}
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+// fetchMagicItemRefs returns the magic items in the requested equipment category, or all magic items if no category is given.
+func fetchMagicItemRefs(ctx context.Context, src dataSource, category string) ([]magicItemListAPIResponse, error) {
+	if category == "" {
+		var results []magicItemListAPIResponse
+		err := fetchList(ctx, src, magicItems, &results, "")
+		return results, err
+	}
+	cat := &equipmentCategoryDetail{}
+	if err := fetchByName(ctx, src, equipmentCategories, category, cat); err != nil {
+		return nil, err
+	}
+	var results []magicItemListAPIResponse
+	for _, ref := range cat.Equipment {
+		if strings.Contains(ref.URL, "/"+string(magicItems)+"/") {
+			results = append(results, magicItemListAPIResponse(ref))
+		}
+	}
+	return results, nil
+}
+
+// fetchMagicItemListResult lists magic items, optionally by category, rarity and attunement, and returns an MCP tool result.
+func fetchMagicItemListResult(
+	ctx context.Context,
+	src dataSource,
+	input magicItemToolInput,
+) (*mcp.CallToolResult, error) {
+	results, err := fetchMagicItemRefs(ctx, src, input.Category)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to fetch magic item list", err), err
+	}
+	output := magicItemToolOutput{Count: len(results), Results: results}
+	if input.hasFilters() {
+		indexes := make([]string, len(results))
+		for i, r := range results {
+			indexes[i] = r.Index
+		}
+		items, err := fetchMatchingDetails(ctx, src, magicItems, indexes,
+			func(item *magicItemDetail) bool {
+				item.parseAttunement()
+				return input.matches(*item)
+			})
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Failed to fetch magic item details", err), err
+		}
+		output = magicItemToolOutput{Count: len(items), Items: items}
+	}
+	jsonData, err := json.Marshal(output)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal magic item list output", err), err
+	}
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+// runMagicItemTool executes the core logic for the magic items tool.
+func runMagicItemTool(ctx context.Context, src dataSource, input magicItemToolInput) (*mcp.CallToolResult, error) {
+	if input.Name != "" {
+		return fetchMagicItemByNameResult(ctx, src, input)
+	}
+	return fetchMagicItemListResult(ctx, src, input)
+}
+
+// handleMagicItemTool returns the MCP handler for the magic items tool.
+func handleMagicItemTool(src dataSource) mcp.TypedToolHandlerFunc[magicItemToolInput] {
+	return func(ctx context.Context, req mcp.CallToolRequest, input magicItemToolInput) (*mcp.CallToolResult, error) {
+		return runMagicItemTool(ctx, src, input)
+	}
+}
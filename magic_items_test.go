@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestMagicItemDetail_parseAttunement(t *testing.T) {
+	cases := []struct {
+		header     string
+		wantAttune bool
+		wantBy     string
+	}{
+		{"Wondrous item, uncommon", false, ""},
+		{"Ring, rare (requires attunement)", true, ""},
+		{"Wondrous item, very rare (requires attunement by a cleric, druid, or paladin)", true, "a cleric, druid, or paladin"},
+		{"Weapon (any sword), legendary (Requires Attunement)", true, ""},
+		{"", false, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.header, func(t *testing.T) {
+			item := magicItemDetail{Desc: []string{tc.header, "Body text mentioning (requires attunement) should be ignored."}}
+			if tc.header == "" {
+				item.Desc = nil
+			}
+			item.parseAttunement()
+			if item.RequiresAttunement != tc.wantAttune || item.AttunementBy != tc.wantBy {
+				t.Errorf("got (%v, %q), want (%v, %q)", item.RequiresAttunement, item.AttunementBy, tc.wantAttune, tc.wantBy)
+			}
+		})
+	}
+}
+
+func TestMagicItemDetail_parseAttunementSRDText(t *testing.T) {
+	cases := []struct {
+		name       string
+		desc       paragraphs
+		wantAttune bool
+		wantBy     string
+	}{
+		// The 2014 ruleset returns the header as the first of several paragraphs.
+		{"2014 bag of holding", paragraphs{"Wondrous item, uncommon", "This bag has an interior space considerably larger than its outside dimensions, roughly 2 feet in diameter at the mouth and 4 feet deep."}, false, ""},
+		{"2014 armor of invulnerability", paragraphs{"Armor (plate), legendary (requires attunement)", "You have resistance to nonmagical damage while you wear this armor."}, true, ""},
+		{"2014 staff of power", paragraphs{"Staff, very rare (requires attunement by a sorcerer, warlock, or wizard)", "This staff can be wielded as a magic quarterstaff that grants a +2 bonus to attack and damage rolls made with it."}, true, "a sorcerer, warlock, or wizard"},
+		{"2014 holy avenger", paragraphs{"Weapon (any sword), legendary (requires attunement by a paladin)", "You gain a +3 bonus to attack and damage rolls made with this magic weapon."}, true, "a paladin"},
+		{"2014 talisman of pure good", paragraphs{"Wondrous item, legendary (requires attunement by a creature of good alignment)", "This talisman is a mighty symbol of goodness."}, true, "a creature of good alignment"},
+		// The 2024 ruleset returns the whole description as one string starting with the header line.
+		{"2024 bag of holding", paragraphs{"Wondrous Item, Uncommon\n\nThis bag has an interior space considerably larger than its outside dimensions—roughly 2 feet square and 4 feet deep on the inside."}, false, ""},
+		{"2024 cloak of protection", paragraphs{"Wondrous Item, Uncommon (Requires Attunement)\n\nYou gain a +1 bonus to Armor Class and saving throws while you wear this cloak."}, true, ""},
+		{"2024 staff of power", paragraphs{"Staff, Very Rare (Requires Attunement by a Sorcerer, Warlock, or Wizard)\n\nThis staff has 20 charges."}, true, "a Sorcerer, Warlock, or Wizard"},
+		{"2024 italic header", paragraphs{"*Ring, Rare (Requires Attunement)*\n\nWhile wearing this ring, you can turn invisible."}, true, ""},
+		{"2024 attunement in body only", paragraphs{"Potion, Uncommon\n\nA creature that drinks it loses attunement to one item (requires attunement) of its choice."}, false, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			item := magicItemDetail{Desc: tc.desc}
+			item.parseAttunement()
+			if item.RequiresAttunement != tc.wantAttune || item.AttunementBy != tc.wantBy {
+				t.Errorf("got (%v, %q), want (%v, %q)", item.RequiresAttunement, item.AttunementBy, tc.wantAttune, tc.wantBy)
+			}
+		})
+	}
+}
+
+func TestRunMagicItemTool(t *testing.T) {
+	items := map[string]magicItemDetail{
+		"bag-of-holding": {Index: "bag-of-holding", Name: "Bag of Holding", Rarity: magicItemRarity{Name: "Uncommon"},
+			Desc: []string{"Wondrous item, uncommon"}},
+		"cloak-of-protection": {Index: "cloak-of-protection", Name: "Cloak of Protection", Rarity: magicItemRarity{Name: "Uncommon"},
+			Desc: []string{"Wondrous item, uncommon (requires attunement)"}},
+		"ring-of-three-wishes": {Index: "ring-of-three-wishes", Name: "Ring of Three Wishes", Rarity: magicItemRarity{Name: "Legendary"},
+			Desc: []string{"Ring, legendary"}},
+	}
+	wondrous := equipmentCategoryDetail{
+		Index: "wondrous-items",
+		Equipment: []apiReference{
+			{Index: "bag-of-holding", URL: "/api/2014/magic-items/bag-of-holding"},
+			{Index: "cloak-of-protection", URL: "/api/2014/magic-items/cloak-of-protection"},
+		},
+	}
+	src := &mockDataSource{
+		get: func(_ context.Context, e endpoint, index string, v any) error {
+			switch e {
+			case equipmentCategories:
+				if index != "wondrous-items" {
+					return errNotFound
+				}
+				*v.(*equipmentCategoryDetail) = wondrous
+				return nil
+			case magicItems:
+				item, ok := items[index]
+				if !ok {
+					return errNotFound
+				}
+				*v.(*magicItemDetail) = item
+				return nil
+			}
+			return errors.New("unexpected endpoint")
+		},
+		list: func(_ context.Context, _ endpoint, _ string, v any) error {
+			*v.(*[]magicItemListAPIResponse) = []magicItemListAPIResponse{
+				{Index: "bag-of-holding"}, {Index: "cloak-of-protection"}, {Index: "ring-of-three-wishes"},
+			}
+			return nil
+		},
+	}
+	yes, no := true, false
+
+	cases := []struct {
+		name        string
+		input       magicItemToolInput
+		wantErr     bool
+		wantName    string
+		wantAttune  bool
+		wantResults []string
+		wantItems   []string
+	}{
+		{name: "by name", input: magicItemToolInput{Name: "Cloak of Protection"}, wantName: "Cloak of Protection", wantAttune: true},
+		{name: "by name not found", input: magicItemToolInput{Name: "Deck of Many Things"}, wantErr: true},
+		{name: "list all", input: magicItemToolInput{}, wantResults: []string{"bag-of-holding", "cloak-of-protection", "ring-of-three-wishes"}},
+		{name: "list category", input: magicItemToolInput{Category: "Wondrous Items"}, wantResults: []string{"bag-of-holding", "cloak-of-protection"}},
+		{name: "rarity", input: magicItemToolInput{Rarity: "legendary"}, wantItems: []string{"ring-of-three-wishes"}},
+		{name: "requires attunement", input: magicItemToolInput{RequiresAttunement: &yes}, wantItems: []string{"cloak-of-protection"}},
+		{name: "no attunement in category", input: magicItemToolInput{Category: "wondrous-items", RequiresAttunement: &no}, wantItems: []string{"bag-of-holding"}},
+		{name: "rarity and attunement", input: magicItemToolInput{Rarity: "Uncommon", RequiresAttunement: &yes}, wantItems: []string{"cloak-of-protection"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := runMagicItemTool(context.Background(), src, tc.input)
+			if tc.wantErr {
+				if err == nil || res == nil || !res.IsError {
+					t.Fatalf("expected error result, got %+v, %v", res, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			txt, ok := mcp.AsTextContent(res.Content[0])
+			if !ok {
+				t.Fatalf("content is not TextContent, got %T", res.Content[0])
+			}
+			var out magicItemToolOutput
+			if err := json.Unmarshal([]byte(txt.Text), &out); err != nil {
+				t.Fatalf("unmarshal output: %v", err)
+			}
+			if tc.wantName != "" {
+				if out.MagicItem == nil || out.MagicItem.Name != tc.wantName || out.MagicItem.RequiresAttunement != tc.wantAttune {
+					t.Errorf("expected %q (attunement %v), got %+v", tc.wantName, tc.wantAttune, out.MagicItem)
+				}
+				return
+			}
+			var gotResults, gotItems []string
+			for _, r := range out.Results {
+				gotResults = append(gotResults, r.Index)
+			}
+			for _, i := range out.Items {
+				gotItems = append(gotItems, i.Index)
+			}
+			if strings.Join(gotResults, ",") != strings.Join(tc.wantResults, ",") {
+				t.Errorf("expected results %v, got %v", tc.wantResults, gotResults)
+			}
+			if strings.Join(gotItems, ",") != strings.Join(tc.wantItems, ",") {
+				t.Errorf("expected items %v, got %v", tc.wantItems, gotItems)
+			}
+		})
+	}
+}
+
+func TestRunMagicItemTool_FiltersWholeList(t *testing.T) {
+	var refs []magicItemListAPIResponse
+	for i := 0; i < 360; i++ {
+		refs = append(refs, magicItemListAPIResponse{Index: fmt.Sprintf("item-%d", i)})
+	}
+	legendary := map[string]bool{"item-300": true, "item-359": true}
+	src := &mockDataSource{
+		get: func(_ context.Context, _ endpoint, index string, v any) error {
+			rarity := "Common"
+			if legendary[index] {
+				rarity = "Legendary"
+			}
+			return setJSON(v, magicItemDetail{Index: index, Rarity: magicItemRarity{Name: rarity}})
+		},
+		list: func(_ context.Context, _ endpoint, _ string, v any) error { return setJSON(v, refs) },
+	}
+	res, err := runMagicItemTool(context.Background(), src, magicItemToolInput{Rarity: "legendary"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var out magicItemToolOutput
+	txt, _ := mcp.AsTextContent(res.Content[0])
+	if err := json.Unmarshal([]byte(txt.Text), &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Count != 2 || out.Items[0].Index != "item-300" || out.Items[1].Index != "item-359" {
+		t.Errorf("expected both legendary items from the end of the list, got %+v", out.Items)
+	}
+}
@@ -2,7 +2,16 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
 	"reflect"
+	"syscall"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
@@ -14,38 +23,242 @@ func newAPITool[T any](
 	e endpoint,
 	description string,
 	input T,
-	handler func(ctx context.Context, req mcp.CallToolRequest, input T) (*mcp.CallToolResult, error),
+	handler mcp.TypedToolHandlerFunc[T],
+) server.ServerTool {
+	return newTool(string(e), description, input, handler)
+}
+
+// newTool creates a new read-only MCP tool with the given name, input type and handler.
+// It is used for tools that do not map onto a single API endpoint.
+func newTool[T any](
+	name string,
+	description string,
+	input T,
+	handler mcp.TypedToolHandlerFunc[T],
+) server.ServerTool {
+	readonly := true
+	return buildTool(name, description, input, handler,
+		mcp.ToolAnnotation{ReadOnlyHint: &readonly, OpenWorldHint: &readonly},
+		expandOption, rulesetOption,
+	)
+}
+
+// newWriteTool creates a new MCP tool that changes the server's local content. Unlike the read-only tools
+// it does not reach the API, so it takes neither the expand nor the ruleset argument.
+func newWriteTool[T any](
+	name string,
+	description string,
+	input T,
+	handler mcp.TypedToolHandlerFunc[T],
+	destructive bool,
+	idempotent bool,
+) server.ServerTool {
+	readonly, openWorld := false, false
+	return buildTool(name, description, input, handler, mcp.ToolAnnotation{
+		ReadOnlyHint:    &readonly,
+		DestructiveHint: &destructive,
+		IdempotentHint:  &idempotent,
+		OpenWorldHint:   &openWorld,
+	})
+}
+
+// buildTool creates an MCP tool from its input type, annotation and any extra schema options.
+func buildTool[T any](
+	name string,
+	description string,
+	input T,
+	handler mcp.TypedToolHandlerFunc[T],
+	annotation mcp.ToolAnnotation,
+	extra ...mcp.ToolOption,
 ) server.ServerTool {
 	logrus.WithFields(logrus.Fields{
-		"endpoint":    e,
+		"tool":        name,
 		"description": description,
 		"inputType":   reflect.TypeOf(input),
-	}).Debug("Creating new API tool")
+	}).Trace("Creating new API tool")
 	opts := []mcp.ToolOption{
 		mcp.WithDescription(description),
 	}
 	opts = append(opts, makeToolOptions(input)...)
-	readonly := true
-	opts = append(opts, mcp.WithToolAnnotation(mcp.ToolAnnotation{ReadOnlyHint: &readonly, OpenWorldHint: &readonly}))
-	tool := mcp.NewTool(string(e), opts...)
-	logrus.Debugf("Tool Input Schema Properties: %v", tool.InputSchema.Properties)
+	opts = append(opts, extra...)
+	opts = append(opts, mcp.WithToolAnnotation(annotation))
+	tool := mcp.NewTool(name, opts...)
+	logrus.Tracef("Tool Input Schema Properties: %v", tool.InputSchema.Properties)
 	return server.ServerTool{
 		Tool:    tool,
 		Handler: mcp.NewTypedToolHandler(handler),
 	}
 }
 
+// withToolMiddleware returns the tools with their handlers wrapped in the given middlewares, the first outermost
+// as with server.WithToolHandlerMiddleware. It applies middlewares that only suit some of the tools.
+func withToolMiddleware(tools []server.ServerTool, mws ...server.ToolHandlerMiddleware) []server.ServerTool {
+	wrapped := make([]server.ServerTool, len(tools))
+	for i, tool := range tools {
+		for j := len(mws) - 1; j >= 0; j-- {
+			tool.Handler = mws[j](tool.Handler)
+		}
+		wrapped[i] = tool
+	}
+	return wrapped
+}
+
+// selectTools returns the tools chosen by the tool settings, in their original order. Naming a tool
+// that does not exist is an error, so that typos do not silently leave a tool enabled.
+func selectTools(tools []server.ServerTool, settings toolSettings) ([]server.ServerTool, error) {
+	known := map[string]bool{}
+	for _, tool := range tools {
+		known[tool.Tool.Name] = true
+	}
+	var errs []error
+	enabled, disabled := map[string]bool{}, map[string]bool{}
+	for _, names := range []struct {
+		key string
+		set map[string]bool
+		in  []string
+	}{{"tools.enabled", enabled, settings.Enabled}, {"tools.disabled", disabled, settings.Disabled}} {
+		for _, name := range names.in {
+			if !known[name] {
+				errs = append(errs, fmt.Errorf("%s: unknown tool %q", names.key, name))
+			}
+			names.set[name] = true
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	var selected []server.ServerTool
+	for _, tool := range tools {
+		if (len(enabled) == 0 || enabled[tool.Tool.Name]) && !disabled[tool.Tool.Name] {
+			selected = append(selected, tool)
+		}
+	}
+	return selected, nil
+}
+
 func main() {
 	logrus.SetFormatter(&logrus.TextFormatter{FullTimestamp: true})
-	logrus.SetLevel(logrus.DebugLevel)
+
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "snapshot":
+			if err := runSnapshotCommand(os.Args[2:]); err != nil {
+				logrus.WithError(err).Fatal("Snapshot failed")
+			}
+			return
+		case "config":
+			if err := runConfigCommand(os.Args[2:], os.Stdout); err != nil {
+				logrus.WithError(err).Fatal("Invalid configuration")
+			}
+			return
+		}
+	}
+
+	cfg, err := loadConfig(os.Args[0], os.Args[1:], os.LookupEnv)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		logrus.WithError(err).Fatal("Invalid configuration")
+	}
+	closeLog, err := setupLogging(cfg.Log)
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to set up logging")
+	}
+	defer closeLog()
+
 	logrus.Info("Starting D&D 5e MCP server...")
 
-	s := server.NewMCPServer(
-		"D&D 5e Knowledge Base",
-		"1.0.0",
+	t, _ := parseTransport(cfg.Transport.Type)
+	transportCfg := transportConfig{
+		Transport:       t,
+		Addr:            cfg.Transport.Addr,
+		BasePath:        cfg.Transport.BasePath,
+		ShutdownTimeout: time.Duration(cfg.Transport.ShutdownTimeout),
+	}
+
+	opts := []server.ServerOption{
 		server.WithToolCapabilities(true),
 		server.WithRecovery(),
 		server.WithLogging(),
+		server.WithHooks(clientLogHooks()),
+		server.WithToolHandlerMiddleware(requestLogMiddleware()),
+	}
+	var m *metrics
+	if cfg.Metrics.Addr != "" {
+		m = newMetrics()
+		opts = append(opts, server.WithToolHandlerMiddleware(metricsMiddleware(m)))
+	}
+	if cfg.Transport.APIKeys != "" {
+		auth, err := loadAPIKeys(cfg.Transport.APIKeys)
+		if err != nil {
+			logrus.WithError(err).Fatal("Failed to load API keys")
+		}
+		audit, err := newAuditLogger(cfg.Transport.AuditLog)
+		if err != nil {
+			logrus.WithError(err).Fatal("Failed to open audit log")
+		}
+		logrus.WithField("keys", len(auth.clients)).Info("API key authentication enabled")
+		transportCfg.Auth, transportCfg.Audit = auth, audit
+		opts = append(opts, server.WithToolHandlerMiddleware(quotaMiddleware(audit)))
+	}
+	var src dataSource
+	serverRuleset := defaultRuleset
+	if cfg.Server.Ruleset != "" {
+		serverRuleset, _ = parseRuleset(cfg.Server.Ruleset)
+	}
+	if cfg.Server.Snapshot != "" {
+		snap, err := openSnapshot(cfg.Server.Snapshot)
+		if err != nil {
+			logrus.WithError(err).Fatal("Failed to open snapshot")
+		}
+		defer snap.Close()
+		logrus.WithFields(logrus.Fields{
+			"path":       cfg.Server.Snapshot,
+			"ruleset":    snap.manifest.ruleset(),
+			"created_at": snap.manifest.CreatedAt,
+		}).Info("Serving from offline snapshot")
+		if cfg.Server.Ruleset == "" {
+			serverRuleset = snap.manifest.ruleset()
+		}
+		src = snap
+		opts = append(opts, server.WithToolHandlerMiddleware(snapshotDateMiddleware(snap.manifest.CreatedAt)))
+	} else {
+		apiOpts := []apiOption{withRequestTimeout(time.Duration(cfg.API.Timeout))}
+		if cfg.Cache.Size > 0 {
+			cache, err := newResponseCache(cfg.Cache.Size, time.Duration(cfg.Cache.TTL), cfg.Cache.Dir)
+			if err != nil {
+				logrus.WithError(err).Fatal("Failed to create response cache")
+			}
+			defer cache.logStats(context.Background(), logrus.InfoLevel, "Response cache statistics")
+			apiOpts = append(apiOpts, withCache(cache))
+			if m != nil {
+				m.registerCache(cache)
+			}
+		}
+		var base http.RoundTripper = http.DefaultTransport
+		if m != nil {
+			base = m.upstreamTransport(base)
+		}
+		src = newAPIDataSource(newAPIClient(cfg.API.clientConfig(), base), cfg.API.BaseURL, apiOpts...)
+	}
+
+	var hb *homebrewDataSource
+	if cfg.Server.Homebrew != "" {
+		hb, err = loadHomebrew(cfg.Server.Homebrew, src)
+		if err != nil {
+			logrus.WithError(err).Fatal("Failed to load homebrew content")
+		}
+		src = hb
+	}
+	src = newNameCache(src)
+
+	logrus.WithField("ruleset", serverRuleset).Info("Default ruleset selected")
+
+	s := server.NewMCPServer(
+		cfg.Server.Name,
+		cfg.Server.Version,
+		opts...,
 	)
 
 	logrus.Info("Creating tools...")
@@ -54,51 +267,160 @@ func main() {
 			spells,
 			"Fetches information about D&D 5e spells.",
 			spellToolInput{},
-			handleSpellTool,
+			handleSpellTool(src),
 		),
 		newAPITool(
 			monsters,
 			"Fetches information about D&D 5e monsters.",
 			monsterToolInput{},
-			handleMonsterTool,
+			handleMonsterTool(src),
 		),
 		newAPITool(
 			abilityScores,
 			"Fetches information about D&D 5e ability scores.",
 			abilityScoreToolInput{},
-			handleAbilityScoreTool,
+			handleAbilityScoreTool(src),
 		),
 		newAPITool(
 			alignments,
 			"Fetches information about D&D 5e alignments.",
 			alignmentToolInput{},
-			handleAlignmentTool,
+			handleAlignmentTool(src),
 		),
 		newAPITool(
 			backgrounds,
 			"Fetches information about D&D 5e backgrounds.",
 			backgroundToolInput{},
-			handleBackgroundTool,
+			handleBackgroundTool(src),
 		),
 		newAPITool(
 			classes,
-			"Fetches information about D&D 5e classes.",
+			"Fetches information about D&D 5e classes, including the merged class and subclass features gained up to a given level.",
 			classToolInput{},
-			handleClassTool,
+			handleClassTool(src),
+		),
+		newAPITool(
+			conditions,
+			"Fetches information about D&D 5e conditions, optionally checking which of a set of monsters are immune.",
+			conditionToolInput{},
+			handleConditionTool(src),
+		),
+		newAPITool(
+			equipment,
+			"Fetches information about D&D 5e equipment, with filtering by category, cost, weight, weapon category, damage dice and armor class.",
+			equipmentToolInput{},
+			handleEquipmentTool(src),
+		),
+		newAPITool(
+			magicItems,
+			"Fetches information about D&D 5e magic items, with filtering by rarity, equipment category and attunement.",
+			magicItemToolInput{},
+			handleMagicItemTool(src),
+		),
+		newAPITool(
+			races,
+			"Fetches information about D&D 5e races, including their subraces and full trait descriptions.",
+			raceToolInput{},
+			handleRaceTool(src),
 		),
+		newAPITool(
+			subclasses,
+			"Fetches information about D&D 5e subclasses.",
+			subclassToolInput{},
+			handleSubclassTool(src),
+		),
+		newAPITool(
+			features,
+			"Fetches information about D&D 5e class and subclass features.",
+			featureToolInput{},
+			handleFeatureTool(src),
+		),
+		newAPITool(
+			feats,
+			"Fetches information about D&D 5e feats and their prerequisites.",
+			featToolInput{},
+			handleFeatTool(src),
+		),
+		newAPITool(
+			skills,
+			"Fetches information about D&D 5e skills, including the ability score that governs each skill.",
+			skillToolInput{},
+			handleSkillTool(src),
+		),
+		newAPITool(
+			languages,
+			"Fetches information about D&D 5e languages, with filtering by standard or exotic type.",
+			languageToolInput{},
+			handleLanguageTool(src),
+		),
+		newAPITool(
+			proficiencies,
+			"Fetches information about D&D 5e proficiencies, including the classes, races and subraces that grant them.",
+			proficiencyToolInput{},
+			handleProficiencyTool(src),
+		),
+		newAPITool(
+			rules,
+			"Fetches D&D 5e rules by section or chapter name, or searches all rule sections for keywords and returns the best matching paragraphs.",
+			ruleToolInput{},
+			handleRuleTool(src),
+		),
+		newTool(
+			glossaryToolName,
+			"Looks up D&D 5e damage types, schools of magic and weapon properties by name or prefix.",
+			glossaryToolInput{},
+			handleGlossaryTool(src),
+		),
+		newTool(
+			searchToolName,
+			"Searches every D&D 5e category by name and returns ranked hits with their category, index and a short description snippet. The first search of each ruleset builds the search index, which takes a few seconds.",
+			searchToolInput{},
+			handleSearchTool(src),
+		),
+	}
+	// The ruleset and expand arguments only apply to the read-only tools, not to the homebrew write tools.
+	tools = withToolMiddleware(tools, rulesetMiddleware(serverRuleset), expandMiddleware(src))
+	if hb != nil {
+		tools = append(tools, homebrewTools(hb)...)
+	}
+	tools, err = selectTools(tools, cfg.Tools)
+	if err != nil {
+		logrus.WithError(err).Fatal("Invalid tool selection")
 	}
 	for _, tool := range tools {
 		logrus.WithFields(logrus.Fields{
-			"tool":         tool.Tool.Name,
-			"description":  tool.Tool.Description,
-			"input_schema": tool.Tool.InputSchema,
-		}).Info("Registering tool")
+			"tool":        tool.Tool.Name,
+			"description": tool.Tool.Description,
+		}).Debug("Registering tool")
 		s.AddTool(tool.Tool, tool.Handler)
 	}
+	logrus.WithField("count", len(tools)).Info("Registered tools")
 
 	logrus.Info("Server setup complete. Listening for requests...")
 
-	if err := server.ServeStdio(s); err != nil {
-		logrus.WithError(err).Fatal("Server error")
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	metricsDone := make(chan struct{})
+	if m != nil {
+		ln, err := net.Listen("tcp", cfg.Metrics.Addr)
+		if err != nil {
+			logrus.WithError(err).Fatal("Failed to start metrics listener")
+		}
+		go func() {
+			defer close(metricsDone)
+			if err := serveMetrics(ctx, m, ln); err != nil {
+				logrus.WithError(err).Error("Metrics listener error")
+			}
+		}()
+	} else {
+		close(metricsDone)
+	}
+	err = serve(ctx, s, transportCfg)
+	stop()
+	<-metricsDone
+	if err != nil {
+		logrus.WithError(err).Error("Server error")
+		return
 	}
+	logrus.Info("Server stopped")
 }
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// metricsNamespace prefixes the name of every metric, e.g. dnd5e_mcp_tool_calls_total.
+	metricsNamespace = "dnd5e_mcp"
+	// metricsPath is where the metrics listener serves the metrics.
+	metricsPath = "/metrics"
+)
+
+// Failure kinds of a tool call, as reported by the kind label of dnd5e_mcp_tool_errors_total.
+const (
+	errorKindRejected            = "rejected"             // over the caller's quota or concurrency limit
+	errorKindInvalidArguments    = "invalid_arguments"    // arguments that do not match the tool's schema
+	errorKindNotFound            = "not_found"            // the requested resource does not exist
+	errorKindTimeout             = "timeout"              // a deadline expired
+	errorKindCanceled            = "canceled"             // the client cancelled the call
+	errorKindUpstreamUnavailable = "upstream_unavailable" // the circuit breaker rejected the API request
+	errorKindUpstream            = "upstream_error"       // the API request failed or returned an error status
+	errorKindOther               = "other"
+)
+
+// metrics holds the server's Prometheus metrics. They are kept in a registry of their own rather than the
+// global one, so that only the server's metrics and the Go runtime's are exported.
+type metrics struct {
+	registry         *prometheus.Registry
+	toolCalls        *prometheus.CounterVec
+	toolErrors       *prometheus.CounterVec
+	toolDuration     *prometheus.HistogramVec
+	upstreamDuration *prometheus.HistogramVec
+}
+
+// newMetrics creates the server's metrics and registers them together with the Go runtime and process metrics.
+func newMetrics() *metrics {
+	m := &metrics{
+		registry: prometheus.NewRegistry(),
+		toolCalls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "tool_calls_total",
+			Help:      "Tool calls handled, by tool.",
+		}, []string{"tool"}),
+		toolErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "tool_errors_total",
+			Help:      "Tool calls that failed, by tool and kind of failure.",
+		}, []string{"tool", "kind"}),
+		toolDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "tool_call_duration_seconds",
+			Help:      "Time taken to handle tool calls, by tool.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"tool"}),
+		upstreamDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "upstream_request_duration_seconds",
+			Help:      "Time taken by requests to the D&D 5e API, by endpoint and HTTP status; the status is \"error\" if no response was received.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"endpoint", "status"}),
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.toolCalls,
+		m.toolErrors,
+		m.toolDuration,
+		m.upstreamDuration,
+	)
+	return m
+}
+
+// registerCache exports the counters of the response cache c and its hit ratio.
+func (m *metrics) registerCache(c *responseCache) {
+	counter := func(name, help string, v func(cacheStats) uint64) prometheus.Collector {
+		return prometheus.NewCounterFunc(prometheus.CounterOpts{Namespace: metricsNamespace, Name: name, Help: help},
+			func() float64 { return float64(v(c.stats())) })
+	}
+	m.registry.MustRegister(
+		counter("cache_hits_total", "API responses served fresh from the cache.", func(s cacheStats) uint64 { return s.Hits }),
+		counter("cache_misses_total", "API responses not in the cache or stale, which required a request.", func(s cacheStats) uint64 { return s.Misses }),
+		counter("cache_revalidations_total", "Stale cache entries the API confirmed unchanged.", func(s cacheStats) uint64 { return s.Revalidations }),
+		counter("cache_evictions_total", "Cache entries evicted to make room for others.", func(s cacheStats) uint64 { return s.Evictions }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "cache_entries",
+			Help:      "API responses held in the in-memory cache.",
+		}, func() float64 { return float64(c.stats().Entries) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "cache_hit_ratio",
+			Help:      "Share of cache lookups served fresh from the cache since the server started.",
+		}, func() float64 {
+			st := c.stats()
+			if st.Hits+st.Misses == 0 {
+				return 0
+			}
+			return float64(st.Hits) / float64(st.Hits+st.Misses)
+		}),
+	)
+}
+
+// metricsMiddleware counts tool calls and their failures and times their handling.
+func metricsMiddleware(m *metrics) server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			start := time.Now()
+			result, err := next(ctx, req)
+			tool := req.Params.Name
+			m.toolCalls.WithLabelValues(tool).Inc()
+			m.toolDuration.WithLabelValues(tool).Observe(time.Since(start).Seconds())
+			if err != nil || (result != nil && result.IsError) {
+				m.toolErrors.WithLabelValues(tool, toolErrorKind(err)).Inc()
+			}
+			return result, err
+		}
+	}
+}
+
+// toolErrorKind classifies the failure of a tool call. A failed call without an error is one whose
+// arguments mcp-go could not bind to the tool's input.
+func toolErrorKind(err error) string {
+	var urlErr *url.Error
+	switch {
+	case err == nil:
+		return errorKindInvalidArguments
+	case errors.Is(err, errRequestRejected):
+		return errorKindRejected
+	case errors.Is(err, errNotFound):
+		return errorKindNotFound
+	case errors.Is(err, errCircuitOpen):
+		return errorKindUpstreamUnavailable
+	case errors.Is(err, context.DeadlineExceeded):
+		return errorKindTimeout
+	case errors.Is(err, context.Canceled):
+		return errorKindCanceled
+	case errors.Is(err, errUpstreamStatus), errors.As(err, &urlErr):
+		return errorKindUpstream
+	default:
+		return errorKindOther
+	}
+}
+
+// upstreamMetricsTransport is an http.RoundTripper that times every request to the API by endpoint and status.
+// It sits below the resilient transport, so each retry is a request of its own.
+type upstreamMetricsTransport struct {
+	next http.RoundTripper
+	m    *metrics
+}
+
+// RoundTrip sends the request with the next transport and records its duration.
+func (t upstreamMetricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	status := "error"
+	if err == nil {
+		status = strconv.Itoa(resp.StatusCode)
+	}
+	t.m.upstreamDuration.WithLabelValues(upstreamEndpoint(req.URL.Path), status).Observe(time.Since(start).Seconds())
+	return resp, err
+}
+
+// upstreamTransport returns next wrapped to record the metrics of API requests.
+func (m *metrics) upstreamTransport(next http.RoundTripper) http.RoundTripper {
+	return upstreamMetricsTransport{next: next, m: m}
+}
+
+// upstreamEndpoint returns the endpoint an API URL path belongs to, e.g. "spells" for /api/2014/spells/fireball,
+// or "other" if the path names none. Indices are left out to keep the number of label values bounded.
+func upstreamEndpoint(p string) string {
+	for _, seg := range strings.Split(p, "/") {
+		if e, ok := endpointForPath(seg); ok {
+			return string(e)
+		}
+	}
+	return "other"
+}
+
+// serveMetrics serves the metrics of m at /metrics on ln until ctx is cancelled, then shuts the listener down.
+// The listener is separate from the MCP transports and is not protected by API keys.
+func serveMetrics(ctx context.Context, m *metrics, ln net.Listener) error {
+	mux := http.NewServeMux()
+	mux.Handle(metricsPath, promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	errc := make(chan error, 1)
+	go func() { errc <- srv.Serve(ln) }()
+	logrus.WithField("addr", ln.Addr().String()).Info("Serving metrics at " + metricsPath)
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), defaultShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		srv.Close()
+	}
+	if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestToolErrorKind(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want string
+	}{
+		{nil, errorKindInvalidArguments},
+		{fmt.Errorf("%w: quota exceeded", errRequestRejected), errorKindRejected},
+		{fmt.Errorf("%w: /api/2014/spells/nope", errNotFound), errorKindNotFound},
+		{&url.Error{Op: "Get", URL: "http://api", Err: errCircuitOpen}, errorKindUpstreamUnavailable},
+		{&url.Error{Op: "Get", URL: "http://api", Err: context.DeadlineExceeded}, errorKindTimeout},
+		{context.Canceled, errorKindCanceled},
+		{fmt.Errorf("%w with status 502", errUpstreamStatus), errorKindUpstream},
+		{&url.Error{Op: "Get", URL: "http://api", Err: errors.New("connection refused")}, errorKindUpstream},
+		{errors.New("invalid query"), errorKindOther},
+	} {
+		if got := toolErrorKind(tc.err); got != tc.want {
+			t.Errorf("toolErrorKind(%v) = %s, want %s", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestUpstreamEndpoint(t *testing.T) {
+	for p, want := range map[string]string{
+		"/api/2014/spells/fireball":         "spells",
+		"/api/2014/classes/wizard/spells":   "classes",
+		"/api/2024/magic-items":             "magic-items",
+		"/api/2014/monsters/ancient-dragon": "monsters",
+		"/api/2014":                         "other",
+	} {
+		if got := upstreamEndpoint(p); got != want {
+			t.Errorf("upstreamEndpoint(%q) = %s, want %s", p, got, want)
+		}
+	}
+}
+
+func TestMetricsMiddleware(t *testing.T) {
+	m := newMetrics()
+	handler := metricsMiddleware(m)(func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if req.Params.Name == "monsters" {
+			err := fmt.Errorf("%w: monsters/nope", errNotFound)
+			return mcp.NewToolResultErrorFromErr("failed to fetch monster", err), err
+		}
+		return mcp.NewToolResultText("{}"), nil
+	})
+	call := func(tool string) {
+		req := mcp.CallToolRequest{}
+		req.Params.Name = tool
+		handler(context.Background(), req)
+	}
+	call("spells")
+	call("spells")
+	call("monsters")
+
+	if got := testutil.ToFloat64(m.toolCalls.WithLabelValues("spells")); got != 2 {
+		t.Errorf("expected 2 spells calls, got %v", got)
+	}
+	if got := testutil.ToFloat64(m.toolErrors.WithLabelValues("monsters", errorKindNotFound)); got != 1 {
+		t.Errorf("expected 1 not_found monsters error, got %v", got)
+	}
+	if got := testutil.CollectAndCount(m.toolErrors); got != 1 {
+		t.Errorf("expected errors only for monsters, got %d series", got)
+	}
+	if got := testutil.CollectAndCount(m.toolDuration); got != 2 {
+		t.Errorf("expected a latency histogram per tool, got %d", got)
+	}
+}
+
+func TestUpstreamAndCacheMetrics(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/nope") {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `{"index":"fireball"}`)
+	}))
+	defer srv.Close()
+
+	m := newMetrics()
+	cache, err := newResponseCache(8, time.Hour, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.registerCache(cache)
+	client := newAPIClient(testClientConfig(), m.upstreamTransport(http.DefaultTransport))
+	src := newAPIDataSource(client, srv.URL+"/api", withCache(cache))
+
+	ctx := context.Background()
+	var v map[string]any
+	for i := 0; i < 2; i++ {
+		if err := src.Get(ctx, spells, "fireball", &v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := src.Get(ctx, spells, "nope", &v); !errors.Is(err, errNotFound) {
+		t.Fatalf("expected errNotFound, got %v", err)
+	}
+
+	body := scrapeMetrics(t, m)
+	for _, want := range []string{
+		`dnd5e_mcp_upstream_request_duration_seconds_count{endpoint="spells",status="200"} 1`,
+		`dnd5e_mcp_upstream_request_duration_seconds_count{endpoint="spells",status="404"} 1`,
+		`dnd5e_mcp_cache_hits_total 1`,
+		`dnd5e_mcp_cache_misses_total 2`,
+		`dnd5e_mcp_cache_entries 1`,
+		`dnd5e_mcp_cache_hit_ratio 0.3333333333333333`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected metrics to contain %q", want)
+		}
+	}
+}
+
+// scrapeMetrics serves the metrics of m on a local listener and returns what a scrape of /metrics returns.
+// The listener is shut down before it returns.
+func scrapeMetrics(t *testing.T, m *metrics) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() { errc <- serveMetrics(ctx, m, ln) }()
+	defer func() {
+		cancel()
+		if err := <-errc; err != nil {
+			t.Errorf("metrics listener: %v", err)
+		}
+	}()
+
+	resp, err := http.Get("http://" + ln.Addr().String() + metricsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 from %s, got %d", metricsPath, resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+func TestServeMetrics(t *testing.T) {
+	m := newMetrics()
+	m.toolCalls.WithLabelValues("spells").Inc()
+	body := scrapeMetrics(t, m)
+	for _, want := range []string{`dnd5e_mcp_tool_calls_total{tool="spells"} 1`, "go_goroutines"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected metrics to contain %q", want)
+		}
+	}
+}
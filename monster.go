@@ -3,7 +3,7 @@ package main
 import (
 	"context"
 	"encoding/json"
-	"net/http"
+	"fmt"
 	"strconv"
 	"strings"
 
@@ -12,9 +12,10 @@ import (
 
 // monsterListAPIResponse defines the structure for a single monster in the list response.
 type monsterListAPIResponse struct {
-	Index string `json:"index"`
-	Name  string `json:"name"`
-	URL   string `json:"url"`
+	Index  string `json:"index"`
+	Name   string `json:"name"`
+	URL    string `json:"url"`
+	Source string `json:"source,omitempty"`
 }
 
 // monsterToolInput defines the input structure for the monster tool.
@@ -74,10 +75,10 @@ type monsterDetail struct {
 			URL   string `json:"url"`
 		} `json:"proficiency"`
 	} `json:"proficiencies"`
-	DamageVulnerabilities []string `json:"damage_vulnerabilities"`
-	DamageResistances     []string `json:"damage_resistances"`
-	DamageImmunities      []string `json:"damage_immunities"`
-	ConditionImmunities   []string `json:"condition_immunities"`
+	DamageVulnerabilities []string       `json:"damage_vulnerabilities"`
+	DamageResistances     []string       `json:"damage_resistances"`
+	DamageImmunities      []string       `json:"damage_immunities"`
+	ConditionImmunities   []apiReference `json:"condition_immunities"`
 	Senses                struct {
 		Darkvision        string `json:"darkvision"`
 		PassivePerception int    `json:"passive_perception"`
@@ -149,16 +150,32 @@ type monsterDetail struct {
 	Image     string `json:"image"`
 	URL       string `json:"url"`
 	UpdatedAt string `json:"updated_at"`
+	Source    string `json:"source,omitempty"`
+}
+
+// validate checks the fields the monster tool relies on, for monsters defined as homebrew.
+func (m *monsterDetail) validate() error {
+	switch {
+	case m.Size == "" || m.Type == "":
+		return fmt.Errorf("size and type are required")
+	case len(m.ArmorClass) == 0:
+		return fmt.Errorf("armor_class is required")
+	case m.HitPoints <= 0:
+		return fmt.Errorf("hit_points must be positive, got %d", m.HitPoints)
+	case m.ChallengeRating < 0:
+		return fmt.Errorf("challenge_rating must not be negative, got %v", m.ChallengeRating)
+	}
+	return nil
 }
 
 // fetchMonsterByNameResult fetches a monster by index and returns an MCP tool result.
 func fetchMonsterByNameResult(
-	client *http.Client,
+	ctx context.Context,
+	src dataSource,
 	input monsterToolInput,
-	fetchByName func(*http.Client, endpoint, string, any) error,
 ) (*mcp.CallToolResult, error) {
 	monster := &monsterDetail{}
-	err := fetchByName(client, monsters, input.Name, monster)
+	err := fetchByName(ctx, src, monsters, input.Name, monster)
 	if err != nil {
 		return mcp.NewToolResultErrorFromErr("failed to fetch monster", err), err
 	}
@@ -172,12 +189,12 @@ func fetchMonsterByNameResult(
 
 // fetchMonsterListResult fetches a list of monsters with optional filtering and returns an MCP tool result.
 func fetchMonsterListResult(
-	client *http.Client,
+	ctx context.Context,
+	src dataSource,
 	input monsterToolInput,
-	fetchList func(*http.Client, endpoint, any, string) error,
 ) (*mcp.CallToolResult, error) {
 	var results []monsterListAPIResponse
-	err := fetchList(client, monsters, &results, input.buildQueryString())
+	err := fetchList(ctx, src, monsters, &results, input.buildQueryString())
 	if err != nil {
 		return mcp.NewToolResultErrorFromErr("Failed to fetch monster list", err), err
 	}
@@ -189,20 +206,17 @@ func fetchMonsterListResult(
 	return mcp.NewToolResultText(string(jsonData)), nil
 }
 
-// runMonsterTool executes the core logic for the monster tool, using injected fetchByName and fetchList dependencies for testability.
-func runMonsterTool(
-	input monsterToolInput,
-	fetchByName func(*http.Client, endpoint, string, any) error,
-	fetchList func(*http.Client, endpoint, any, string) error,
-) (*mcp.CallToolResult, error) {
-	client := http.DefaultClient
+// runMonsterTool executes the core logic for the monster tool, using the injected data source for testability.
+func runMonsterTool(ctx context.Context, src dataSource, input monsterToolInput) (*mcp.CallToolResult, error) {
 	if input.Name != "" {
-		return fetchMonsterByNameResult(client, input, fetchByName)
+		return fetchMonsterByNameResult(ctx, src, input)
 	}
-	return fetchMonsterListResult(client, input, fetchList)
+	return fetchMonsterListResult(ctx, src, input)
 }
 
-// handleMonsterTool is the MCP handler for the monster tool.
-func handleMonsterTool(ctx context.Context, req mcp.CallToolRequest, input monsterToolInput) (*mcp.CallToolResult, error) {
-	return runMonsterTool(input, fetchByName, fetchList)
+// handleMonsterTool returns the MCP handler for the monster tool.
+func handleMonsterTool(src dataSource) mcp.TypedToolHandlerFunc[monsterToolInput] {
+	return func(ctx context.Context, req mcp.CallToolRequest, input monsterToolInput) (*mcp.CallToolResult, error) {
+		return runMonsterTool(ctx, src, input)
+	}
 }
@@ -1,9 +1,9 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
-	"net/http"
 	"strings"
 	"testing"
 
@@ -37,8 +37,8 @@ func TestRunMonsterTool(t *testing.T) {
 	cases := []struct {
 		name       string
 		input      monsterToolInput
-		mockByName func(*http.Client, endpoint, string, any) error
-		mockList   func(*http.Client, endpoint, any, string) error
+		mockByName func(context.Context, endpoint, string, any) error
+		mockList   func(context.Context, endpoint, string, any) error
 		wantOutput monsterToolOutput
 		wantErr    bool
 		wantErrMsg string
@@ -46,7 +46,7 @@ func TestRunMonsterTool(t *testing.T) {
 		{
 			name:  "by name",
 			input: monsterToolInput{Name: "goblin"},
-			mockByName: func(_ *http.Client, _ endpoint, name string, v any) error {
+			mockByName: func(_ context.Context, _ endpoint, name string, v any) error {
 				ptr, ok := v.(*monsterDetail)
 				if !ok {
 					return errors.New("wrong type")
@@ -54,7 +54,7 @@ func TestRunMonsterTool(t *testing.T) {
 				*ptr = monster
 				return nil
 			},
-			mockList:   func(_ *http.Client, _ endpoint, v any, _ string) error { return nil },
+			mockList:   func(_ context.Context, _ endpoint, _ string, v any) error { return nil },
 			wantOutput: monsterToolOutput{Monster: &monster},
 			wantErr:    false,
 			wantErrMsg: "",
@@ -62,8 +62,8 @@ func TestRunMonsterTool(t *testing.T) {
 		{
 			name:       "list",
 			input:      monsterToolInput{},
-			mockByName: func(_ *http.Client, _ endpoint, name string, v any) error { return nil },
-			mockList: func(_ *http.Client, _ endpoint, v any, _ string) error {
+			mockByName: func(_ context.Context, _ endpoint, name string, v any) error { return nil },
+			mockList: func(_ context.Context, _ endpoint, _ string, v any) error {
 				ptr, ok := v.(*[]monsterListAPIResponse)
 				if !ok {
 					return errors.New("wrong type")
@@ -78,10 +78,10 @@ func TestRunMonsterTool(t *testing.T) {
 		{
 			name:  "fetchByName error",
 			input: monsterToolInput{Name: "fail"},
-			mockByName: func(_ *http.Client, _ endpoint, name string, v any) error {
+			mockByName: func(_ context.Context, _ endpoint, name string, v any) error {
 				return errors.New("fetchByName failed")
 			},
-			mockList:   func(_ *http.Client, _ endpoint, v any, _ string) error { return nil },
+			mockList:   func(_ context.Context, _ endpoint, _ string, v any) error { return nil },
 			wantOutput: monsterToolOutput{},
 			wantErr:    true,
 			wantErrMsg: "fetchByName failed",
@@ -89,8 +89,8 @@ func TestRunMonsterTool(t *testing.T) {
 		{
 			name:       "fetchList error",
 			input:      monsterToolInput{},
-			mockByName: func(_ *http.Client, _ endpoint, name string, v any) error { return nil },
-			mockList: func(_ *http.Client, _ endpoint, v any, _ string) error {
+			mockByName: func(_ context.Context, _ endpoint, name string, v any) error { return nil },
+			mockList: func(_ context.Context, _ endpoint, _ string, v any) error {
 				return errors.New("fetchList failed")
 			},
 			wantOutput: monsterToolOutput{},
@@ -100,8 +100,8 @@ func TestRunMonsterTool(t *testing.T) {
 		{
 			name:       "empty list",
 			input:      monsterToolInput{},
-			mockByName: func(_ *http.Client, _ endpoint, name string, v any) error { return nil },
-			mockList: func(_ *http.Client, _ endpoint, v any, _ string) error {
+			mockByName: func(_ context.Context, _ endpoint, name string, v any) error { return nil },
+			mockList: func(_ context.Context, _ endpoint, _ string, v any) error {
 				ptr, ok := v.(*[]monsterListAPIResponse)
 				if !ok {
 					return errors.New("wrong type")
@@ -116,7 +116,7 @@ func TestRunMonsterTool(t *testing.T) {
 		{
 			name:  "nil monster from fetchByName",
 			input: monsterToolInput{Name: "empty"},
-			mockByName: func(_ *http.Client, _ endpoint, name string, v any) error {
+			mockByName: func(_ context.Context, _ endpoint, name string, v any) error {
 				ptr, ok := v.(*monsterDetail)
 				if !ok {
 					return errors.New("wrong type")
@@ -124,7 +124,7 @@ func TestRunMonsterTool(t *testing.T) {
 				*ptr = monsterDetail{} // zero value
 				return nil
 			},
-			mockList:   func(_ *http.Client, _ endpoint, v any, _ string) error { return nil },
+			mockList:   func(_ context.Context, _ endpoint, _ string, v any) error { return nil },
 			wantOutput: monsterToolOutput{Monster: &monsterDetail{}},
 			wantErr:    false,
 			wantErrMsg: "",
@@ -132,8 +132,8 @@ func TestRunMonsterTool(t *testing.T) {
 		{
 			name:       "tool error result",
 			input:      monsterToolInput{Name: ""},
-			mockByName: func(_ *http.Client, _ endpoint, name string, v any) error { return nil },
-			mockList: func(_ *http.Client, _ endpoint, v any, _ string) error {
+			mockByName: func(_ context.Context, _ endpoint, name string, v any) error { return nil },
+			mockList: func(_ context.Context, _ endpoint, _ string, v any) error {
 				return errors.New("test Go error")
 			},
 			wantOutput: monsterToolOutput{},
@@ -144,7 +144,7 @@ func TestRunMonsterTool(t *testing.T) {
 
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			res, err := runMonsterTool(tc.input, tc.mockByName, tc.mockList)
+			res, err := runMonsterTool(context.Background(), &mockDataSource{get: tc.mockByName, list: tc.mockList}, tc.input)
 			if tc.wantErr {
 				if err == nil {
 					t.Errorf("expected Go error, got nil")
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// maxNameSuggestions is the number of candidates listed when a name cannot be resolved unambiguously.
+const maxNameSuggestions = 5
+
+// maxCachedMisses bounds the names remembered as unresolvable per ruleset and endpoint. The remembered misses of
+// an endpoint are dropped when it is reached.
+const maxCachedMisses = 1000
+
+// apostrophes are the characters dropped from names rather than treated as word separators.
+const apostrophes = "'’‘ʼ`´"
+
+// stripDiacritics removes accents and other combining marks, e.g. "Mordenkainen’s Épée" -> "Mordenkainen’s Epee".
+func stripDiacritics(s string) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	out, _, err := transform.String(t, s)
+	if err != nil {
+		return s
+	}
+	return out
+}
+
+// normalizeName converts a display name into the API's index form: diacritics and apostrophes are removed,
+// letters lowercased and every other run of punctuation or whitespace replaced by a single hyphen.
+// For example "Tasha’s Hideous Laughter" becomes "tashas-hideous-laughter".
+func normalizeName(name string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range stripDiacritics(name) {
+		switch {
+		case strings.ContainsRune(apostrophes, r):
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if hyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			hyphen = false
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			hyphen = true
+		}
+	}
+	return b.String()
+}
+
+// nameVariants returns the normalized forms of a name worth matching against indexes.
+// Besides the full name it includes the name without a leading possessive, since the SRD drops
+// the owner from spell names: "Melf's Acid Arrow" is listed as "Acid Arrow".
+func nameVariants(name string) []string {
+	variants := []string{normalizeName(name)}
+	fields := strings.Fields(stripDiacritics(name))
+	if len(fields) > 1 {
+		first := strings.ToLower(fields[0])
+		for _, a := range apostrophes {
+			if strings.HasSuffix(first, string(a)+"s") || strings.HasSuffix(first, "s"+string(a)) {
+				variants = append(variants, normalizeName(strings.Join(fields[1:], " ")))
+				break
+			}
+		}
+	}
+	return variants
+}
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+// minPartialLength is the shortest name that is suggested for resources whose index merely contains it.
+const minPartialLength = 3
+
+// nameCandidate is an index scored against a requested name. Lower distances are better.
+// Partial is set when the index or name contains the requested name, e.g. "acid" in "acid-arrow".
+type nameCandidate struct {
+	ref      apiReference
+	distance int
+	partial  bool
+}
+
+// maxAutoResolveDistance is the largest edit distance at which a name is resolved without asking,
+// scaled with the name's length so short names need closer matches.
+func maxAutoResolveDistance(name string) int {
+	return max(1, min(3, len(name)/4))
+}
+
+// rankNameCandidates scores every reference against the name variants, best first.
+// Each reference is scored by its closest variant, comparing against both its index and its normalized name.
+func rankNameCandidates(refs []apiReference, variants []string) []nameCandidate {
+	candidates := make([]nameCandidate, 0, len(refs))
+	for _, r := range refs {
+		c := nameCandidate{ref: r, distance: -1}
+		for _, v := range variants {
+			for _, target := range []string{r.Index, normalizeName(r.Name)} {
+				if d := levenshtein(v, target); c.distance < 0 || d < c.distance {
+					c.distance = d
+				}
+				if len(v) >= minPartialLength && strings.Contains(target, v) {
+					c.partial = true
+				}
+			}
+		}
+		candidates = append(candidates, c)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].distance < candidates[j].distance })
+	return candidates
+}
+
+// nameCache is a dataSource that remembers, for each ruleset and endpoint, the reference list that name
+// resolution matches against and the names that resolved to nothing, so that a miss does not list the
+// whole endpoint again. Everything it remembers is dropped when the content of the wrapped source changes.
+type nameCache struct {
+	dataSource
+	mu      sync.Mutex
+	entries map[nameCacheKey]*nameCacheEntry
+}
+
+type nameCacheKey struct {
+	ruleset  ruleset
+	endpoint endpoint
+}
+
+// nameCacheEntry holds what is remembered about an endpoint, as of the source generation gen.
+type nameCacheEntry struct {
+	gen    uint64
+	refs   []apiReference
+	misses map[string]error
+}
+
+// newNameCache wraps src with a cache for name resolution.
+func newNameCache(src dataSource) *nameCache {
+	return &nameCache{dataSource: src, entries: map[nameCacheKey]*nameCacheEntry{}}
+}
+
+// generation returns the content generation of the wrapped source, always 0 for a source that does not change.
+func (c *nameCache) generation() uint64 {
+	if s, ok := c.dataSource.(changingSource); ok {
+		return s.generation()
+	}
+	return 0
+}
+
+// entry returns the current entry of the endpoint e in the ruleset selected by ctx, creating an empty one if
+// there is none or the wrapped source has changed since it was created. c.mu must be held.
+func (c *nameCache) entry(ctx context.Context, e endpoint) *nameCacheEntry {
+	key := nameCacheKey{rulesetFrom(ctx), e}
+	gen := c.generation()
+	if ent, ok := c.entries[key]; ok && ent.gen == gen {
+		return ent
+	}
+	ent := &nameCacheEntry{gen: gen, misses: map[string]error{}}
+	c.entries[key] = ent
+	return ent
+}
+
+// references returns the reference list of the endpoint e, listing it only if it is not remembered yet.
+// A failed listing is not remembered.
+func (c *nameCache) references(ctx context.Context, e endpoint) ([]apiReference, error) {
+	c.mu.Lock()
+	ent := c.entry(ctx, e)
+	refs := ent.refs
+	c.mu.Unlock()
+	if refs != nil {
+		return refs, nil
+	}
+	if err := c.List(ctx, e, "", &refs); err != nil {
+		return nil, err
+	}
+	if refs == nil {
+		refs = []apiReference{}
+	}
+	c.mu.Lock()
+	ent.refs = refs
+	c.mu.Unlock()
+	return refs, nil
+}
+
+// miss returns the error a name resolved to the last time it matched nothing in the endpoint e, or nil.
+func (c *nameCache) miss(ctx context.Context, e endpoint, name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.entry(ctx, e).misses[normalizeName(name)]
+}
+
+// recordMiss remembers that name matched nothing in the endpoint e.
+func (c *nameCache) recordMiss(ctx context.Context, e endpoint, name string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ent := c.entry(ctx, e)
+	if len(ent.misses) >= maxCachedMisses {
+		clear(ent.misses)
+	}
+	ent.misses[normalizeName(name)] = err
+}
+
+// listReferences returns the reference list name resolution matches against, from the cache if src has one.
+func listReferences(ctx context.Context, src dataSource, e endpoint) ([]apiReference, error) {
+	if c, ok := src.(*nameCache); ok {
+		return c.references(ctx, e)
+	}
+	var refs []apiReference
+	if err := src.List(ctx, e, "", &refs); err != nil {
+		return nil, err
+	}
+	return refs, nil
+}
+
+// resolveName finds the index of the endpoint's resource that best matches name. A unique closest match
+// within maxAutoResolveDistance is returned; otherwise the error wraps errNotFound and lists the closest
+// candidates, including resources that merely contain the name. Partial matches are never resolved automatically.
+func resolveName(ctx context.Context, src dataSource, e endpoint, name string) (string, error) {
+	refs, err := listReferences(ctx, src, e)
+	if err != nil {
+		return "", err
+	}
+	variants := nameVariants(name)
+	candidates := rankNameCandidates(refs, variants)
+	if len(candidates) == 0 {
+		return "", fmt.Errorf("%w: no %s named %q", errNotFound, e, name)
+	}
+	best := candidates[0]
+	unique := len(candidates) == 1 || candidates[1].distance > best.distance
+	if unique && best.distance <= maxAutoResolveDistance(variants[0]) {
+		return best.ref.Index, nil
+	}
+
+	limit := max(maxAutoResolveDistance(variants[0]), len(variants[0])/2)
+	var suggestions []string
+	for _, c := range candidates {
+		if len(suggestions) == maxNameSuggestions {
+			break
+		}
+		if c.distance <= limit || c.partial {
+			suggestions = append(suggestions, fmt.Sprintf("%s (%s)", c.ref.Name, c.ref.Index))
+		}
+	}
+	if len(suggestions) == 0 {
+		return "", fmt.Errorf("%w: no %s named %q", errNotFound, e, name)
+	}
+	return "", fmt.Errorf("%w: no %s named %q; did you mean: %s", errNotFound, e, name, strings.Join(suggestions, ", "))
+}
+
+// getByName fetches the resource whose index matches name, falling back to fuzzy resolution
+// against the endpoint's list when the normalized name is not an index. If src is a nameCache,
+// names that resolved to nothing before fail without a request.
+func getByName(ctx context.Context, src dataSource, e endpoint, name string, v any) error {
+	index := normalizeName(name)
+	if index == "" {
+		// An empty index would fetch the endpoint's list instead of a resource.
+		return fmt.Errorf("%w: no %s named %q", errNotFound, e, name)
+	}
+	cache, _ := src.(*nameCache)
+	if cache != nil {
+		if err := cache.miss(ctx, e, name); err != nil {
+			return err
+		}
+	}
+	err := src.Get(ctx, e, index, v)
+	if !errors.Is(err, errNotFound) {
+		return err
+	}
+	resolved, rerr := resolveName(ctx, src, e, name)
+	if rerr != nil {
+		if errors.Is(rerr, errNotFound) {
+			if cache != nil {
+				cache.recordMiss(ctx, e, name, rerr)
+			}
+			return rerr
+		}
+		return err
+	}
+	if resolved == index {
+		return err
+	}
+	logFrom(ctx).WithFields(logrus.Fields{"endpoint": e, "name": name, "index": resolved}).Info("Resolved name to closest match")
+	return src.Get(ctx, e, resolved, v)
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeName(t *testing.T) {
+	cases := map[string]string{
+		"Magic Missile":                  "magic-missile",
+		"Melf's Acid Arrow":              "melfs-acid-arrow",
+		"Tasha’s Hideous Laughter":       "tashas-hideous-laughter",
+		"  Potion of Healing (Greater) ": "potion-of-healing-greater",
+		"Épée":                           "epee",
+		"half-orc":                       "half-orc",
+		"Shield, +1":                     "shield-1",
+	}
+	for in, want := range cases {
+		if got := normalizeName(in); got != want {
+			t.Errorf("normalizeName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestNameVariants(t *testing.T) {
+	cases := map[string][]string{
+		"Melf's Acid Arrow":        {"melfs-acid-arrow", "acid-arrow"},
+		"Tasha’s Hideous Laughter": {"tashas-hideous-laughter", "hideous-laughter"},
+		"Magic Missile":            {"magic-missile"},
+		"Wish":                     {"wish"},
+	}
+	for in, want := range cases {
+		if got := nameVariants(in); strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("nameVariants(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"magic-missle", "magic-missile", 1},
+		{"kitten", "sitting", 3},
+		{"épée", "epee", 2},
+	}
+	for _, tc := range cases {
+		if got := levenshtein(tc.a, tc.b); got != tc.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestFetchByNameFuzzy(t *testing.T) {
+	spellRefs := []apiReference{
+		{Index: "acid-arrow", Name: "Acid Arrow"},
+		{Index: "acid-splash", Name: "Acid Splash"},
+		{Index: "hideous-laughter", Name: "Hideous Laughter"},
+		{Index: "magic-missile", Name: "Magic Missile"},
+		{Index: "cure-wounds", Name: "Cure Wounds"},
+		{Index: "mass-cure-wounds", Name: "Mass Cure Wounds"},
+	}
+	var listCalls int
+	src := &mockDataSource{
+		get: func(_ context.Context, _ endpoint, index string, v any) error {
+			for _, r := range spellRefs {
+				if r.Index == index {
+					*v.(*spellAPIResponse) = spellAPIResponse{Index: r.Index, Name: r.Name}
+					return nil
+				}
+			}
+			return errNotFound
+		},
+		list: func(_ context.Context, _ endpoint, _ string, v any) error {
+			listCalls++
+			return setJSON(v, spellRefs)
+		},
+	}
+
+	resolved := map[string]string{
+		"Magic Missile":            "magic-missile",
+		"magic-missle":             "magic-missile",
+		"Melf's Acid Arrow":        "acid-arrow",
+		"Tasha’s Hideous Laughter": "hideous-laughter",
+		"Cure Wound":               "cure-wounds",
+	}
+	for name, want := range resolved {
+		t.Run(name, func(t *testing.T) {
+			var spell spellAPIResponse
+			if err := fetchByName(context.Background(), src, spells, name, &spell); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if spell.Index != want {
+				t.Errorf("expected %q, got %q", want, spell.Index)
+			}
+		})
+	}
+
+	t.Run("exact index does not list", func(t *testing.T) {
+		listCalls = 0
+		var spell spellAPIResponse
+		if err := fetchByName(context.Background(), src, spells, "Acid Splash", &spell); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if listCalls != 0 {
+			t.Errorf("expected no list call for an exact index, got %d", listCalls)
+		}
+	})
+
+	t.Run("ambiguous name lists candidates", func(t *testing.T) {
+		var spell spellAPIResponse
+		err := fetchByName(context.Background(), src, spells, "acid", &spell)
+		if !errors.Is(err, errNotFound) {
+			t.Fatalf("expected errNotFound, got %v", err)
+		}
+		if !strings.Contains(err.Error(), "did you mean") || !strings.Contains(err.Error(), "Acid Arrow (acid-arrow)") {
+			t.Errorf("expected suggestions in error, got %q", err)
+		}
+	})
+
+	t.Run("unrelated name", func(t *testing.T) {
+		var spell spellAPIResponse
+		err := fetchByName(context.Background(), src, spells, "Power Word Kill", &spell)
+		if !errors.Is(err, errNotFound) || strings.Contains(err.Error(), "did you mean") {
+			t.Errorf("expected plain not found error, got %v", err)
+		}
+	})
+
+	t.Run("name without letters or digits", func(t *testing.T) {
+		listCalls = 0
+		for _, name := range []string{"'", "--", "  "} {
+			var spell spellAPIResponse
+			if err := fetchByName(context.Background(), src, spells, name, &spell); !errors.Is(err, errNotFound) {
+				t.Errorf("fetchByName(%q): expected errNotFound, got %v", name, err)
+			}
+		}
+		if listCalls != 0 {
+			t.Errorf("expected no list calls, got %d", listCalls)
+		}
+	})
+
+	t.Run("list error keeps original error", func(t *testing.T) {
+		failing := &mockDataSource{
+			get:  func(context.Context, endpoint, string, any) error { return errNotFound },
+			list: func(context.Context, endpoint, string, any) error { return errors.New("list failed") },
+		}
+		var spell spellAPIResponse
+		err := fetchByName(context.Background(), failing, spells, "magic-missle", &spell)
+		if !errors.Is(err, errNotFound) {
+			t.Errorf("expected errNotFound, got %v", err)
+		}
+	})
+}
+
+// changingMockSource is a mockDataSource whose content generation is set by the test.
+type changingMockSource struct {
+	mockDataSource
+	gen uint64
+}
+
+func (s *changingMockSource) generation() uint64 { return s.gen }
+
+func TestNameCache(t *testing.T) {
+	var gets, lists int
+	src := &changingMockSource{mockDataSource: mockDataSource{
+		get: func(_ context.Context, _ endpoint, index string, v any) error {
+			gets++
+			if index != "magic-missile" {
+				return errNotFound
+			}
+			*v.(*spellAPIResponse) = spellAPIResponse{Index: index}
+			return nil
+		},
+		list: func(_ context.Context, _ endpoint, _ string, v any) error {
+			lists++
+			return setJSON(v, []apiReference{{Index: "magic-missile", Name: "Magic Missile"}})
+		},
+	}}
+	cache := newNameCache(src)
+	fetch := func(ctx context.Context, name string) error {
+		var spell spellAPIResponse
+		return fetchByName(ctx, cache, spells, name, &spell)
+	}
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if err := fetch(ctx, "magic-missle"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if lists != 1 {
+		t.Errorf("expected the list to be fetched once for repeated misspellings, got %d", lists)
+	}
+
+	gets, lists = 0, 0
+	for i := 0; i < 3; i++ {
+		if err := fetch(ctx, "Power Word Kill"); !errors.Is(err, errNotFound) {
+			t.Fatalf("expected errNotFound, got %v", err)
+		}
+	}
+	if gets != 1 || lists != 0 {
+		t.Errorf("expected an unknown name to be looked up once, got %d gets and %d lists", gets, lists)
+	}
+
+	gets, lists = 0, 0
+	if err := fetch(withRuleset(ctx, ruleset2024), "Power Word Kill"); !errors.Is(err, errNotFound) {
+		t.Fatalf("expected errNotFound, got %v", err)
+	}
+	if gets != 1 || lists != 1 {
+		t.Errorf("expected the 2024 ruleset to be cached separately, got %d gets and %d lists", gets, lists)
+	}
+
+	gets, lists = 0, 0
+	src.gen++
+	if err := fetch(ctx, "Power Word Kill"); !errors.Is(err, errNotFound) {
+		t.Fatalf("expected errNotFound, got %v", err)
+	}
+	if gets != 1 || lists != 1 {
+		t.Errorf("expected a content change to drop the cache, got %d gets and %d lists", gets, lists)
+	}
+}
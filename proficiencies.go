@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// proficiencyToolInput defines the input structure for the proficiencies tool.
+type proficiencyToolInput struct {
+	Name string `json:"name" mcp:"description=The index of the proficiency to retrieve (e.g., 'skill-perception', 'longswords')."`
+}
+
+// proficiencyListAPIResponse defines the structure for a single proficiency in the list response.
+type proficiencyListAPIResponse struct {
+	Index string `json:"index"`
+	Name  string `json:"name"`
+	URL   string `json:"url"`
+}
+
+// proficiencyDetail defines the structure for a detailed proficiency response.
+// The API lists granting races and subraces together; Subraces is split out of Races after decoding.
+type proficiencyDetail struct {
+	Index     string         `json:"index"`
+	Name      string         `json:"name"`
+	Type      string         `json:"type"`
+	Classes   []apiReference `json:"classes"`
+	Races     []apiReference `json:"races"`
+	Subraces  []apiReference `json:"subraces,omitempty"`
+	Reference apiReference   `json:"reference"`
+	URL       string         `json:"url"`
+}
+
+// splitSubraces moves the subrace (or 2024 subspecies) references listed among Races into Subraces.
+func (d *proficiencyDetail) splitSubraces() {
+	var raceRefs, subraceRefs []apiReference
+	for _, r := range d.Races {
+		if e, _, ok := parseReferenceURL(r.URL); ok && e == subraces {
+			subraceRefs = append(subraceRefs, r)
+		} else {
+			raceRefs = append(raceRefs, r)
+		}
+	}
+	d.Races, d.Subraces = raceRefs, subraceRefs
+}
+
+// proficiencyToolOutput defines the output structure for the proficiencies tool.
+type proficiencyToolOutput struct {
+	Count       int                          `json:"count,omitempty"`
+	Results     []proficiencyListAPIResponse `json:"results,omitempty"`
+	Proficiency *proficiencyDetail           `json:"proficiency,omitempty"`
+}
+
+// fetchProficiencyByNameResult fetches a proficiency by index and returns an MCP tool result.
+func fetchProficiencyByNameResult(
+	ctx context.Context,
+	src dataSource,
+	input proficiencyToolInput,
+) (*mcp.CallToolResult, error) {
+	proficiency := &proficiencyDetail{}
+	err := fetchByName(ctx, src, proficiencies, input.Name, proficiency)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to fetch proficiency", err), err
+	}
+	proficiency.splitSubraces()
+	output := proficiencyToolOutput{Proficiency: proficiency}
+	jsonData, err := json.Marshal(output)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal proficiency output", err), err
+	}
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+// fetchProficiencyListResult fetches a list of proficiencies and returns an MCP tool result.
+func fetchProficiencyListResult(
+	ctx context.Context,
+	src dataSource,
+) (*mcp.CallToolResult, error) {
+	var results []proficiencyListAPIResponse
+	err := fetchList(ctx, src, proficiencies, &results, "")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to fetch proficiency list", err), err
+	}
+	output := proficiencyToolOutput{Count: len(results), Results: results}
+	jsonData, err := json.Marshal(output)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal proficiency list output", err), err
+	}
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+// runProficiencyTool executes the core logic for the proficiencies tool.
+func runProficiencyTool(ctx context.Context, src dataSource, input proficiencyToolInput) (*mcp.CallToolResult, error) {
+	if input.Name != "" {
+		return fetchProficiencyByNameResult(ctx, src, input)
+	}
+	return fetchProficiencyListResult(ctx, src)
+}
+
+// handleProficiencyTool returns the MCP handler for the proficiencies tool.
+func handleProficiencyTool(src dataSource) mcp.TypedToolHandlerFunc[proficiencyToolInput] {
+	return func(ctx context.Context, req mcp.CallToolRequest, input proficiencyToolInput) (*mcp.CallToolResult, error) {
+		return runProficiencyTool(ctx, src, input)
+	}
+}
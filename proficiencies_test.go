@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestRunProficiencyTool(t *testing.T) {
+	perception := proficiencyDetail{
+		Index:   "skill-perception",
+		Name:    "Skill: Perception",
+		Type:    "Skills",
+		Classes: []apiReference{{Index: "barbarian", URL: "/api/2014/classes/barbarian"}, {Index: "ranger", URL: "/api/2014/classes/ranger"}},
+		Races: []apiReference{
+			{Index: "elf", Name: "Elf", URL: "/api/2014/races/elf"},
+			{Index: "high-elf", Name: "High Elf", URL: "/api/2014/subraces/high-elf"},
+		},
+		Reference: apiReference{Index: "perception", URL: "/api/2014/skills/perception"},
+	}
+
+	t.Run("by name splits races and subraces", func(t *testing.T) {
+		src := &mockDataSource{get: func(_ context.Context, e endpoint, index string, v any) error {
+			if e != proficiencies || index != "skill-perception" {
+				return errNotFound
+			}
+			*v.(*proficiencyDetail) = perception
+			return nil
+		}}
+		res, err := runProficiencyTool(context.Background(), src, proficiencyToolInput{Name: "skill-perception"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		txt, _ := mcp.AsTextContent(res.Content[0])
+		var out proficiencyToolOutput
+		if err := json.Unmarshal([]byte(txt.Text), &out); err != nil {
+			t.Fatalf("unmarshal output: %v", err)
+		}
+		p := out.Proficiency
+		if p == nil || len(p.Classes) != 2 {
+			t.Fatalf("unexpected proficiency: %+v", p)
+		}
+		if len(p.Races) != 1 || p.Races[0].Index != "elf" {
+			t.Errorf("expected races [elf], got %+v", p.Races)
+		}
+		if len(p.Subraces) != 1 || p.Subraces[0].Index != "high-elf" {
+			t.Errorf("expected subraces [high-elf], got %+v", p.Subraces)
+		}
+	})
+
+	t.Run("by name error", func(t *testing.T) {
+		src := &mockDataSource{get: func(context.Context, endpoint, string, any) error { return errNotFound }}
+		res, err := runProficiencyTool(context.Background(), src, proficiencyToolInput{Name: "juggling"})
+		if !errors.Is(err, errNotFound) || res == nil || !res.IsError {
+			t.Errorf("expected not found error result, got %+v, %v", res, err)
+		}
+	})
+
+	t.Run("list", func(t *testing.T) {
+		src := &mockDataSource{list: func(_ context.Context, _ endpoint, _ string, v any) error {
+			*v.(*[]proficiencyListAPIResponse) = []proficiencyListAPIResponse{{Index: "skill-perception"}, {Index: "longswords"}}
+			return nil
+		}}
+		res, err := runProficiencyTool(context.Background(), src, proficiencyToolInput{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		txt, _ := mcp.AsTextContent(res.Content[0])
+		var out proficiencyToolOutput
+		if err := json.Unmarshal([]byte(txt.Text), &out); err != nil {
+			t.Fatalf("unmarshal output: %v", err)
+		}
+		if out.Count != 2 || len(out.Results) != 2 {
+			t.Errorf("expected 2 proficiencies, got %+v", out)
+		}
+	})
+}
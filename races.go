@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// raceToolInput defines the input structure for the races tool.
+type raceToolInput struct {
+	Name string `json:"name" mcp:"description=The index of the race to retrieve (e.g., 'elf', 'half-orc')."`
+}
+
+// raceListAPIResponse defines the structure for a single race in the list response.
+type raceListAPIResponse struct {
+	Index string `json:"index"`
+	Name  string `json:"name"`
+	URL   string `json:"url"`
+}
+
+// abilityBonus is a fixed increase to an ability score granted by a race or subrace.
+type abilityBonus struct {
+	AbilityScore apiReference `json:"ability_score"`
+	Bonus        int          `json:"bonus"`
+}
+
+// traitDetail defines the structure for a detailed racial trait response.
+type traitDetail struct {
+	Index       string     `json:"index"`
+	Name        string     `json:"name"`
+	Desc        paragraphs `json:"desc,omitempty"`
+	Description string     `json:"description,omitempty"`
+	URL         string     `json:"url"`
+}
+
+// subraceDetail defines the structure for a detailed subrace response.
+// RacialTraits are decoded as references and then resolved to full trait details.
+// A 2024 subspecies lists its traits under Traits, which normalize moves into RacialTraits.
+type subraceDetail struct {
+	Index                 string         `json:"index"`
+	Name                  string         `json:"name"`
+	Desc                  string         `json:"desc,omitempty"`
+	AbilityBonuses        []abilityBonus `json:"ability_bonuses,omitempty"`
+	StartingProficiencies []apiReference `json:"starting_proficiencies,omitempty"`
+	Languages             []apiReference `json:"languages,omitempty"`
+	LanguageOptions       *choice        `json:"language_options,omitempty"`
+	RacialTraits          []traitDetail  `json:"racial_traits,omitempty"`
+	Traits                []traitDetail  `json:"traits,omitempty"`
+	URL                   string         `json:"url"`
+}
+
+// normalize moves the traits of a 2024 subspecies into RacialTraits.
+func (s *subraceDetail) normalize() {
+	if len(s.RacialTraits) == 0 {
+		s.RacialTraits, s.Traits = s.Traits, nil
+	}
+}
+
+// raceDetail defines the structure for a detailed race response.
+// Traits and Subraces are decoded as references and then resolved to full details.
+// The 2024 ruleset serves races as species, with a creature Type, no ability bonuses, alignment or age,
+// and its subspecies under Subspecies, which normalize moves into Subraces.
+type raceDetail struct {
+	Index                      string          `json:"index"`
+	Name                       string          `json:"name"`
+	Type                       string          `json:"type,omitempty"`
+	Speed                      int             `json:"speed"`
+	AbilityBonuses             []abilityBonus  `json:"ability_bonuses,omitempty"`
+	AbilityBonusOptions        *choice         `json:"ability_bonus_options,omitempty"`
+	Alignment                  string          `json:"alignment,omitempty"`
+	Age                        string          `json:"age,omitempty"`
+	Size                       string          `json:"size"`
+	SizeDescription            string          `json:"size_description,omitempty"`
+	StartingProficiencies      []apiReference  `json:"starting_proficiencies,omitempty"`
+	StartingProficiencyOptions *choice         `json:"starting_proficiency_options,omitempty"`
+	Languages                  []apiReference  `json:"languages,omitempty"`
+	LanguageOptions            *choice         `json:"language_options,omitempty"`
+	LanguageDesc               string          `json:"language_desc,omitempty"`
+	Traits                     []traitDetail   `json:"traits"`
+	Subraces                   []subraceDetail `json:"subraces"`
+	Subspecies                 []subraceDetail `json:"subspecies,omitempty"`
+	URL                        string          `json:"url"`
+}
+
+// normalize moves the subspecies of a 2024 species into Subraces.
+func (r *raceDetail) normalize() {
+	if len(r.Subraces) == 0 {
+		r.Subraces, r.Subspecies = r.Subspecies, nil
+	}
+}
+
+// raceToolOutput defines the output structure for the races tool.
+type raceToolOutput struct {
+	Count   int                   `json:"count,omitempty"`
+	Results []raceListAPIResponse `json:"results,omitempty"`
+	Race    *raceDetail           `json:"race,omitempty"`
+}
+
+// resolveRace replaces the race's subrace and trait references with their full details.
+// Each trait is fetched once even when shared between the race and several subraces.
+func resolveRace(ctx context.Context, src dataSource, race *raceDetail) error {
+	race.normalize()
+	subraceIndexes := make([]string, len(race.Subraces))
+	for i, s := range race.Subraces {
+		subraceIndexes[i] = s.Index
+	}
+	subs, err := fetchAll[subraceDetail](ctx, src, subraces, subraceIndexes)
+	if err != nil {
+		return err
+	}
+	for i := range subs {
+		subs[i].normalize()
+	}
+	race.Subraces = subs
+
+	seen := map[string]bool{}
+	var traitIndexes []string
+	collect := func(traits []traitDetail) {
+		for _, t := range traits {
+			if !seen[t.Index] {
+				seen[t.Index] = true
+				traitIndexes = append(traitIndexes, t.Index)
+			}
+		}
+	}
+	collect(race.Traits)
+	for _, s := range race.Subraces {
+		collect(s.RacialTraits)
+	}
+	traitList, err := fetchAll[traitDetail](ctx, src, traits, traitIndexes)
+	if err != nil {
+		return err
+	}
+	byIndex := make(map[string]traitDetail, len(traitList))
+	for _, t := range traitList {
+		byIndex[t.Index] = t
+	}
+	resolve := func(traits []traitDetail) {
+		for i, t := range traits {
+			if full, ok := byIndex[t.Index]; ok {
+				traits[i] = full
+			}
+		}
+	}
+	resolve(race.Traits)
+	for _, s := range race.Subraces {
+		resolve(s.RacialTraits)
+	}
+	return nil
+}
+
+// fetchRaceByNameResult fetches a race by index, resolves its subraces and traits, and returns an MCP tool result.
+func fetchRaceByNameResult(
+	ctx context.Context,
+	src dataSource,
+	input raceToolInput,
+) (*mcp.CallToolResult, error) {
+	race := &raceDetail{}
+	err := fetchByName(ctx, src, races, input.Name, race)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to fetch race", err), err
+	}
+	if err := resolveRace(ctx, src, race); err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to resolve race subraces and traits", err), err
+	}
+	output := raceToolOutput{Race: race}
+	jsonData, err := json.Marshal(output)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal race output", err), err
+	}
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+// fetchRaceListResult fetches a list of races and returns an MCP tool result.
+func fetchRaceListResult(
+	ctx context.Context,
+	src dataSource,
+) (*mcp.CallToolResult, error) {
+	var results []raceListAPIResponse
+	err := fetchList(ctx, src, races, &results, "")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to fetch race list", err), err
+	}
+	output := raceToolOutput{Count: len(results), Results: results}
+	jsonData, err := json.Marshal(output)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal race list output", err), err
+	}
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+// runRaceTool executes the core logic for the races tool.
+func runRaceTool(ctx context.Context, src dataSource, input raceToolInput) (*mcp.CallToolResult, error) {
+	if input.Name != "" {
+		return fetchRaceByNameResult(ctx, src, input)
+	}
+	return fetchRaceListResult(ctx, src)
+}
+
+// handleRaceTool returns the MCP handler for the races tool.
+func handleRaceTool(src dataSource) mcp.TypedToolHandlerFunc[raceToolInput] {
+	return func(ctx context.Context, req mcp.CallToolRequest, input raceToolInput) (*mcp.CallToolResult, error) {
+		return runRaceTool(ctx, src, input)
+	}
+}
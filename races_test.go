@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestRunRaceTool(t *testing.T) {
+	race := raceDetail{
+		Index:          "elf",
+		Name:           "Elf",
+		Speed:          30,
+		Size:           "Medium",
+		AbilityBonuses: []abilityBonus{{AbilityScore: apiReference{Index: "dex", Name: "DEX"}, Bonus: 2}},
+		Languages:      []apiReference{{Index: "common"}, {Index: "elvish"}},
+		Traits:         []traitDetail{{Index: "darkvision", Name: "Darkvision"}, {Index: "fey-ancestry", Name: "Fey Ancestry"}},
+		Subraces:       []subraceDetail{{Index: "high-elf", Name: "High Elf"}},
+	}
+	highElf := subraceDetail{
+		Index:          "high-elf",
+		Name:           "High Elf",
+		AbilityBonuses: []abilityBonus{{AbilityScore: apiReference{Index: "int"}, Bonus: 1}},
+		RacialTraits:   []traitDetail{{Index: "elf-weapon-training"}, {Index: "darkvision"}},
+	}
+	traitDescs := map[string]string{
+		"darkvision":          "You can see in dim light within 60 feet of you as if it were bright light.",
+		"fey-ancestry":        "You have advantage on saving throws against being charmed.",
+		"elf-weapon-training": "You have proficiency with the longsword, shortsword, shortbow, and longbow.",
+	}
+	var (
+		mu           sync.Mutex
+		traitFetches []string
+	)
+	list := []raceListAPIResponse{{Index: "elf", Name: "Elf"}, {Index: "dwarf", Name: "Dwarf"}}
+
+	getFn := func(_ context.Context, e endpoint, index string, v any) error {
+		switch e {
+		case races:
+			if index != "elf" {
+				return errNotFound
+			}
+			r := race
+			r.Traits = append([]traitDetail(nil), race.Traits...)
+			r.Subraces = append([]subraceDetail(nil), race.Subraces...)
+			*v.(*raceDetail) = r
+		case subraces:
+			s := highElf
+			s.RacialTraits = append([]traitDetail(nil), highElf.RacialTraits...)
+			*v.(*subraceDetail) = s
+		case traits:
+			mu.Lock()
+			traitFetches = append(traitFetches, index)
+			mu.Unlock()
+			*v.(*traitDetail) = traitDetail{Index: index, Name: index, Desc: []string{traitDescs[index]}}
+		default:
+			return errors.New("unexpected endpoint")
+		}
+		return nil
+	}
+
+	t.Run("by name resolves subraces and traits", func(t *testing.T) {
+		traitFetches = nil
+		res, err := runRaceTool(context.Background(), &mockDataSource{get: getFn}, raceToolInput{Name: "Elf"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		txt, _ := mcp.AsTextContent(res.Content[0])
+		var out raceToolOutput
+		if err := json.Unmarshal([]byte(txt.Text), &out); err != nil {
+			t.Fatalf("unmarshal output: %v", err)
+		}
+		if out.Race == nil || out.Race.Name != "Elf" || out.Race.Speed != 30 {
+			t.Fatalf("unexpected race: %+v", out.Race)
+		}
+		for _, tr := range out.Race.Traits {
+			if len(tr.Desc) == 0 || tr.Desc[0] != traitDescs[tr.Index] {
+				t.Errorf("trait %q not resolved: %+v", tr.Index, tr)
+			}
+		}
+		if len(out.Race.Subraces) != 1 || len(out.Race.Subraces[0].AbilityBonuses) != 1 {
+			t.Fatalf("subrace not resolved: %+v", out.Race.Subraces)
+		}
+		for _, tr := range out.Race.Subraces[0].RacialTraits {
+			if len(tr.Desc) == 0 || tr.Desc[0] != traitDescs[tr.Index] {
+				t.Errorf("subrace trait %q not resolved: %+v", tr.Index, tr)
+			}
+		}
+		if len(traitFetches) != 3 {
+			t.Errorf("expected each trait to be fetched once, got %v", traitFetches)
+		}
+	})
+
+	t.Run("by name not found", func(t *testing.T) {
+		res, err := runRaceTool(context.Background(), &mockDataSource{get: getFn}, raceToolInput{Name: "Warforged"})
+		if !errors.Is(err, errNotFound) || res == nil || !res.IsError {
+			t.Errorf("expected not found error result, got %+v, %v", res, err)
+		}
+	})
+
+	t.Run("trait fetch error", func(t *testing.T) {
+		failing := func(ctx context.Context, e endpoint, index string, v any) error {
+			if e == traits {
+				return errors.New("trait fetch failed")
+			}
+			return getFn(ctx, e, index, v)
+		}
+		res, err := runRaceTool(context.Background(), &mockDataSource{get: failing}, raceToolInput{Name: "elf"})
+		if err == nil || res == nil || !res.IsError {
+			t.Fatalf("expected error result, got %+v, %v", res, err)
+		}
+		txt, _ := mcp.AsTextContent(res.Content[0])
+		if !strings.Contains(txt.Text, "trait fetch failed") {
+			t.Errorf("unexpected error text %q", txt.Text)
+		}
+	})
+
+	t.Run("list", func(t *testing.T) {
+		src := &mockDataSource{list: func(_ context.Context, _ endpoint, _ string, v any) error {
+			*v.(*[]raceListAPIResponse) = list
+			return nil
+		}}
+		res, err := runRaceTool(context.Background(), src, raceToolInput{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		txt, _ := mcp.AsTextContent(res.Content[0])
+		var out raceToolOutput
+		if err := json.Unmarshal([]byte(txt.Text), &out); err != nil {
+			t.Fatalf("unmarshal output: %v", err)
+		}
+		if out.Count != 2 || len(out.Results) != 2 {
+			t.Errorf("expected 2 races, got %+v", out)
+		}
+	})
+}
+
+func TestRunRaceTool2024Species(t *testing.T) {
+	docs := map[endpoint]map[string]string{
+		races: {"elf": `{"index":"elf","name":"Elf","type":"Humanoid","size":"Medium","speed":30,` +
+			`"traits":[{"index":"darkvision","name":"Darkvision","url":"/api/2024/traits/darkvision"}],` +
+			`"subspecies":[{"index":"drow","name":"Drow","url":"/api/2024/subspecies/drow"}],"url":"/api/2024/species/elf"}`},
+		subraces: {"drow": `{"index":"drow","name":"Drow","species":{"index":"elf","name":"Elf","url":"/api/2024/species/elf"},` +
+			`"traits":[{"index":"drow-magic","name":"Drow Magic","url":"/api/2024/traits/drow-magic"}],"url":"/api/2024/subspecies/drow"}`},
+		traits: {
+			"darkvision": `{"index":"darkvision","name":"Darkvision","description":"You have Darkvision with a range of 60 feet.","url":"/api/2024/traits/darkvision"}`,
+			"drow-magic": `{"index":"drow-magic","name":"Drow Magic","description":"You know the Dancing Lights cantrip.","url":"/api/2024/traits/drow-magic"}`,
+		},
+	}
+	src := &mockDataSource{get: func(_ context.Context, e endpoint, index string, v any) error {
+		doc, ok := docs[e][index]
+		if !ok {
+			return errNotFound
+		}
+		return json.Unmarshal([]byte(doc), v)
+	}}
+
+	res, err := runRaceTool(withRuleset(context.Background(), ruleset2024), src, raceToolInput{Name: "elf"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	txt, _ := mcp.AsTextContent(res.Content[0])
+	var out raceToolOutput
+	if err := json.Unmarshal([]byte(txt.Text), &out); err != nil {
+		t.Fatalf("unmarshal output: %v", err)
+	}
+	if out.Race == nil || out.Race.Type != "Humanoid" || len(out.Race.Subspecies) != 0 {
+		t.Fatalf("unexpected species: %+v", out.Race)
+	}
+	if len(out.Race.Traits) != 1 || out.Race.Traits[0].Description == "" {
+		t.Errorf("species trait not resolved: %+v", out.Race.Traits)
+	}
+	if len(out.Race.Subraces) != 1 || out.Race.Subraces[0].Name != "Drow" {
+		t.Fatalf("subspecies not moved into subraces: %+v", out.Race.Subraces)
+	}
+	drow := out.Race.Subraces[0]
+	if len(drow.Traits) != 0 || len(drow.RacialTraits) != 1 || drow.RacialTraits[0].Description != "You know the Dancing Lights cantrip." {
+		t.Errorf("subspecies traits not resolved: %+v", drow)
+	}
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// defaultRuleSearchLimit is the number of matching paragraphs returned when no limit is given.
+const defaultRuleSearchLimit = 10
+
+// ruleToolInput defines the input structure for the rules tool.
+type ruleToolInput struct {
+	Name  string `json:"name" mcp:"description=The name of the rule section (e.g., 'Ability Checks', 'cover') or rules chapter (e.g., 'combat') to retrieve."`
+	Query string `json:"query" mcp:"description=Keywords to search for across all rule sections (e.g., 'opportunity attack reach'). Returns the best matching paragraphs with their headings."`
+	Limit int    `json:"limit" mcp:"description=Maximum number of matching paragraphs to return for a query (default 10)."`
+}
+
+// ruleListAPIResponse defines the structure for a single rule or rule section in the list response.
+type ruleListAPIResponse struct {
+	Index string `json:"index"`
+	Name  string `json:"name"`
+	URL   string `json:"url"`
+}
+
+// ruleDetail defines the structure for a detailed rules chapter response.
+type ruleDetail struct {
+	Index       string         `json:"index"`
+	Name        string         `json:"name"`
+	Desc        string         `json:"desc"`
+	Subsections []apiReference `json:"subsections"`
+	URL         string         `json:"url"`
+}
+
+// ruleSectionDetail defines the structure for a detailed rule section response. Desc is a markdown document.
+type ruleSectionDetail struct {
+	Index string `json:"index"`
+	Name  string `json:"name"`
+	Desc  string `json:"desc"`
+	URL   string `json:"url"`
+}
+
+// ruleMatch is a paragraph of a rule section that matched a search query.
+type ruleMatch struct {
+	Section   apiReference `json:"section"`
+	Heading   string       `json:"heading"`
+	Paragraph string       `json:"paragraph"`
+	Score     int          `json:"score"`
+}
+
+// ruleToolOutput defines the output structure for the rules tool.
+type ruleToolOutput struct {
+	Count   int                   `json:"count,omitempty"`
+	Results []ruleListAPIResponse `json:"results,omitempty"`
+	Rule    *ruleDetail           `json:"rule,omitempty"`
+	Section *ruleSectionDetail    `json:"section,omitempty"`
+	Matches []ruleMatch           `json:"matches,omitempty"`
+}
+
+// searchTerms splits a query into lowercase keywords, dropping punctuation and duplicates.
+func searchTerms(query string) []string {
+	seen := map[string]bool{}
+	var terms []string
+	for _, f := range strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	}) {
+		if !seen[f] {
+			seen[f] = true
+			terms = append(terms, f)
+		}
+	}
+	return terms
+}
+
+// scoreParagraph ranks a paragraph against the search terms. Matching more distinct terms
+// outweighs repeated matches of one term, and terms found in the heading add a small bonus.
+// A score of zero means no term matched.
+func scoreParagraph(terms []string, heading, paragraph string) int {
+	text := strings.ToLower(paragraph)
+	head := strings.ToLower(heading)
+	score := 0
+	for _, t := range terms {
+		n := strings.Count(text, t)
+		if n == 0 {
+			continue
+		}
+		score += 10 + n
+		if strings.Contains(head, t) {
+			score += 3
+		}
+	}
+	return score
+}
+
+// searchRuleSections returns the paragraphs of the given sections that match the query, best first.
+// Each paragraph is reported with the nearest markdown heading above it.
+func searchRuleSections(sections []ruleSectionDetail, query string, limit int) []ruleMatch {
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return nil
+	}
+	var matches []ruleMatch
+	for _, s := range sections {
+		heading := s.Name
+		for _, para := range strings.Split(s.Desc, "\n\n") {
+			para = strings.TrimSpace(para)
+			if para == "" {
+				continue
+			}
+			if strings.HasPrefix(para, "#") && !strings.Contains(para, "\n") {
+				heading = strings.TrimSpace(strings.TrimLeft(para, "#"))
+				continue
+			}
+			if score := scoreParagraph(terms, heading, para); score > 0 {
+				matches = append(matches, ruleMatch{
+					Section:   apiReference{Index: s.Index, Name: s.Name, URL: s.URL},
+					Heading:   heading,
+					Paragraph: para,
+					Score:     score,
+				})
+			}
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// fetchRuleByNameResult fetches a rule section by name, falling back to a rules chapter, and returns an MCP tool result.
+func fetchRuleByNameResult(
+	ctx context.Context,
+	src dataSource,
+	input ruleToolInput,
+) (*mcp.CallToolResult, error) {
+	var output ruleToolOutput
+	section := &ruleSectionDetail{}
+	err := fetchByName(ctx, src, ruleSections, input.Name, section)
+	switch {
+	case err == nil:
+		output.Section = section
+	case errors.Is(err, errNotFound):
+		rule := &ruleDetail{}
+		if err := fetchByName(ctx, src, rules, input.Name, rule); err != nil {
+			return mcp.NewToolResultErrorFromErr("failed to fetch rule", err), err
+		}
+		output.Rule = rule
+	default:
+		return mcp.NewToolResultErrorFromErr("failed to fetch rule section", err), err
+	}
+	jsonData, err := json.Marshal(output)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal rule output", err), err
+	}
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+// fetchRuleSearchResult searches every rule section for the query and returns an MCP tool result.
+func fetchRuleSearchResult(
+	ctx context.Context,
+	src dataSource,
+	input ruleToolInput,
+) (*mcp.CallToolResult, error) {
+	if len(searchTerms(input.Query)) == 0 {
+		err := fmt.Errorf("query %q contains no searchable keywords", input.Query)
+		return mcp.NewToolResultErrorFromErr("invalid query", err), err
+	}
+	var refs []ruleListAPIResponse
+	if err := fetchList(ctx, src, ruleSections, &refs, ""); err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to fetch rule section list", err), err
+	}
+	indexes := make([]string, len(refs))
+	for i, r := range refs {
+		indexes[i] = r.Index
+	}
+	sections, err := fetchAll[ruleSectionDetail](ctx, src, ruleSections, indexes)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to fetch rule sections", err), err
+	}
+	limit := input.Limit
+	if limit <= 0 {
+		limit = defaultRuleSearchLimit
+	}
+	matches := searchRuleSections(sections, input.Query, limit)
+	output := ruleToolOutput{Count: len(matches), Matches: matches}
+	jsonData, err := json.Marshal(output)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal rule search output", err), err
+	}
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+// fetchRuleListResult fetches the list of rules chapters and returns an MCP tool result.
+func fetchRuleListResult(
+	ctx context.Context,
+	src dataSource,
+) (*mcp.CallToolResult, error) {
+	var results []ruleListAPIResponse
+	err := fetchList(ctx, src, rules, &results, "")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to fetch rule list", err), err
+	}
+	output := ruleToolOutput{Count: len(results), Results: results}
+	jsonData, err := json.Marshal(output)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal rule list output", err), err
+	}
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+// runRuleTool executes the core logic for the rules tool.
+func runRuleTool(ctx context.Context, src dataSource, input ruleToolInput) (*mcp.CallToolResult, error) {
+	if input.Name != "" {
+		return fetchRuleByNameResult(ctx, src, input)
+	}
+	if input.Query != "" {
+		return fetchRuleSearchResult(ctx, src, input)
+	}
+	return fetchRuleListResult(ctx, src)
+}
+
+// handleRuleTool returns the MCP handler for the rules tool.
+func handleRuleTool(src dataSource) mcp.TypedToolHandlerFunc[ruleToolInput] {
+	return func(ctx context.Context, req mcp.CallToolRequest, input ruleToolInput) (*mcp.CallToolResult, error) {
+		return runRuleTool(ctx, src, input)
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+var testRuleSections = map[string]ruleSectionDetail{
+	"cover": {
+		Index: "cover",
+		Name:  "Cover",
+		Desc: "## Cover\n\nWalls, trees, creatures, and other obstacles can provide cover during combat.\n\n" +
+			"A target with half cover has a +2 bonus to AC and Dexterity saving throws.\n\n" +
+			"### Total Cover\n\nA target with total cover can't be targeted directly by an attack or a spell.",
+	},
+	"making-an-attack": {
+		Index: "making-an-attack",
+		Name:  "Making an Attack",
+		Desc: "## Making an Attack\n\nWhether you're striking with a melee weapon or firing a weapon at range, an attack has a simple structure.\n\n" +
+			"### Opportunity Attacks\n\nYou can make an opportunity attack when a hostile creature that you can see moves out of your reach.",
+	},
+}
+
+func TestSearchRuleSections(t *testing.T) {
+	sections := []ruleSectionDetail{testRuleSections["cover"], testRuleSections["making-an-attack"]}
+
+	matches := searchRuleSections(sections, "opportunity attack reach", 10)
+	if len(matches) == 0 {
+		t.Fatalf("expected matches")
+	}
+	top := matches[0]
+	if top.Heading != "Opportunity Attacks" || top.Section.Index != "making-an-attack" {
+		t.Errorf("expected the opportunity attacks paragraph first, got %+v", top)
+	}
+	for i := 1; i < len(matches); i++ {
+		if matches[i].Score > matches[i-1].Score {
+			t.Errorf("matches not sorted by score: %+v", matches)
+		}
+	}
+
+	matches = searchRuleSections(sections, "Total cover!", 1)
+	if len(matches) != 1 || matches[0].Heading != "Total Cover" {
+		t.Errorf("expected total cover paragraph limited to 1 match, got %+v", matches)
+	}
+
+	if matches := searchRuleSections(sections, "grapple", 10); len(matches) != 0 {
+		t.Errorf("expected no matches, got %+v", matches)
+	}
+}
+
+func TestRunRuleTool(t *testing.T) {
+	combat := ruleDetail{
+		Index:       "combat",
+		Name:        "Combat",
+		Subsections: []apiReference{{Index: "cover"}, {Index: "making-an-attack"}},
+	}
+	src := &mockDataSource{
+		get: func(_ context.Context, e endpoint, index string, v any) error {
+			switch e {
+			case ruleSections:
+				s, ok := testRuleSections[index]
+				if !ok {
+					return errNotFound
+				}
+				*v.(*ruleSectionDetail) = s
+			case rules:
+				if index != "combat" {
+					return errNotFound
+				}
+				*v.(*ruleDetail) = combat
+			}
+			return nil
+		},
+		list: func(_ context.Context, e endpoint, _ string, v any) error {
+			if e == rules {
+				*v.(*[]ruleListAPIResponse) = []ruleListAPIResponse{{Index: "combat", Name: "Combat"}}
+				return nil
+			}
+			*v.(*[]ruleListAPIResponse) = []ruleListAPIResponse{{Index: "cover"}, {Index: "making-an-attack"}}
+			return nil
+		},
+	}
+	run := func(t *testing.T, input ruleToolInput) ruleToolOutput {
+		t.Helper()
+		res, err := runRuleTool(context.Background(), src, input)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		txt, _ := mcp.AsTextContent(res.Content[0])
+		var out ruleToolOutput
+		if err := json.Unmarshal([]byte(txt.Text), &out); err != nil {
+			t.Fatalf("unmarshal output: %v", err)
+		}
+		return out
+	}
+
+	t.Run("section by name", func(t *testing.T) {
+		if out := run(t, ruleToolInput{Name: "Making an Attack"}); out.Section == nil || out.Section.Index != "making-an-attack" {
+			t.Errorf("unexpected section: %+v", out.Section)
+		}
+	})
+
+	t.Run("falls back to rules chapter", func(t *testing.T) {
+		out := run(t, ruleToolInput{Name: "Combat"})
+		if out.Section != nil || out.Rule == nil || len(out.Rule.Subsections) != 2 {
+			t.Errorf("expected combat chapter, got %+v", out)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		res, err := runRuleTool(context.Background(), src, ruleToolInput{Name: "Downtime"})
+		if !errors.Is(err, errNotFound) || res == nil || !res.IsError {
+			t.Errorf("expected not found error result, got %+v, %v", res, err)
+		}
+	})
+
+	t.Run("search", func(t *testing.T) {
+		out := run(t, ruleToolInput{Query: "half cover", Limit: 2})
+		if out.Count == 0 || out.Count > 2 || out.Matches[0].Section.Index != "cover" {
+			t.Errorf("unexpected matches: %+v", out)
+		}
+	})
+
+	t.Run("search without keywords", func(t *testing.T) {
+		res, err := runRuleTool(context.Background(), src, ruleToolInput{Query: "?!"})
+		if err == nil || res == nil || !res.IsError {
+			t.Errorf("expected error result, got %+v, %v", res, err)
+		}
+	})
+
+	t.Run("list", func(t *testing.T) {
+		if out := run(t, ruleToolInput{}); out.Count != 1 || out.Results[0].Index != "combat" {
+			t.Errorf("unexpected list: %+v", out)
+		}
+	})
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ruleset identifies a version of the SRD served by the API under /api/<ruleset>.
+type ruleset string
+
+const (
+	ruleset2014 ruleset = "2014"
+	ruleset2024 ruleset = "2024"
+
+	// defaultRuleset is used when neither the server nor the tool call selects a ruleset.
+	defaultRuleset = ruleset2014
+	// rulesetParam is the argument, accepted by every tool, that overrides the server's ruleset for one call.
+	rulesetParam = "ruleset"
+)
+
+// allRulesets lists every ruleset the server can serve.
+var allRulesets = []ruleset{ruleset2014, ruleset2024}
+
+// rulesetOption is the schema property added to every tool for the ruleset argument.
+var rulesetOption = mcp.WithString(rulesetParam,
+	mcp.Description("The rules version to answer from: '2014' for the 2014 SRD or '2024' for the 2024 SRD. Defaults to the server's ruleset."),
+	mcp.Enum(string(ruleset2014), string(ruleset2024)),
+)
+
+// rulesetEndpointPaths maps endpoints that were renamed in a ruleset to their path in that ruleset.
+// The 2024 SRD calls races and subraces species and subspecies.
+var rulesetEndpointPaths = map[ruleset]map[endpoint]string{
+	ruleset2024: {
+		races:    "species",
+		subraces: "subspecies",
+	},
+}
+
+// parseRuleset returns the ruleset named by s.
+func parseRuleset(s string) (ruleset, error) {
+	for _, r := range allRulesets {
+		if strings.TrimSpace(s) == string(r) {
+			return r, nil
+		}
+	}
+	return "", fmt.Errorf("unknown ruleset %q: must be one of %s, %s", s, ruleset2014, ruleset2024)
+}
+
+// path returns the API path segment of e in the ruleset.
+func (r ruleset) path(e endpoint) string {
+	if p, ok := rulesetEndpointPaths[r][e]; ok {
+		return p
+	}
+	return string(e)
+}
+
+// endpointForPath returns the endpoint served at the API path segment p in any ruleset.
+func endpointForPath(p string) (endpoint, bool) {
+	for _, e := range allEndpoints {
+		if p == string(e) {
+			return e, true
+		}
+	}
+	for _, paths := range rulesetEndpointPaths {
+		for e, alias := range paths {
+			if p == alias {
+				return e, true
+			}
+		}
+	}
+	return "", false
+}
+
+// rulesetKey is the context key under which the ruleset of a tool call is stored.
+type rulesetKey struct{}
+
+// withRuleset returns a copy of ctx that selects the ruleset r for data source requests.
+func withRuleset(ctx context.Context, r ruleset) context.Context {
+	return context.WithValue(ctx, rulesetKey{}, r)
+}
+
+// rulesetFrom returns the ruleset selected in ctx, or defaultRuleset if none was selected.
+func rulesetFrom(ctx context.Context) ruleset {
+	if r, ok := ctx.Value(rulesetKey{}).(ruleset); ok {
+		return r
+	}
+	return defaultRuleset
+}
+
+// rulesetMiddleware selects the ruleset for each tool call, from the call's ruleset argument or else
+// the server-wide default, and tags the result with it. JSON object results gain a "ruleset" field
+// and every result carries it in its metadata.
+func rulesetMiddleware(def ruleset) server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			r := def
+			if name := req.GetString(rulesetParam, ""); name != "" {
+				parsed, err := parseRuleset(name)
+				if err != nil {
+					return mcp.NewToolResultErrorFromErr("invalid ruleset", err), err
+				}
+				r = parsed
+			}
+			result, err := next(withRuleset(ctx, r), req)
+			if result == nil {
+				return result, err
+			}
+			if result.Meta == nil {
+				result.Meta = map[string]any{}
+			}
+			result.Meta[rulesetParam] = string(r)
+			if result.IsError || len(result.Content) == 0 {
+				return result, err
+			}
+			text, ok := mcp.AsTextContent(result.Content[0])
+			if !ok {
+				return result, err
+			}
+			var fields map[string]json.RawMessage
+			if json.Unmarshal([]byte(text.Text), &fields) != nil || fields == nil {
+				return result, err
+			}
+			fields[rulesetParam], _ = json.Marshal(r)
+			data, merr := json.Marshal(fields)
+			if merr != nil {
+				return result, err
+			}
+			result.Content[0] = mcp.NewTextContent(string(data))
+			return result, err
+		}
+	}
+}
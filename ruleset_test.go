@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestParseRuleset(t *testing.T) {
+	for _, name := range []string{"2014", "2024", " 2024 "} {
+		if _, err := parseRuleset(name); err != nil {
+			t.Errorf("parseRuleset(%q): unexpected error: %v", name, err)
+		}
+	}
+	if _, err := parseRuleset("2020"); err == nil {
+		t.Error("expected an error for an unknown ruleset")
+	}
+}
+
+func TestRulesetPaths(t *testing.T) {
+	if got := ruleset2014.path(races); got != "races" {
+		t.Errorf("expected 2014 races path 'races', got %q", got)
+	}
+	if got := ruleset2024.path(races); got != "species" {
+		t.Errorf("expected 2024 races path 'species', got %q", got)
+	}
+	if got := ruleset2024.path(subraces); got != "subspecies" {
+		t.Errorf("expected 2024 subraces path 'subspecies', got %q", got)
+	}
+	if got := ruleset2024.path(spells); got != "spells" {
+		t.Errorf("expected unchanged spells path, got %q", got)
+	}
+	for p, want := range map[string]endpoint{"spells": spells, "species": races, "subspecies": subraces} {
+		if e, ok := endpointForPath(p); !ok || e != want {
+			t.Errorf("endpointForPath(%q) = %q, %v; want %q", p, e, ok, want)
+		}
+	}
+	if _, ok := endpointForPath("vehicles"); ok {
+		t.Error("expected unknown path not to resolve")
+	}
+	if r := rulesetFrom(context.Background()); r != defaultRuleset {
+		t.Errorf("expected default ruleset without a selection, got %q", r)
+	}
+}
+
+func TestAPIDataSource_Ruleset(t *testing.T) {
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.Write([]byte(`{"index":"elf","name":"Elf","url":"/api/2024/species/elf"}`))
+	}))
+	t.Cleanup(srv.Close)
+	src := newAPIDataSource(srv.Client(), srv.URL)
+
+	var race raceDetail
+	if err := src.Get(context.Background(), races, "elf", &race); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := src.Get(withRuleset(context.Background(), ruleset2024), races, "elf", &race); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"/2014/races/elf", "/2024/species/elf"}
+	if len(paths) != 2 || paths[0] != want[0] || paths[1] != want[1] {
+		t.Errorf("expected requests to %v, got %v", want, paths)
+	}
+}
+
+func TestRulesetMiddleware(t *testing.T) {
+	var seen ruleset
+	handler := rulesetMiddleware(ruleset2014)(func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		seen = rulesetFrom(ctx)
+		return mcp.NewToolResultText(`{"count":1}`), nil
+	})
+	call := func(args map[string]any) (*mcp.CallToolResult, error) {
+		req := mcp.CallToolRequest{}
+		req.Params.Arguments = args
+		return handler(context.Background(), req)
+	}
+
+	for _, tc := range []struct {
+		name string
+		args map[string]any
+		want ruleset
+	}{
+		{"server default", nil, ruleset2014},
+		{"per-call override", map[string]any{"ruleset": "2024"}, ruleset2024},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := call(tc.args)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if seen != tc.want {
+				t.Errorf("expected handler to see ruleset %q, got %q", tc.want, seen)
+			}
+			if res.Meta["ruleset"] != string(tc.want) {
+				t.Errorf("expected ruleset %q in meta, got %v", tc.want, res.Meta)
+			}
+			txt, _ := mcp.AsTextContent(res.Content[0])
+			var out map[string]any
+			if err := json.Unmarshal([]byte(txt.Text), &out); err != nil {
+				t.Fatalf("unmarshal output: %v", err)
+			}
+			if out["ruleset"] != string(tc.want) || out["count"] != float64(1) {
+				t.Errorf("expected output tagged with ruleset %q, got %v", tc.want, out)
+			}
+		})
+	}
+
+	t.Run("invalid ruleset", func(t *testing.T) {
+		res, err := call(map[string]any{"ruleset": "5.5"})
+		if err == nil || res == nil || !res.IsError {
+			t.Errorf("expected an error result, got %+v, %v", res, err)
+		}
+	})
+}
@@ -0,0 +1,369 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// searchToolName is the name under which the search tool is registered.
+	searchToolName = "search"
+	// defaultSearchLimit and maxSearchLimit bound the number of hits returned by the search tool.
+	defaultSearchLimit = 10
+	maxSearchLimit     = 50
+	// snippetLength is the maximum length of a hit's snippet, in characters.
+	snippetLength = 160
+)
+
+// searchToolInput defines the input structure for the search tool.
+type searchToolInput struct {
+	Query      string   `json:"query" mcp:"description=The name or part of a name to search for across every category (e.g., 'shield')."`
+	Categories []string `json:"categories" mcp:"description=Only search these categories (e.g., ['spells', 'equipment', 'magic-items']). Searches all categories if omitted."`
+	Limit      int      `json:"limit" mcp:"description=Maximum number of hits to return (default 10, maximum 50)."`
+}
+
+// searchEntry is a single resource in the search index.
+type searchEntry struct {
+	Category endpoint
+	Index    string
+	Name     string
+	URL      string
+	Source   string
+}
+
+// searchHit is a ranked search result.
+type searchHit struct {
+	Category endpoint `json:"category"`
+	Index    string   `json:"index"`
+	Name     string   `json:"name"`
+	URL      string   `json:"url"`
+	Source   string   `json:"source,omitempty"`
+	Score    int      `json:"score"`
+	Snippet  string   `json:"snippet,omitempty"`
+}
+
+// searchToolOutput defines the output structure for the search tool.
+type searchToolOutput struct {
+	Count   int         `json:"count"`
+	Results []searchHit `json:"results"`
+}
+
+// searchIndex holds the names of every resource of every endpoint, separately for each ruleset.
+// Each ruleset's index is built from the endpoint lists on first use and kept until the source's
+// content changes; a failed build is not cached so the next search retries it.
+type searchIndex struct {
+	src      dataSource
+	mu       sync.Mutex
+	entries  map[ruleset][]searchEntry
+	built    map[ruleset]uint64
+	building map[ruleset]*indexBuild
+}
+
+// indexBuild is a build of a ruleset's index in progress. done is closed once entries and err are set.
+type indexBuild struct {
+	gen     uint64
+	done    chan struct{}
+	entries []searchEntry
+	err     error
+}
+
+// changingSource is implemented by data sources whose content can change while the server runs.
+// generation returns a counter that increases with every change.
+type changingSource interface {
+	generation() uint64
+}
+
+// newSearchIndex creates an empty search index over src.
+func newSearchIndex(src dataSource) *searchIndex {
+	return &searchIndex{
+		src:      src,
+		entries:  map[ruleset][]searchEntry{},
+		built:    map[ruleset]uint64{},
+		building: map[ruleset]*indexBuild{},
+	}
+}
+
+// generation returns the content generation of the indexed source, always 0 for a source that does not change.
+func (idx *searchIndex) generation() uint64 {
+	if c, ok := idx.src.(changingSource); ok {
+		return c.generation()
+	}
+	return 0
+}
+
+// load returns the index entries of the ruleset selected by ctx, building its index if it has not been built
+// yet or the source has changed since. The lock is not held while the endpoints are listed: concurrent
+// searches of the same ruleset wait for a single build, and searches of other rulesets are not held up.
+// A build carries on if the search that started it is cancelled, so that the searches waiting on it can use it.
+func (idx *searchIndex) load(ctx context.Context) ([]searchEntry, error) {
+	r := rulesetFrom(ctx)
+	gen := idx.generation()
+	idx.mu.Lock()
+	if entries, ok := idx.entries[r]; ok && idx.built[r] == gen {
+		idx.mu.Unlock()
+		return entries, nil
+	}
+	b := idx.building[r]
+	if b == nil || b.gen != gen {
+		b = &indexBuild{gen: gen, done: make(chan struct{})}
+		idx.building[r] = b
+		go idx.build(context.WithoutCancel(ctx), r, b)
+	}
+	idx.mu.Unlock()
+
+	select {
+	case <-b.done:
+		return b.entries, b.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// build lists every endpoint of the ruleset r into the in-progress build b. The result is stored in the index
+// unless a build for a later generation of the source has started since.
+func (idx *searchIndex) build(ctx context.Context, r ruleset, b *indexBuild) {
+	defer close(b.done)
+	start := time.Now()
+	lists, err := fetchEndpointLists(ctx, idx.src, allEndpoints)
+	if err != nil {
+		b.err = err
+		idx.finishBuild(r, b)
+		return
+	}
+	b.entries = []searchEntry{}
+	for i, e := range allEndpoints {
+		for _, ref := range lists[i] {
+			b.entries = append(b.entries, searchEntry{Category: e, Index: ref.Index, Name: ref.Name, URL: ref.URL, Source: ref.Source})
+		}
+	}
+	idx.finishBuild(r, b)
+	logFrom(ctx).WithFields(logrus.Fields{"ruleset": r, "entries": len(b.entries), "duration": time.Since(start)}).Info("Built search index")
+}
+
+// finishBuild removes the finished build b of the ruleset r from the builds in progress and, if it succeeded,
+// stores its entries in the index.
+func (idx *searchIndex) finishBuild(r ruleset, b *indexBuild) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if idx.building[r] != b {
+		return
+	}
+	delete(idx.building, r)
+	if b.err == nil {
+		idx.entries[r] = b.entries
+		idx.built[r] = b.gen
+	}
+}
+
+// fetchEndpointLists lists the given endpoints concurrently and returns their references in the same order.
+// Endpoints that the selected ruleset does not have are returned as empty lists.
+func fetchEndpointLists(ctx context.Context, src dataSource, endpoints []endpoint) ([][]apiReference, error) {
+	lists := make([][]apiReference, len(endpoints))
+	err := forEachConcurrently(ctx, len(endpoints), maxConcurrentFetches, func(ctx context.Context, i int) error {
+		e := endpoints[i]
+		err := src.List(ctx, e, "", &lists[i])
+		if errors.Is(err, errNotFound) {
+			logFrom(ctx).WithFields(logrus.Fields{"endpoint": e, "ruleset": rulesetFrom(ctx)}).Debug("Endpoint not available in ruleset")
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("list %s: %w", e, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return lists, nil
+}
+
+// searchCategories resolves the requested category names, as the ruleset r calls them, to endpoints.
+// No names selects every endpoint.
+func searchCategories(r ruleset, names []string) (map[endpoint]bool, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	known := make(map[string]endpoint, len(allEndpoints))
+	paths := make([]string, len(allEndpoints))
+	for i, e := range allEndpoints {
+		paths[i] = r.path(e)
+		known[paths[i]] = e
+	}
+	selected := make(map[endpoint]bool, len(names))
+	for _, n := range names {
+		e, ok := known[toKebabCase(n)]
+		if !ok {
+			return nil, fmt.Errorf("unknown category %q in the %s ruleset: must be one of %v", n, r, paths)
+		}
+		selected[e] = true
+	}
+	return selected, nil
+}
+
+// scoreName ranks how well a resource name or index matches the query.
+// Exact matches rank highest, then prefixes of the whole name, then prefixes of a word in the name,
+// then substrings, then names containing every query term. A score of zero means no match.
+func scoreName(query, name, index string) int {
+	q := strings.ToLower(strings.TrimSpace(query))
+	n := strings.ToLower(name)
+	switch {
+	case q == "":
+		return 0
+	case n == q || index == toKebabCase(q):
+		return 100
+	case strings.HasPrefix(n, q):
+		return 75
+	}
+	for _, w := range strings.FieldsFunc(n, func(r rune) bool { return r == ' ' || r == '-' || r == ',' || r == '(' || r == '/' }) {
+		if strings.HasPrefix(w, q) {
+			return 50
+		}
+	}
+	if strings.Contains(n, q) {
+		return 30
+	}
+	terms := searchTerms(q)
+	if len(terms) < 2 {
+		return 0
+	}
+	for _, t := range terms {
+		if !strings.Contains(n, t) {
+			return 0
+		}
+	}
+	return 20
+}
+
+// rankSearchEntries returns the entries matching the query, best first, restricted to the selected categories if any.
+// Ties are broken by shorter names, then by category order.
+func rankSearchEntries(entries []searchEntry, query string, categories map[endpoint]bool, limit int) []searchHit {
+	var hits []searchHit
+	for _, e := range entries {
+		if categories != nil && !categories[e.Category] {
+			continue
+		}
+		if score := scoreName(query, e.Name, e.Index); score > 0 {
+			hits = append(hits, searchHit{Category: e.Category, Index: e.Index, Name: e.Name, URL: e.URL, Source: e.Source, Score: score})
+		}
+	}
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return len(hits[i].Name) < len(hits[j].Name)
+	})
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits
+}
+
+// snippetOf extracts a short snippet from a resource's description, which the API returns
+// either as a string or as a list of paragraphs, under "desc" or, in the 2024 ruleset, "description".
+// It returns "" if the resource has no description.
+func snippetOf(raw json.RawMessage) string {
+	var detail struct {
+		Desc        json.RawMessage `json:"desc"`
+		Description json.RawMessage `json:"description"`
+	}
+	if err := json.Unmarshal(raw, &detail); err != nil {
+		return ""
+	}
+	if len(detail.Desc) == 0 {
+		detail.Desc = detail.Description
+	}
+	if len(detail.Desc) == 0 {
+		return ""
+	}
+	var text string
+	var paragraphs []string
+	if err := json.Unmarshal(detail.Desc, &paragraphs); err == nil {
+		if len(paragraphs) > 0 {
+			text = paragraphs[0]
+		}
+	} else if err := json.Unmarshal(detail.Desc, &text); err != nil {
+		return ""
+	}
+	text = strings.Join(strings.Fields(strings.TrimLeft(text, "# ")), " ")
+	if utf8.RuneCountInString(text) <= snippetLength {
+		return text
+	}
+	cut := string([]rune(text)[:snippetLength])
+	if i := strings.LastIndexByte(cut, ' '); i > 0 {
+		cut = cut[:i]
+	}
+	return cut + "…"
+}
+
+// addSnippets fetches the details of each hit concurrently and fills in its snippet.
+// Hits whose details cannot be fetched are returned without a snippet.
+func addSnippets(ctx context.Context, src dataSource, hits []searchHit) {
+	forEachConcurrently(ctx, len(hits), maxConcurrentFetches, func(ctx context.Context, i int) error {
+		var raw json.RawMessage
+		if err := src.Get(ctx, hits[i].Category, hits[i].Index, &raw); err != nil {
+			logFrom(ctx).WithError(err).WithFields(logrus.Fields{"category": hits[i].Category, "index": hits[i].Index}).Warn("Failed to fetch search hit details")
+			return nil
+		}
+		hits[i].Snippet = snippetOf(raw)
+		return nil
+	})
+}
+
+// runSearchTool executes the core logic for the search tool.
+func runSearchTool(ctx context.Context, idx *searchIndex, input searchToolInput) (*mcp.CallToolResult, error) {
+	if strings.TrimSpace(input.Query) == "" {
+		err := fmt.Errorf("query must not be empty")
+		return mcp.NewToolResultErrorFromErr("invalid query", err), err
+	}
+	categories, err := searchCategories(rulesetFrom(ctx), input.Categories)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("invalid categories", err), err
+	}
+	limit := input.Limit
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	if limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+	entries, err := idx.load(ctx)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to build search index", err), err
+	}
+	hits := rankSearchEntries(entries, input.Query, categories, limit)
+	addSnippets(ctx, idx.src, hits)
+	if err := ctx.Err(); err != nil {
+		return mcp.NewToolResultErrorFromErr("search cancelled", err), err
+	}
+	// Report categories by the names the ruleset uses, as accepted by the categories argument.
+	for i := range hits {
+		hits[i].Category = endpoint(rulesetFrom(ctx).path(hits[i].Category))
+	}
+	output := searchToolOutput{Count: len(hits), Results: hits}
+	if output.Results == nil {
+		output.Results = []searchHit{}
+	}
+	jsonData, err := json.Marshal(output)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal search output", err), err
+	}
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+// handleSearchTool returns the MCP handler for the search tool. The index is shared by every call.
+func handleSearchTool(src dataSource) mcp.TypedToolHandlerFunc[searchToolInput] {
+	idx := newSearchIndex(src)
+	return func(ctx context.Context, req mcp.CallToolRequest, input searchToolInput) (*mcp.CallToolResult, error) {
+		return runSearchTool(ctx, idx, input)
+	}
+}
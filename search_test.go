@@ -0,0 +1,295 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestScoreName(t *testing.T) {
+	cases := []struct {
+		query, name, index string
+		want               int
+	}{
+		{"shield", "Shield", "shield", 100},
+		{"Magic Missile", "Magic Missile", "magic-missile", 100},
+		{"shield", "Shield of Faith", "shield-of-faith", 75},
+		{"shield", "Spellguard Shield", "spellguard-shield", 50},
+		{"ield", "Shield", "shield", 30},
+		{"faith shield", "Shield of Faith", "shield-of-faith", 20},
+		{"sword", "Shield", "shield", 0},
+		{" ", "Shield", "shield", 0},
+	}
+	for _, tc := range cases {
+		if got := scoreName(tc.query, tc.name, tc.index); got != tc.want {
+			t.Errorf("scoreName(%q, %q) = %d, want %d", tc.query, tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestSnippetOf(t *testing.T) {
+	long := strings.Repeat("word ", 60)
+	cases := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{"paragraph list", `{"desc":["An invisible barrier of magical force appears.","Second."]}`, "An invisible barrier of magical force appears."},
+		{"markdown string", `{"desc":"## Cover\n\nWalls and trees"}`, "Cover Walls and trees"},
+		{"no desc", `{"name":"Goblin"}`, ""},
+		{"truncated", `{"desc":["` + long + `"]}`, strings.TrimSpace(strings.Repeat("word ", 32)) + "…"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := snippetOf(json.RawMessage(tc.raw)); got != tc.want {
+				t.Errorf("snippetOf() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRunSearchTool(t *testing.T) {
+	lists := map[endpoint][]apiReference{
+		spells:     {{Index: "shield", Name: "Shield"}, {Index: "shield-of-faith", Name: "Shield of Faith"}, {Index: "fireball", Name: "Fireball"}},
+		equipment:  {{Index: "shield", Name: "Shield"}, {Index: "longsword", Name: "Longsword"}},
+		magicItems: {{Index: "spellguard-shield", Name: "Spellguard Shield"}},
+	}
+	var listCalls atomic.Int32
+	src := &mockDataSource{
+		list: func(_ context.Context, e endpoint, _ string, v any) error {
+			listCalls.Add(1)
+			*v.(*[]apiReference) = lists[e]
+			return nil
+		},
+		get: func(_ context.Context, e endpoint, index string, v any) error {
+			if e == equipment {
+				return errNotFound
+			}
+			*v.(*json.RawMessage) = json.RawMessage(`{"desc":["` + index + ` description"]}`)
+			return nil
+		},
+	}
+	idx := newSearchIndex(src)
+	run := func(t *testing.T, input searchToolInput) searchToolOutput {
+		t.Helper()
+		res, err := runSearchTool(context.Background(), idx, input)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		txt, _ := mcp.AsTextContent(res.Content[0])
+		var out searchToolOutput
+		if err := json.Unmarshal([]byte(txt.Text), &out); err != nil {
+			t.Fatalf("unmarshal output: %v", err)
+		}
+		return out
+	}
+
+	t.Run("ranks hits across categories", func(t *testing.T) {
+		out := run(t, searchToolInput{Query: "shield"})
+		if out.Count != 4 {
+			t.Fatalf("expected 4 hits, got %+v", out)
+		}
+		if out.Results[0].Score != 100 || out.Results[1].Score != 100 {
+			t.Errorf("expected exact matches first, got %+v", out.Results)
+		}
+		if out.Results[3].Index != "spellguard-shield" {
+			t.Errorf("expected word-prefix match last, got %+v", out.Results[3])
+		}
+		for _, h := range out.Results {
+			if h.Category == equipment && h.Snippet != "" {
+				t.Errorf("expected no snippet when details fail, got %q", h.Snippet)
+			}
+			if h.Category == spells && h.Snippet != h.Index+" description" {
+				t.Errorf("unexpected snippet for %s: %q", h.Index, h.Snippet)
+			}
+		}
+	})
+
+	t.Run("restricts categories", func(t *testing.T) {
+		out := run(t, searchToolInput{Query: "shield", Categories: []string{"equipment", "Magic Items"}})
+		if out.Count != 2 {
+			t.Fatalf("expected 2 hits, got %+v", out)
+		}
+		for _, h := range out.Results {
+			if h.Category == spells {
+				t.Errorf("unexpected spell hit %+v", h)
+			}
+		}
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		if out := run(t, searchToolInput{Query: "shield", Limit: 1}); out.Count != 1 {
+			t.Errorf("expected 1 hit, got %+v", out)
+		}
+	})
+
+	t.Run("no hits", func(t *testing.T) {
+		if out := run(t, searchToolInput{Query: "beholder"}); out.Count != 0 || out.Results == nil {
+			t.Errorf("expected empty results, got %+v", out)
+		}
+	})
+
+	t.Run("index is built once", func(t *testing.T) {
+		if got := int(listCalls.Load()); got != len(allEndpoints) {
+			t.Errorf("expected %d list calls, got %d", len(allEndpoints), got)
+		}
+	})
+
+	t.Run("unknown category", func(t *testing.T) {
+		res, err := runSearchTool(context.Background(), idx, searchToolInput{Query: "shield", Categories: []string{"vehicles"}})
+		if err == nil || res == nil || !res.IsError {
+			t.Errorf("expected error result, got %+v, %v", res, err)
+		}
+	})
+
+	t.Run("empty query", func(t *testing.T) {
+		res, err := runSearchTool(context.Background(), idx, searchToolInput{})
+		if err == nil || res == nil || !res.IsError {
+			t.Errorf("expected error result, got %+v, %v", res, err)
+		}
+	})
+}
+
+func TestSearchIndexRetriesFailedBuild(t *testing.T) {
+	var fail atomic.Bool
+	fail.Store(true)
+	src := &mockDataSource{list: func(_ context.Context, e endpoint, _ string, v any) error {
+		if fail.Load() && e == monsters {
+			return errors.New("upstream down")
+		}
+		*v.(*[]apiReference) = []apiReference{{Index: "x-" + string(e), Name: "X"}}
+		return nil
+	}}
+	idx := newSearchIndex(src)
+	if _, err := idx.load(context.Background()); err == nil {
+		t.Fatalf("expected build error")
+	}
+	fail.Store(false)
+	entries, err := idx.load(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != len(allEndpoints) {
+		t.Errorf("expected %d entries, got %d", len(allEndpoints), len(entries))
+	}
+}
+
+func TestSearchIndexPerRuleset(t *testing.T) {
+	src := &mockDataSource{list: func(ctx context.Context, e endpoint, _ string, v any) error {
+		if rulesetFrom(ctx) == ruleset2024 && e != races {
+			return errNotFound
+		}
+		*v.(*[]apiReference) = []apiReference{{Index: "x-" + string(e), Name: "X"}}
+		return nil
+	}}
+	idx := newSearchIndex(src)
+	entries, err := idx.load(withRuleset(context.Background(), ruleset2024))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Category != races {
+		t.Errorf("expected only the species entry in the 2024 index, got %+v", entries)
+	}
+	entries, err = idx.load(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != len(allEndpoints) {
+		t.Errorf("expected %d entries in the 2014 index, got %d", len(allEndpoints), len(entries))
+	}
+}
+
+func TestSearchIndexBuildDoesNotBlockOtherRulesets(t *testing.T) {
+	release := make(chan struct{})
+	var lists atomic.Int32
+	src := &mockDataSource{list: func(ctx context.Context, e endpoint, _ string, v any) error {
+		if rulesetFrom(ctx) == ruleset2014 {
+			lists.Add(1)
+			<-release
+		}
+		*v.(*[]apiReference) = []apiReference{{Index: "x-" + string(e), Name: "X"}}
+		return nil
+	}}
+	idx := newSearchIndex(src)
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() {
+		_, err := idx.load(cancelled)
+		errc <- err
+	}()
+	waiting := make(chan []searchEntry, 1)
+	go func() {
+		entries, _ := idx.load(context.Background())
+		waiting <- entries
+	}()
+
+	if _, err := idx.load(withRuleset(context.Background(), ruleset2024)); err != nil {
+		t.Fatalf("2024 search was held up by the 2014 build: %v", err)
+	}
+	cancel()
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the cancelled search to return context.Canceled, got %v", err)
+	}
+
+	close(release)
+	if entries := <-waiting; len(entries) != len(allEndpoints) {
+		t.Errorf("expected %d entries from the shared build, got %d", len(allEndpoints), len(entries))
+	}
+	if got := lists.Load(); got != int32(len(allEndpoints)) {
+		t.Errorf("expected one build listing each endpoint once, got %d lists", got)
+	}
+}
+
+func TestSearchCategoriesPerRuleset(t *testing.T) {
+	for _, tc := range []struct {
+		r       ruleset
+		name    string
+		want    endpoint
+		wantErr bool
+	}{
+		{ruleset2014, "races", races, false},
+		{ruleset2014, "species", "", true},
+		{ruleset2024, "Species", races, false},
+		{ruleset2024, "subspecies", subraces, false},
+		{ruleset2024, "races", "", true},
+	} {
+		got, err := searchCategories(tc.r, []string{tc.name})
+		if tc.wantErr {
+			if err == nil || !strings.Contains(err.Error(), string(tc.r)) {
+				t.Errorf("searchCategories(%s, %q): expected an error naming the ruleset, got %v", tc.r, tc.name, err)
+			}
+			continue
+		}
+		if err != nil || !got[tc.want] {
+			t.Errorf("searchCategories(%s, %q) = %v, %v; want %s", tc.r, tc.name, got, err, tc.want)
+		}
+	}
+}
+
+func TestRunSearchToolReportsRulesetCategory(t *testing.T) {
+	src := &mockDataSource{list: func(_ context.Context, e endpoint, _ string, v any) error {
+		if e == races {
+			*v.(*[]apiReference) = []apiReference{{Index: "elf", Name: "Elf"}}
+		}
+		return nil
+	}}
+	idx := newSearchIndex(src)
+	res, err := runSearchTool(withRuleset(context.Background(), ruleset2024), idx, searchToolInput{Query: "elf", Categories: []string{"species"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var out searchToolOutput
+	txt, _ := mcp.AsTextContent(res.Content[0])
+	if err := json.Unmarshal([]byte(txt.Text), &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Count != 1 || out.Results[0].Category != "species" {
+		t.Errorf("expected the elf under species, got %+v", out.Results)
+	}
+}
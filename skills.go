@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// skillToolInput defines the input structure for the skills tool.
+type skillToolInput struct {
+	Name         string `json:"name" mcp:"description=The index of the skill to retrieve (e.g., 'acrobatics', 'sleight-of-hand')."`
+	AbilityScore string `json:"ability_score" mcp:"description=Only list skills governed by this ability score (e.g., 'dex')."`
+}
+
+// skillListAPIResponse defines the structure for a single skill in the list response.
+type skillListAPIResponse struct {
+	Index string `json:"index"`
+	Name  string `json:"name"`
+	URL   string `json:"url"`
+}
+
+// skillDetail defines the structure for a detailed skill response.
+type skillDetail struct {
+	Index        string       `json:"index"`
+	Name         string       `json:"name"`
+	Desc         paragraphs   `json:"desc,omitempty"`
+	Description  string       `json:"description,omitempty"`
+	AbilityScore apiReference `json:"ability_score"`
+	URL          string       `json:"url"`
+}
+
+// skillToolOutput defines the output structure for the skills tool.
+// AbilityScore is the governing ability score of the requested skill, or the one used to filter the list.
+type skillToolOutput struct {
+	Count        int                    `json:"count,omitempty"`
+	Results      []skillListAPIResponse `json:"results,omitempty"`
+	Skill        *skillDetail           `json:"skill,omitempty"`
+	AbilityScore *abilityScoreDetail    `json:"ability_score,omitempty"`
+}
+
+// fetchSkillByNameResult fetches a skill by index along with its governing ability score and returns an MCP tool result.
+func fetchSkillByNameResult(
+	ctx context.Context,
+	src dataSource,
+	input skillToolInput,
+) (*mcp.CallToolResult, error) {
+	skill := &skillDetail{}
+	err := fetchByName(ctx, src, skills, input.Name, skill)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to fetch skill", err), err
+	}
+	ability := &abilityScoreDetail{}
+	if err := src.Get(ctx, abilityScores, skill.AbilityScore.Index, ability); err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to fetch governing ability score", err), err
+	}
+	output := skillToolOutput{Skill: skill, AbilityScore: ability}
+	jsonData, err := json.Marshal(output)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal skill output", err), err
+	}
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+// fetchSkillListResult fetches a list of skills, optionally only those governed by an ability score, and returns an MCP tool result.
+func fetchSkillListResult(
+	ctx context.Context,
+	src dataSource,
+	input skillToolInput,
+) (*mcp.CallToolResult, error) {
+	var output skillToolOutput
+	if input.AbilityScore != "" {
+		ability := &abilityScoreDetail{}
+		if err := fetchByName(ctx, src, abilityScores, input.AbilityScore, ability); err != nil {
+			return mcp.NewToolResultErrorFromErr("Failed to fetch ability score", err), err
+		}
+		results := make([]skillListAPIResponse, len(ability.Skills))
+		for i, s := range ability.Skills {
+			results[i] = skillListAPIResponse(s)
+		}
+		output = skillToolOutput{Count: len(results), Results: results, AbilityScore: ability}
+	} else {
+		var results []skillListAPIResponse
+		if err := fetchList(ctx, src, skills, &results, ""); err != nil {
+			return mcp.NewToolResultErrorFromErr("Failed to fetch skill list", err), err
+		}
+		output = skillToolOutput{Count: len(results), Results: results}
+	}
+	jsonData, err := json.Marshal(output)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal skill list output", err), err
+	}
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+// runSkillTool executes the core logic for the skills tool.
+func runSkillTool(ctx context.Context, src dataSource, input skillToolInput) (*mcp.CallToolResult, error) {
+	if input.Name != "" {
+		return fetchSkillByNameResult(ctx, src, input)
+	}
+	return fetchSkillListResult(ctx, src, input)
+}
+
+// handleSkillTool returns the MCP handler for the skills tool.
+func handleSkillTool(src dataSource) mcp.TypedToolHandlerFunc[skillToolInput] {
+	return func(ctx context.Context, req mcp.CallToolRequest, input skillToolInput) (*mcp.CallToolResult, error) {
+		return runSkillTool(ctx, src, input)
+	}
+}
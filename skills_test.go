@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestRunSkillTool(t *testing.T) {
+	acrobatics := skillDetail{
+		Index:        "acrobatics",
+		Name:         "Acrobatics",
+		Desc:         []string{"Your Dexterity (Acrobatics) check covers your attempt to stay on your feet."},
+		AbilityScore: apiReference{Index: "dex", Name: "DEX", URL: "/api/2014/ability-scores/dex"},
+	}
+	dex := abilityScoreDetail{
+		Index:    "dex",
+		Name:     "DEX",
+		FullName: "Dexterity",
+		Skills: []struct {
+			Index string `json:"index"`
+			Name  string `json:"name"`
+			URL   string `json:"url"`
+		}{
+			{Index: "acrobatics", Name: "Acrobatics"},
+			{Index: "sleight-of-hand", Name: "Sleight of Hand"},
+			{Index: "stealth", Name: "Stealth"},
+		},
+	}
+	getFn := func(_ context.Context, e endpoint, index string, v any) error {
+		switch {
+		case e == skills && index == "acrobatics":
+			*v.(*skillDetail) = acrobatics
+		case e == abilityScores && index == "dex":
+			*v.(*abilityScoreDetail) = dex
+		default:
+			return errNotFound
+		}
+		return nil
+	}
+	decode := func(t *testing.T, res *mcp.CallToolResult) skillToolOutput {
+		t.Helper()
+		txt, _ := mcp.AsTextContent(res.Content[0])
+		var out skillToolOutput
+		if err := json.Unmarshal([]byte(txt.Text), &out); err != nil {
+			t.Fatalf("unmarshal output: %v", err)
+		}
+		return out
+	}
+
+	t.Run("by name links governing ability score", func(t *testing.T) {
+		res, err := runSkillTool(context.Background(), &mockDataSource{get: getFn}, skillToolInput{Name: "Acrobatics"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		out := decode(t, res)
+		if out.Skill == nil || out.Skill.Name != "Acrobatics" {
+			t.Errorf("unexpected skill: %+v", out.Skill)
+		}
+		if out.AbilityScore == nil || out.AbilityScore.FullName != "Dexterity" {
+			t.Errorf("expected Dexterity ability score, got %+v", out.AbilityScore)
+		}
+	})
+
+	t.Run("by name error", func(t *testing.T) {
+		res, err := runSkillTool(context.Background(), &mockDataSource{get: getFn}, skillToolInput{Name: "cooking"})
+		if !errors.Is(err, errNotFound) || res == nil || !res.IsError {
+			t.Errorf("expected not found error result, got %+v, %v", res, err)
+		}
+	})
+
+	t.Run("list by ability score", func(t *testing.T) {
+		res, err := runSkillTool(context.Background(), &mockDataSource{get: getFn}, skillToolInput{AbilityScore: "dex"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		out := decode(t, res)
+		if out.Count != 3 || len(out.Results) != 3 || out.Results[1].Index != "sleight-of-hand" {
+			t.Errorf("expected the 3 dexterity skills, got %+v", out)
+		}
+	})
+
+	t.Run("list", func(t *testing.T) {
+		src := &mockDataSource{list: func(_ context.Context, _ endpoint, _ string, v any) error {
+			*v.(*[]skillListAPIResponse) = []skillListAPIResponse{{Index: "acrobatics"}, {Index: "athletics"}}
+			return nil
+		}}
+		res, err := runSkillTool(context.Background(), src, skillToolInput{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if out := decode(t, res); out.Count != 2 || out.AbilityScore != nil {
+			t.Errorf("expected 2 skills, got %+v", out)
+		}
+	})
+}
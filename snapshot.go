@@ -0,0 +1,464 @@
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	snapshotManifestFile = "manifest.json"
+	snapshotListFile     = "_list.json"
+)
+
+// snapshotSubresources lists the per-item sub-resources downloaded alongside each item of an endpoint.
+// They are stored as <endpoint>/<index>/<sub-resource>.json.
+var snapshotSubresources = map[endpoint][]string{
+	classes:    {"levels"},
+	subclasses: {"levels"},
+}
+
+// snapshotManifest describes the contents of an SRD snapshot.
+// Snapshots taken before rulesets were selectable have no ruleset and hold the 2014 SRD.
+type snapshotManifest struct {
+	CreatedAt time.Time        `json:"created_at"`
+	BaseURL   string           `json:"base_url"`
+	Ruleset   ruleset          `json:"ruleset,omitempty"`
+	Endpoints map[endpoint]int `json:"endpoints"`
+}
+
+// ruleset returns the ruleset the snapshot was downloaded from.
+func (m snapshotManifest) ruleset() ruleset {
+	if m.Ruleset == "" {
+		return defaultRuleset
+	}
+	return m.Ruleset
+}
+
+// snapshotWriter stores the files that make up a snapshot.
+type snapshotWriter interface {
+	WriteFile(name string, data []byte) error
+	Close() error
+}
+
+// dirSnapshotWriter writes snapshot files into a directory tree.
+type dirSnapshotWriter struct {
+	dir string
+}
+
+// WriteFile writes data to name relative to the snapshot directory, creating parent directories as needed.
+func (w *dirSnapshotWriter) WriteFile(name string, data []byte) error {
+	p := filepath.Join(w.dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(p, data, 0o644)
+}
+
+// Close is a no-op for directory snapshots.
+func (w *dirSnapshotWriter) Close() error { return nil }
+
+// zipSnapshotWriter writes snapshot files into a single zip archive.
+type zipSnapshotWriter struct {
+	mu sync.Mutex
+	f  *os.File
+	zw *zip.Writer
+}
+
+// newZipSnapshotWriter creates the archive at p and returns a writer for it.
+func newZipSnapshotWriter(p string) (*zipSnapshotWriter, error) {
+	f, err := os.Create(p)
+	if err != nil {
+		return nil, err
+	}
+	return &zipSnapshotWriter{f: f, zw: zip.NewWriter(f)}, nil
+}
+
+// WriteFile adds data to the archive as name.
+func (w *zipSnapshotWriter) WriteFile(name string, data []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	fw, err := w.zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = fw.Write(data)
+	return err
+}
+
+// Close finalizes the archive and closes the underlying file.
+func (w *zipSnapshotWriter) Close() error {
+	if err := w.zw.Close(); err != nil {
+		w.f.Close()
+		return err
+	}
+	return w.f.Close()
+}
+
+// downloadSnapshot downloads the list and every item of each endpoint from src and stores them with w.
+// Up to concurrency item requests are made at the same time. The ruleset is taken from ctx; endpoints
+// that the ruleset does not have are skipped.
+func downloadSnapshot(ctx context.Context, src *apiDataSource, w snapshotWriter, endpoints []endpoint, concurrency int) (snapshotManifest, error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	manifest := snapshotManifest{
+		CreatedAt: time.Now().UTC(),
+		BaseURL:   src.baseURL,
+		Ruleset:   rulesetFrom(ctx),
+		Endpoints: make(map[endpoint]int, len(endpoints)),
+	}
+	for _, e := range endpoints {
+		logrus.WithField("endpoint", e).Info("Downloading endpoint")
+		list, err := src.fetchAPIList(ctx, e, "")
+		if errors.Is(err, errNotFound) {
+			logrus.WithFields(logrus.Fields{"endpoint": e, "ruleset": manifest.Ruleset}).Warn("Endpoint not available in ruleset, skipping")
+			continue
+		}
+		if err != nil {
+			return snapshotManifest{}, fmt.Errorf("list %s: %w", e, err)
+		}
+		listData, err := json.Marshal(list)
+		if err != nil {
+			return snapshotManifest{}, err
+		}
+		if err := w.WriteFile(path.Join(string(e), snapshotListFile), listData); err != nil {
+			return snapshotManifest{}, err
+		}
+		var items []struct {
+			Index string `json:"index"`
+		}
+		if err := json.Unmarshal(list.Results, &items); err != nil {
+			return snapshotManifest{}, fmt.Errorf("decode %s list: %w", e, err)
+		}
+
+		err = forEachConcurrently(ctx, len(items), concurrency, func(ctx context.Context, i int) error {
+			index := items[i].Index
+			body, err := src.fetchAPIItem(ctx, e, index)
+			if err != nil {
+				return fmt.Errorf("fetch %s/%s: %w", e, index, err)
+			}
+			if err := w.WriteFile(path.Join(string(e), index+".json"), body); err != nil {
+				return err
+			}
+			for _, sub := range snapshotSubresources[e] {
+				body, err := src.fetchAPIItem(ctx, e, index+"/"+sub)
+				if err != nil {
+					return fmt.Errorf("fetch %s/%s/%s: %w", e, index, sub, err)
+				}
+				if err := w.WriteFile(path.Join(string(e), index, sub+".json"), body); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return snapshotManifest{}, err
+		}
+		manifest.Endpoints[e] = len(items)
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return snapshotManifest{}, err
+	}
+	if err := w.WriteFile(snapshotManifestFile, data); err != nil {
+		return snapshotManifest{}, err
+	}
+	return manifest, nil
+}
+
+// runSnapshotCommand implements the "snapshot" subcommand, which downloads the SRD into a directory or zip archive.
+func runSnapshotCommand(args []string) error {
+	fset := flag.NewFlagSet("snapshot", flag.ContinueOnError)
+	out := fset.String("out", "srd-snapshot", "Directory to write the snapshot to, or a path ending in .zip for a single archive.")
+	baseURL := fset.String("base-url", defaultAPIBaseURL, "Base URL of the D&D 5e API to download from.")
+	concurrency := fset.Int("concurrency", 8, "Maximum number of concurrent item requests.")
+	rulesetName := fset.String("ruleset", string(defaultRuleset), "Ruleset to download: 2014 or 2024.")
+	if err := fset.Parse(args); err != nil {
+		return err
+	}
+	r, err := parseRuleset(*rulesetName)
+	if err != nil {
+		return err
+	}
+
+	var w snapshotWriter
+	if strings.EqualFold(filepath.Ext(*out), ".zip") {
+		zw, err := newZipSnapshotWriter(*out)
+		if err != nil {
+			return err
+		}
+		w = zw
+	} else {
+		if err := os.MkdirAll(*out, 0o755); err != nil {
+			return err
+		}
+		w = &dirSnapshotWriter{dir: *out}
+	}
+
+	ctx, stop := signal.NotifyContext(withRuleset(context.Background(), r), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	src := newAPIDataSource(newAPIClient(defaultClientConfig(), http.DefaultTransport), *baseURL)
+	manifest, err := downloadSnapshot(ctx, src, w, allEndpoints, *concurrency)
+	if closeErr := w.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	logrus.WithFields(logrus.Fields{"path": *out, "ruleset": manifest.Ruleset, "created_at": manifest.CreatedAt}).Info("Snapshot complete")
+	return nil
+}
+
+// snapshotDataSource is a dataSource that answers every request from an SRD snapshot without network access.
+type snapshotDataSource struct {
+	fsys     fs.FS
+	closer   io.Closer
+	manifest snapshotManifest
+}
+
+// openSnapshot opens the snapshot at p, which may be a directory or a zip archive.
+func openSnapshot(p string) (*snapshotDataSource, error) {
+	info, err := os.Stat(p)
+	if err != nil {
+		return nil, err
+	}
+	s := &snapshotDataSource{}
+	if info.IsDir() {
+		s.fsys = os.DirFS(p)
+	} else {
+		zr, err := zip.OpenReader(p)
+		if err != nil {
+			return nil, err
+		}
+		s.fsys, s.closer = zr, zr
+	}
+	data, err := fs.ReadFile(s.fsys, snapshotManifestFile)
+	if err != nil {
+		s.Close()
+		return nil, fmt.Errorf("read snapshot manifest: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.manifest); err != nil {
+		s.Close()
+		return nil, fmt.Errorf("decode snapshot manifest: %w", err)
+	}
+	return s, nil
+}
+
+// Close releases the snapshot archive, if any.
+func (s *snapshotDataSource) Close() error {
+	if s.closer == nil {
+		return nil
+	}
+	return s.closer.Close()
+}
+
+// checkRuleset reports an error if ctx selects a different ruleset from the one in the snapshot.
+func (s *snapshotDataSource) checkRuleset(ctx context.Context) error {
+	if r := rulesetFrom(ctx); r != s.manifest.ruleset() {
+		return fmt.Errorf("the snapshot holds the %s ruleset, not %s", s.manifest.ruleset(), r)
+	}
+	return nil
+}
+
+// Get reads a single item from the snapshot and unmarshals it into v.
+func (s *snapshotDataSource) Get(ctx context.Context, e endpoint, index string, v any) error {
+	if err := s.checkRuleset(ctx); err != nil {
+		return err
+	}
+	data, err := s.readFile(string(e) + "/" + index + ".json")
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// List reads the item list of an endpoint from the snapshot and unmarshals it into v.
+// The filter is applied against the stored item details, mirroring the API's query parameters.
+func (s *snapshotDataSource) List(ctx context.Context, e endpoint, filter string, v any) error {
+	if err := s.checkRuleset(ctx); err != nil {
+		return err
+	}
+	data, err := s.readFile(string(e) + "/" + snapshotListFile)
+	if err != nil {
+		return err
+	}
+	var list listResponse
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	results := list.Results
+	if filter != "" {
+		params, err := url.ParseQuery(filter)
+		if err != nil {
+			return fmt.Errorf("invalid filter %q: %w", filter, err)
+		}
+		results, err = s.filterResults(ctx, e, list.Results, params)
+		if err != nil {
+			return err
+		}
+	}
+	if len(results) == 0 {
+		return nil
+	}
+	return json.Unmarshal(results, v)
+}
+
+// Search lists the items of an endpoint whose name contains the query.
+func (s *snapshotDataSource) Search(ctx context.Context, e endpoint, query string, v any) error {
+	return s.List(ctx, e, "name="+url.QueryEscape(query), v)
+}
+
+// filterResults returns the list entries whose item details match every parameter.
+func (s *snapshotDataSource) filterResults(ctx context.Context, e endpoint, results json.RawMessage, params url.Values) (json.RawMessage, error) {
+	var entries []json.RawMessage
+	if err := json.Unmarshal(results, &entries); err != nil {
+		return nil, err
+	}
+	matched := []json.RawMessage{}
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		var ref struct {
+			Index string `json:"index"`
+		}
+		if err := json.Unmarshal(entry, &ref); err != nil {
+			return nil, err
+		}
+		var item map[string]any
+		if err := s.Get(ctx, e, ref.Index, &item); err != nil {
+			return nil, err
+		}
+		if matchesFilter(item, params) {
+			matched = append(matched, entry)
+		}
+	}
+	return json.Marshal(matched)
+}
+
+// readFile reads name from the snapshot, reporting missing or invalid paths as errNotFound.
+// Names are not cleaned, so indexes containing ".." segments are rejected rather than resolved.
+func (s *snapshotDataSource) readFile(name string) ([]byte, error) {
+	if !fs.ValidPath(name) {
+		return nil, fmt.Errorf("%w: %s", errNotFound, name)
+	}
+	data, err := fs.ReadFile(s.fsys, name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", errNotFound, name)
+	}
+	return data, err
+}
+
+// matchesFilter reports whether an item satisfies every query parameter.
+// The "name" parameter is a case-insensitive substring match; other parameters
+// match if any of their comma-separated values equals the item's field.
+func matchesFilter(item map[string]any, params url.Values) bool {
+	for key, values := range params {
+		var wants []string
+		for _, v := range values {
+			wants = append(wants, strings.Split(v, ",")...)
+		}
+		if key == "name" {
+			name, _ := item["name"].(string)
+			if !containsFold(name, wants) {
+				return false
+			}
+			continue
+		}
+		if !fieldMatches(item[key], wants) {
+			return false
+		}
+	}
+	return true
+}
+
+// containsFold reports whether s contains any of the substrings, ignoring case.
+func containsFold(s string, subs []string) bool {
+	s = strings.ToLower(s)
+	for _, sub := range subs {
+		if strings.Contains(s, strings.ToLower(sub)) {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldMatches reports whether a decoded JSON value equals any of the wanted values.
+// Numbers are compared numerically and API references by index or name.
+func fieldMatches(field any, wants []string) bool {
+	switch f := field.(type) {
+	case float64:
+		for _, w := range wants {
+			if n, err := strconv.ParseFloat(w, 64); err == nil && n == f {
+				return true
+			}
+		}
+	case string:
+		for _, w := range wants {
+			if strings.EqualFold(f, w) {
+				return true
+			}
+		}
+	case bool:
+		for _, w := range wants {
+			if b, err := strconv.ParseBool(w); err == nil && b == f {
+				return true
+			}
+		}
+	case map[string]any:
+		index, _ := f["index"].(string)
+		name, _ := f["name"].(string)
+		for _, w := range wants {
+			if strings.EqualFold(index, w) || strings.EqualFold(name, w) {
+				return true
+			}
+		}
+	case []any:
+		for _, elem := range f {
+			if fieldMatches(elem, wants) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// snapshotDateMiddleware records the date of the snapshot every tool result was served from in the result's
+// metadata, leaving its content untouched.
+func snapshotDateMiddleware(createdAt time.Time) server.ToolHandlerMiddleware {
+	date := createdAt.UTC().Format(time.RFC3339)
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			result, err := next(ctx, req)
+			if result == nil {
+				return result, err
+			}
+			if result.Meta == nil {
+				result.Meta = map[string]any{}
+			}
+			result.Meta["snapshot_date"] = date
+			return result, err
+		}
+	}
+}
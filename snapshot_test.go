@@ -0,0 +1,256 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func newSnapshotTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	items := map[string]string{
+		"/2014/spells/fireball":      `{"index":"fireball","name":"Fireball","level":3,"school":{"index":"evocation","name":"Evocation"}}`,
+		"/2014/spells/magic-missile": `{"index":"magic-missile","name":"Magic Missile","level":1,"school":{"index":"evocation","name":"Evocation"}}`,
+		"/2014/spells/sleep":         `{"index":"sleep","name":"Sleep","level":1,"school":{"index":"enchantment","name":"Enchantment"}}`,
+		"/2014/monsters/goblin":      `{"index":"goblin","name":"Goblin","challenge_rating":0.25}`,
+		"/2014/spells": `{"count":3,"results":[` +
+			`{"index":"fireball","name":"Fireball","level":3,"url":"/api/spells/fireball"},` +
+			`{"index":"magic-missile","name":"Magic Missile","level":1,"url":"/api/spells/magic-missile"},` +
+			`{"index":"sleep","name":"Sleep","level":1,"url":"/api/spells/sleep"}]}`,
+		"/2014/monsters":               `{"count":1,"results":[{"index":"goblin","name":"Goblin","url":"/api/monsters/goblin"}]}`,
+		"/2014/classes":                `{"count":1,"results":[{"index":"paladin","name":"Paladin","url":"/api/classes/paladin"}]}`,
+		"/2014/classes/paladin":        `{"index":"paladin","name":"Paladin","hit_die":10}`,
+		"/2014/classes/paladin/levels": `[{"level":1,"prof_bonus":2,"features":[{"index":"divine-sense"}]}]`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := items[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	srv := newSnapshotTestServer(t)
+	api := newAPIDataSource(srv.Client(), srv.URL)
+	dir := t.TempDir()
+
+	for _, tc := range []struct {
+		name   string
+		path   string
+		writer func(p string) (snapshotWriter, error)
+	}{
+		{"directory", filepath.Join(dir, "snap"), func(p string) (snapshotWriter, error) { return &dirSnapshotWriter{dir: p}, nil }},
+		{"zip archive", filepath.Join(dir, "snap.zip"), func(p string) (snapshotWriter, error) { return newZipSnapshotWriter(p) }},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w, err := tc.writer(tc.path)
+			if err != nil {
+				t.Fatalf("create writer: %v", err)
+			}
+			manifest, err := downloadSnapshot(context.Background(), api, w, []endpoint{spells, monsters, classes}, 2)
+			if err != nil {
+				t.Fatalf("downloadSnapshot: %v", err)
+			}
+			if err := w.Close(); err != nil {
+				t.Fatalf("close writer: %v", err)
+			}
+			if manifest.Endpoints[spells] != 3 || manifest.Endpoints[monsters] != 1 || manifest.Endpoints[classes] != 1 {
+				t.Errorf("unexpected manifest counts: %+v", manifest.Endpoints)
+			}
+
+			snap, err := openSnapshot(tc.path)
+			if err != nil {
+				t.Fatalf("openSnapshot: %v", err)
+			}
+			defer snap.Close()
+			if !snap.manifest.CreatedAt.Equal(manifest.CreatedAt) {
+				t.Errorf("expected created_at %v, got %v", manifest.CreatedAt, snap.manifest.CreatedAt)
+			}
+
+			var spell spellAPIResponse
+			if err := snap.Get(context.Background(), spells, "fireball", &spell); err != nil {
+				t.Fatalf("Get: %v", err)
+			}
+			if spell.Name != "Fireball" {
+				t.Errorf("expected Fireball, got %q", spell.Name)
+			}
+			if err := snap.Get(context.Background(), spells, "wish", &spell); !errors.Is(err, errNotFound) {
+				t.Errorf("expected errNotFound, got %v", err)
+			}
+			if err := snap.Get(context.Background(), spells, "../manifest", &spell); !errors.Is(err, errNotFound) {
+				t.Errorf("expected errNotFound for invalid path, got %v", err)
+			}
+			var levels []classLevel
+			if err := snap.Get(context.Background(), classes, "paladin/levels", &levels); err != nil {
+				t.Fatalf("Get class levels: %v", err)
+			}
+			if len(levels) != 1 || levels[0].Features[0].Index != "divine-sense" {
+				t.Errorf("unexpected class levels: %+v", levels)
+			}
+
+			listCases := []struct {
+				filter string
+				want   int
+			}{
+				{"", 3},
+				{"level=1", 2},
+				{"level=1&school=evocation", 1},
+				{"school=Evocation", 2},
+				{"level=9", 0},
+			}
+			for _, lc := range listCases {
+				var results []spellListAPIResponse
+				if err := snap.List(context.Background(), spells, lc.filter, &results); err != nil {
+					t.Fatalf("List(%q): %v", lc.filter, err)
+				}
+				if len(results) != lc.want {
+					t.Errorf("List(%q) returned %d results, want %d", lc.filter, len(results), lc.want)
+				}
+			}
+
+			var monstersList []monsterListAPIResponse
+			if err := snap.List(context.Background(), monsters, "challenge_rating=0.25,1", &monstersList); err != nil {
+				t.Fatalf("List monsters: %v", err)
+			}
+			if len(monstersList) != 1 {
+				t.Errorf("expected 1 monster, got %d", len(monstersList))
+			}
+
+			var found []spellListAPIResponse
+			if err := snap.Search(context.Background(), spells, "missile", &found); err != nil {
+				t.Fatalf("Search: %v", err)
+			}
+			if len(found) != 1 || found[0].Index != "magic-missile" {
+				t.Errorf("unexpected search results: %+v", found)
+			}
+		})
+	}
+}
+
+func TestMatchesFilter(t *testing.T) {
+	item := map[string]any{
+		"name":             "Ancient Red Dragon",
+		"challenge_rating": 24.0,
+		"ritual":           false,
+		"classes":          []any{map[string]any{"index": "wizard", "name": "Wizard"}},
+	}
+	cases := []struct {
+		filter string
+		want   bool
+	}{
+		{"", true},
+		{"name=red", true},
+		{"name=blue", false},
+		{"challenge_rating=1,24", true},
+		{"challenge_rating=23", false},
+		{"ritual=false", true},
+		{"classes=wizard", true},
+		{"classes=cleric", false},
+		{"missing=1", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.filter, func(t *testing.T) {
+			params, err := url.ParseQuery(tc.filter)
+			if err != nil {
+				t.Fatalf("parse filter: %v", err)
+			}
+			if got := matchesFilter(item, params); got != tc.want {
+				t.Errorf("matchesFilter(%q) = %v, want %v", tc.filter, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSnapshotDateMiddleware(t *testing.T) {
+	created := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	handler := snapshotDateMiddleware(created)(func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText(`{"count":0}`), nil
+	})
+	res, err := handler(context.Background(), mcp.CallToolRequest{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Meta["snapshot_date"] != "2025-01-02T03:04:05Z" {
+		t.Errorf("unexpected snapshot_date meta: %v", res.Meta["snapshot_date"])
+	}
+	if len(res.Content) != 1 {
+		t.Fatalf("expected the content to be left alone, got %d items", len(res.Content))
+	}
+	if txt, _ := mcp.AsTextContent(res.Content[0]); txt.Text != `{"count":0}` {
+		t.Errorf("tool output was modified: %q", txt.Text)
+	}
+}
+
+func TestSnapshotListCancellation(t *testing.T) {
+	srv := newSnapshotTestServer(t)
+	api := newAPIDataSource(srv.Client(), srv.URL)
+	dir := t.TempDir()
+	if _, err := downloadSnapshot(context.Background(), api, &dirSnapshotWriter{dir: dir}, []endpoint{spells}, 1); err != nil {
+		t.Fatalf("downloadSnapshot: %v", err)
+	}
+	snap, err := openSnapshot(dir)
+	if err != nil {
+		t.Fatalf("openSnapshot: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var results []spellListAPIResponse
+	if err := snap.List(ctx, spells, "level=1", &results); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if _, err := downloadSnapshot(ctx, api, &dirSnapshotWriter{dir: t.TempDir()}, []endpoint{spells}, 1); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled from downloadSnapshot, got %v", err)
+	}
+}
+
+func TestSnapshotRuleset(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/2024/species":
+			w.Write([]byte(`{"count":1,"results":[{"index":"elf","name":"Elf","url":"/api/2024/species/elf"}]}`))
+		case "/2024/species/elf":
+			w.Write([]byte(`{"index":"elf","name":"Elf","type":"Humanoid"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	api := newAPIDataSource(srv.Client(), srv.URL)
+	dir := t.TempDir()
+	ctx := withRuleset(context.Background(), ruleset2024)
+
+	manifest, err := downloadSnapshot(ctx, api, &dirSnapshotWriter{dir: dir}, []endpoint{races, spells}, 1)
+	if err != nil {
+		t.Fatalf("downloadSnapshot: %v", err)
+	}
+	if manifest.Ruleset != ruleset2024 {
+		t.Errorf("expected manifest ruleset 2024, got %q", manifest.Ruleset)
+	}
+	if _, ok := manifest.Endpoints[spells]; ok || manifest.Endpoints[races] != 1 {
+		t.Errorf("expected only races in the manifest, got %+v", manifest.Endpoints)
+	}
+
+	snap, err := openSnapshot(dir)
+	if err != nil {
+		t.Fatalf("openSnapshot: %v", err)
+	}
+	var race raceDetail
+	if err := snap.Get(ctx, races, "elf", &race); err != nil || race.Type != "Humanoid" {
+		t.Errorf("unexpected species from snapshot: %+v, %v", race, err)
+	}
+	if err := snap.Get(context.Background(), races, "elf", &race); err == nil {
+		t.Error("expected an error when asking a 2024 snapshot for the 2014 ruleset")
+	}
+}
@@ -3,7 +3,7 @@ package main
 import (
 	"context"
 	"encoding/json"
-	"net/http"
+	"fmt"
 	"strconv"
 	"strings"
 
@@ -15,7 +15,7 @@ import (
 type spellToolInput struct {
 	Name   string `json:"name" mcp:"description=The name of the spell to retrieve."`
 	Level  int    `json:"level" mcp:"description=The level of the spell."`
-	School string `json:"school" mcp:"description=The school of magic the spell belongs to."`
+	School string `json:"school" mcp:"description=The school of magic the spell belongs to, by name, index or prefix (e.g., 'Evocation', 'evocation', 'evo')."`
 }
 
 // buildQueryString constructs a query string from the spellToolInput fields for use in API requests.
@@ -45,24 +45,25 @@ type spellToolOutput struct {
 
 // spellListAPIResponse defines the structure for a single spell in the list response.
 type spellListAPIResponse struct {
-	Index string `json:"index"`
-	Name  string `json:"name"`
-	Level int    `json:"level"`
-	URL   string `json:"url"`
+	Index  string `json:"index"`
+	Name   string `json:"name"`
+	Level  int    `json:"level"`
+	URL    string `json:"url"`
+	Source string `json:"source,omitempty"`
 }
 
 // spellAPIResponse defines the structure for a detailed spell response.
 type spellAPIResponse struct {
-	Index         string   `json:"index"`
-	Name          string   `json:"name"`
-	Desc          []string `json:"desc"`
-	Range         string   `json:"range"`
-	Components    []string `json:"components"`
-	Ritual        bool     `json:"ritual"`
-	Duration      string   `json:"duration"`
-	Concentration bool     `json:"concentration"`
-	CastingTime   string   `json:"casting_time"`
-	Level         int      `json:"level"`
+	Index         string     `json:"index"`
+	Name          string     `json:"name"`
+	Desc          paragraphs `json:"desc"`
+	Range         string     `json:"range"`
+	Components    []string   `json:"components"`
+	Ritual        bool       `json:"ritual"`
+	Duration      string     `json:"duration"`
+	Concentration bool       `json:"concentration"`
+	CastingTime   string     `json:"casting_time"`
+	Level         int        `json:"level"`
 	DC            struct {
 		DCType struct {
 			Index string `json:"index"`
@@ -92,16 +93,30 @@ type spellAPIResponse struct {
 	} `json:"subclasses"`
 	URL       string `json:"url"`
 	UpdatedAt string `json:"updated_at"`
+	Source    string `json:"source,omitempty"`
+}
+
+// validate checks the fields the spell tool relies on, for spells defined as homebrew.
+func (s *spellAPIResponse) validate() error {
+	switch {
+	case s.Level < 0 || s.Level > 9:
+		return fmt.Errorf("level must be between 0 and 9, got %d", s.Level)
+	case s.School.Index == "":
+		return fmt.Errorf("school.index is required")
+	case len(s.Desc) == 0:
+		return fmt.Errorf("desc is required")
+	}
+	return nil
 }
 
 // fetchSpellByNameResult handles the logic for fetching a spell by name and returning an MCP tool result.
 func fetchSpellByNameResult(
-	client *http.Client,
+	ctx context.Context,
+	src dataSource,
 	input spellToolInput,
-	fetchByName func(*http.Client, endpoint, string, any) error,
 ) (*mcp.CallToolResult, error) {
 	spell := &spellAPIResponse{}
-	err := fetchByName(client, spells, input.Name, spell)
+	err := fetchByName(ctx, src, spells, input.Name, spell)
 	if err != nil {
 		return mcp.NewToolResultErrorFromErr("failed to fetch name", err), err
 	}
@@ -115,12 +130,19 @@ func fetchSpellByNameResult(
 
 // fetchSpellListResult handles the logic for fetching a list of spells and returning an MCP tool result.
 func fetchSpellListResult(
-	client *http.Client,
+	ctx context.Context,
+	src dataSource,
 	input spellToolInput,
-	fetchList func(*http.Client, endpoint, any, string) error,
 ) (*mcp.CallToolResult, error) {
+	if input.School != "" {
+		school, err := normalizeMagicSchool(ctx, src, input.School)
+		if err != nil {
+			return mcp.NewToolResultErrorFromErr("Failed to resolve school filter", err), err
+		}
+		input.School = school
+	}
 	var results []spellListAPIResponse
-	err := fetchList(client, spells, &results, input.buildQueryString())
+	err := fetchList(ctx, src, spells, &results, input.buildQueryString())
 	if err != nil {
 		return mcp.NewToolResultErrorFromErr("Failed to fetch spell list", err), err
 	}
@@ -132,22 +154,19 @@ func fetchSpellListResult(
 	return mcp.NewToolResultText(string(jsonData)), nil
 }
 
-// runSpellTool executes the core logic for the spell tool, using injected fetchByName and fetchList dependencies for testability.
+// runSpellTool executes the core logic for the spell tool, using the injected data source for testability.
 // It returns an MCP tool result and a Go error if one occurs.
-func runSpellTool(
-	input spellToolInput,
-	fetchByName func(*http.Client, endpoint, string, any) error,
-	fetchList func(*http.Client, endpoint, any, string) error,
-) (*mcp.CallToolResult, error) {
-	client := http.DefaultClient
+func runSpellTool(ctx context.Context, src dataSource, input spellToolInput) (*mcp.CallToolResult, error) {
 	if input.Name != "" {
-		return fetchSpellByNameResult(client, input, fetchByName)
+		return fetchSpellByNameResult(ctx, src, input)
 	}
-	return fetchSpellListResult(client, input, fetchList)
+	return fetchSpellListResult(ctx, src, input)
 }
 
-// handleSpellTool is the MCP handler for the spell tool. It dispatches to the appropriate fetch function.
-func handleSpellTool(ctx context.Context, req mcp.CallToolRequest, input spellToolInput) (*mcp.CallToolResult, error) {
-	logrus.WithFields(logrus.Fields{"input": input}).Debug("handleSpellTool called")
-	return runSpellTool(input, fetchByName, fetchList)
+// handleSpellTool returns the MCP handler for the spell tool. It dispatches to the appropriate fetch function.
+func handleSpellTool(src dataSource) mcp.TypedToolHandlerFunc[spellToolInput] {
+	return func(ctx context.Context, req mcp.CallToolRequest, input spellToolInput) (*mcp.CallToolResult, error) {
+		logFrom(ctx).WithFields(logrus.Fields{"input": input}).Debug("handleSpellTool called")
+		return runSpellTool(ctx, src, input)
+	}
 }
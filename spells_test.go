@@ -1,9 +1,9 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
-	"net/http"
 	"os"
 	"strings"
 	"testing"
@@ -35,6 +35,25 @@ func TestSpellInput_buildQueryString(t *testing.T) {
 	}
 }
 
+func TestDecodeSpellDesc(t *testing.T) {
+	for _, file := range []string{"testdata/spell_by_name.json", "testdata/spell_by_name_2024.json"} {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", file, err)
+		}
+		var spell spellAPIResponse
+		if err := json.Unmarshal(data, &spell); err != nil {
+			t.Fatalf("failed to unmarshal %s: %v", file, err)
+		}
+		if len(spell.Desc) != 1 || !strings.HasPrefix(spell.Desc[0], "A bright streak flashes from") {
+			t.Errorf("%s: unexpected desc %q", file, spell.Desc)
+		}
+		if err := spell.validate(); err != nil {
+			t.Errorf("%s: unexpected validation error: %v", file, err)
+		}
+	}
+}
+
 func TestRunSpellTool(t *testing.T) {
 	// Load test data
 	spellData, err := os.ReadFile("testdata/spell_by_name.json")
@@ -58,8 +77,8 @@ func TestRunSpellTool(t *testing.T) {
 	cases := []struct {
 		name       string
 		input      spellToolInput
-		mockByName func(*http.Client, endpoint, string, any) error
-		mockList   func(*http.Client, endpoint, any, string) error
+		mockByName func(context.Context, endpoint, string, any) error
+		mockList   func(context.Context, endpoint, string, any) error
 		wantOutput spellToolOutput
 		wantErr    bool
 		wantErrMsg string
@@ -67,7 +86,7 @@ func TestRunSpellTool(t *testing.T) {
 		{
 			name:  "by name",
 			input: spellToolInput{Name: spell.Name},
-			mockByName: func(_ *http.Client, _ endpoint, name string, v any) error {
+			mockByName: func(_ context.Context, _ endpoint, name string, v any) error {
 				ptr, ok := v.(*spellAPIResponse)
 				if !ok {
 					return errors.New("wrong type")
@@ -75,7 +94,7 @@ func TestRunSpellTool(t *testing.T) {
 				*ptr = spell
 				return nil
 			},
-			mockList:   func(_ *http.Client, _ endpoint, v any, _ string) error { return nil },
+			mockList:   func(_ context.Context, _ endpoint, _ string, v any) error { return nil },
 			wantOutput: spellToolOutput{Spell: &spell},
 			wantErr:    false,
 			wantErrMsg: "",
@@ -83,8 +102,8 @@ func TestRunSpellTool(t *testing.T) {
 		{
 			name:       "list",
 			input:      spellToolInput{},
-			mockByName: func(_ *http.Client, _ endpoint, name string, v any) error { return nil },
-			mockList: func(_ *http.Client, _ endpoint, v any, _ string) error {
+			mockByName: func(_ context.Context, _ endpoint, name string, v any) error { return nil },
+			mockList: func(_ context.Context, _ endpoint, _ string, v any) error {
 				ptr, ok := v.(*[]spellListAPIResponse)
 				if !ok {
 					return errors.New("wrong type")
@@ -99,10 +118,10 @@ func TestRunSpellTool(t *testing.T) {
 		{
 			name:  "fetchByName error",
 			input: spellToolInput{Name: "fail"},
-			mockByName: func(_ *http.Client, _ endpoint, name string, v any) error {
+			mockByName: func(_ context.Context, _ endpoint, name string, v any) error {
 				return errors.New("fetchByName failed")
 			},
-			mockList:   func(_ *http.Client, _ endpoint, v any, _ string) error { return nil },
+			mockList:   func(_ context.Context, _ endpoint, _ string, v any) error { return nil },
 			wantOutput: spellToolOutput{},
 			wantErr:    true,
 			wantErrMsg: "fetchByName failed",
@@ -110,8 +129,8 @@ func TestRunSpellTool(t *testing.T) {
 		{
 			name:       "fetchList error",
 			input:      spellToolInput{},
-			mockByName: func(_ *http.Client, _ endpoint, name string, v any) error { return nil },
-			mockList: func(_ *http.Client, _ endpoint, v any, _ string) error {
+			mockByName: func(_ context.Context, _ endpoint, name string, v any) error { return nil },
+			mockList: func(_ context.Context, _ endpoint, _ string, v any) error {
 				return errors.New("fetchList failed")
 			},
 			wantOutput: spellToolOutput{},
@@ -121,8 +140,8 @@ func TestRunSpellTool(t *testing.T) {
 		{
 			name:       "empty list",
 			input:      spellToolInput{},
-			mockByName: func(_ *http.Client, _ endpoint, name string, v any) error { return nil },
-			mockList: func(_ *http.Client, _ endpoint, v any, _ string) error {
+			mockByName: func(_ context.Context, _ endpoint, name string, v any) error { return nil },
+			mockList: func(_ context.Context, _ endpoint, _ string, v any) error {
 				ptr, ok := v.(*[]spellListAPIResponse)
 				if !ok {
 					return errors.New("wrong type")
@@ -137,7 +156,7 @@ func TestRunSpellTool(t *testing.T) {
 		{
 			name:  "nil spell from fetchByName",
 			input: spellToolInput{Name: "empty"},
-			mockByName: func(_ *http.Client, _ endpoint, name string, v any) error {
+			mockByName: func(_ context.Context, _ endpoint, name string, v any) error {
 				ptr, ok := v.(*spellAPIResponse)
 				if !ok {
 					return errors.New("wrong type")
@@ -145,7 +164,7 @@ func TestRunSpellTool(t *testing.T) {
 				*ptr = spellAPIResponse{} // zero value
 				return nil
 			},
-			mockList:   func(_ *http.Client, _ endpoint, v any, _ string) error { return nil },
+			mockList:   func(_ context.Context, _ endpoint, _ string, v any) error { return nil },
 			wantOutput: spellToolOutput{Spell: &spellAPIResponse{}},
 			wantErr:    false,
 			wantErrMsg: "",
@@ -153,8 +172,8 @@ func TestRunSpellTool(t *testing.T) {
 		{
 			name:       "tool error result",
 			input:      spellToolInput{Name: ""},
-			mockByName: func(_ *http.Client, _ endpoint, name string, v any) error { return nil },
-			mockList: func(_ *http.Client, _ endpoint, v any, _ string) error {
+			mockByName: func(_ context.Context, _ endpoint, name string, v any) error { return nil },
+			mockList: func(_ context.Context, _ endpoint, _ string, v any) error {
 				return errors.New("test Go error")
 			},
 			wantOutput: spellToolOutput{},
@@ -165,7 +184,7 @@ func TestRunSpellTool(t *testing.T) {
 
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			res, err := runSpellTool(tc.input, tc.mockByName, tc.mockList)
+			res, err := runSpellTool(context.Background(), &mockDataSource{get: tc.mockByName, list: tc.mockList}, tc.input)
 			if tc.wantErr {
 				if err == nil {
 					t.Errorf("expected Go error, got nil")
@@ -220,11 +239,10 @@ func TestRunSpellTool(t *testing.T) {
 
 func TestFetchSpellByNameResult(t *testing.T) {
 	spell := spellAPIResponse{Name: "Magic Missile"}
-	client := &http.Client{}
 	cases := []struct {
 		name       string
 		input      spellToolInput
-		mockFn     func(*http.Client, endpoint, string, any) error
+		mockFn     func(context.Context, endpoint, string, any) error
 		wantErr    bool
 		wantErrMsg string
 		wantName   string
@@ -232,7 +250,7 @@ func TestFetchSpellByNameResult(t *testing.T) {
 		{
 			name:  "success",
 			input: spellToolInput{Name: "Magic Missile"},
-			mockFn: func(_ *http.Client, _ endpoint, name string, v any) error {
+			mockFn: func(_ context.Context, _ endpoint, name string, v any) error {
 				ptr := v.(*spellAPIResponse)
 				*ptr = spell
 				return nil
@@ -243,7 +261,7 @@ func TestFetchSpellByNameResult(t *testing.T) {
 		{
 			name:  "fetch error",
 			input: spellToolInput{Name: "fail"},
-			mockFn: func(_ *http.Client, _ endpoint, name string, v any) error {
+			mockFn: func(_ context.Context, _ endpoint, name string, v any) error {
 				return errors.New("fail fetch")
 			},
 			wantErr:    true,
@@ -252,7 +270,7 @@ func TestFetchSpellByNameResult(t *testing.T) {
 	}
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			res, err := fetchSpellByNameResult(client, tc.input, tc.mockFn)
+			res, err := fetchSpellByNameResult(context.Background(), &mockDataSource{get: tc.mockFn}, tc.input)
 			if tc.wantErr {
 				if err == nil {
 					t.Errorf("expected error, got nil")
@@ -287,12 +305,11 @@ func TestFetchSpellByNameResult(t *testing.T) {
 }
 
 func TestFetchSpellListResult(t *testing.T) {
-	client := &http.Client{}
 	spells := []spellListAPIResponse{{Name: "A"}, {Name: "B"}}
 	cases := []struct {
 		name       string
 		input      spellToolInput
-		mockFn     func(*http.Client, endpoint, any, string) error
+		mockFn     func(context.Context, endpoint, string, any) error
 		wantErr    bool
 		wantErrMsg string
 		wantCount  int
@@ -300,7 +317,7 @@ func TestFetchSpellListResult(t *testing.T) {
 		{
 			name:  "success",
 			input: spellToolInput{},
-			mockFn: func(_ *http.Client, _ endpoint, v any, _ string) error {
+			mockFn: func(_ context.Context, _ endpoint, _ string, v any) error {
 				ptr := v.(*[]spellListAPIResponse)
 				*ptr = spells
 				return nil
@@ -311,7 +328,7 @@ func TestFetchSpellListResult(t *testing.T) {
 		{
 			name:  "fetch error",
 			input: spellToolInput{},
-			mockFn: func(_ *http.Client, _ endpoint, v any, _ string) error {
+			mockFn: func(_ context.Context, _ endpoint, _ string, v any) error {
 				return errors.New("fail list")
 			},
 			wantErr:    true,
@@ -320,7 +337,7 @@ func TestFetchSpellListResult(t *testing.T) {
 	}
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			res, err := fetchSpellListResult(client, tc.input, tc.mockFn)
+			res, err := fetchSpellListResult(context.Background(), &mockDataSource{list: tc.mockFn}, tc.input)
 			if tc.wantErr {
 				if err == nil {
 					t.Errorf("expected error, got nil")
@@ -353,3 +370,43 @@ func TestFetchSpellListResult(t *testing.T) {
 		})
 	}
 }
+
+func TestFetchSpellListResult_SchoolNormalization(t *testing.T) {
+	schools := []apiReference{
+		{Index: "enchantment", Name: "Enchantment"},
+		{Index: "evocation", Name: "Evocation"},
+		{Index: "illusion", Name: "Illusion"},
+	}
+	var gotFilter string
+	src := &mockDataSource{list: func(_ context.Context, e endpoint, filter string, v any) error {
+		if e == magicSchools {
+			*v.(*[]apiReference) = schools
+			return nil
+		}
+		gotFilter = filter
+		*v.(*[]spellListAPIResponse) = []spellListAPIResponse{{Name: "Fireball"}}
+		return nil
+	}}
+
+	for _, school := range []string{"Evocation", "evocation", "evo", "EVO"} {
+		t.Run(school, func(t *testing.T) {
+			gotFilter = ""
+			res, err := fetchSpellListResult(context.Background(), src, spellToolInput{Level: 3, School: school})
+			if err != nil || res.IsError {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if gotFilter != "level=3&school=evocation" {
+				t.Errorf("expected normalized filter, got %q", gotFilter)
+			}
+		})
+	}
+
+	for _, school := range []string{"e", "necromancy"} {
+		t.Run("invalid "+school, func(t *testing.T) {
+			res, err := fetchSpellListResult(context.Background(), src, spellToolInput{School: school})
+			if !errors.Is(err, errNotFound) || res == nil || !res.IsError {
+				t.Errorf("expected error result for %q, got %+v, %v", school, res, err)
+			}
+		})
+	}
+}
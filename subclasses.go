@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// subclassToolInput defines the input structure for the subclasses tool.
+type subclassToolInput struct {
+	Name string `json:"name" mcp:"description=The index of the subclass to retrieve (e.g., 'devotion', 'champion')."`
+}
+
+// subclassListAPIResponse defines the structure for a single subclass in the list response.
+type subclassListAPIResponse struct {
+	Index string `json:"index"`
+	Name  string `json:"name"`
+	URL   string `json:"url"`
+}
+
+// subclassSpellPrerequisite is the class level or feature that unlocks a subclass spell.
+type subclassSpellPrerequisite struct {
+	Index string `json:"index"`
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	URL   string `json:"url"`
+}
+
+// subclassSpell is a spell that a subclass always has prepared or known.
+type subclassSpell struct {
+	Prerequisites []subclassSpellPrerequisite `json:"prerequisites"`
+	Spell         apiReference                `json:"spell"`
+}
+
+// subclassDetail defines the structure for a detailed subclass response.
+type subclassDetail struct {
+	Index          string          `json:"index"`
+	Name           string          `json:"name"`
+	Class          apiReference    `json:"class"`
+	SubclassFlavor string          `json:"subclass_flavor"`
+	Desc           []string        `json:"desc"`
+	SubclassLevels string          `json:"subclass_levels"`
+	Spells         []subclassSpell `json:"spells,omitempty"`
+	URL            string          `json:"url"`
+}
+
+// subclassToolOutput defines the output structure for the subclasses tool.
+type subclassToolOutput struct {
+	Count    int                       `json:"count,omitempty"`
+	Results  []subclassListAPIResponse `json:"results,omitempty"`
+	Subclass *subclassDetail           `json:"subclass,omitempty"`
+}
+
+// fetchSubclassByNameResult fetches a subclass by index and returns an MCP tool result.
+func fetchSubclassByNameResult(
+	ctx context.Context,
+	src dataSource,
+	input subclassToolInput,
+) (*mcp.CallToolResult, error) {
+	subclass := &subclassDetail{}
+	err := fetchByName(ctx, src, subclasses, input.Name, subclass)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("failed to fetch subclass", err), err
+	}
+	output := subclassToolOutput{Subclass: subclass}
+	jsonData, err := json.Marshal(output)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal subclass output", err), err
+	}
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+// fetchSubclassListResult fetches a list of subclasses and returns an MCP tool result.
+func fetchSubclassListResult(
+	ctx context.Context,
+	src dataSource,
+) (*mcp.CallToolResult, error) {
+	var results []subclassListAPIResponse
+	err := fetchList(ctx, src, subclasses, &results, "")
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to fetch subclass list", err), err
+	}
+	output := subclassToolOutput{Count: len(results), Results: results}
+	jsonData, err := json.Marshal(output)
+	if err != nil {
+		return mcp.NewToolResultErrorFromErr("Failed to marshal subclass list output", err), err
+	}
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+// runSubclassTool executes the core logic for the subclasses tool.
+func runSubclassTool(ctx context.Context, src dataSource, input subclassToolInput) (*mcp.CallToolResult, error) {
+	if input.Name != "" {
+		return fetchSubclassByNameResult(ctx, src, input)
+	}
+	return fetchSubclassListResult(ctx, src)
+}
+
+// handleSubclassTool returns the MCP handler for the subclasses tool.
+func handleSubclassTool(src dataSource) mcp.TypedToolHandlerFunc[subclassToolInput] {
+	return func(ctx context.Context, req mcp.CallToolRequest, input subclassToolInput) (*mcp.CallToolResult, error) {
+		return runSubclassTool(ctx, src, input)
+	}
+}
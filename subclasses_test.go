@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestRunSubclassTool(t *testing.T) {
+	devotion := subclassDetail{
+		Index:          "devotion",
+		Name:           "Devotion",
+		Class:          apiReference{Index: "paladin", Name: "Paladin"},
+		SubclassFlavor: "Sacred Oath",
+		Desc:           []string{"The Oath of Devotion binds a paladin to the loftiest ideals of justice."},
+		Spells: []subclassSpell{{
+			Prerequisites: []subclassSpellPrerequisite{{Index: "paladin-3", Name: "Paladin 3", Type: "level"}},
+			Spell:         apiReference{Index: "protection-from-evil-and-good"},
+		}},
+	}
+	list := []subclassListAPIResponse{{Index: "devotion", Name: "Devotion"}, {Index: "champion", Name: "Champion"}}
+
+	t.Run("by name", func(t *testing.T) {
+		src := &mockDataSource{get: func(_ context.Context, e endpoint, index string, v any) error {
+			if e != subclasses || index != "devotion" {
+				return errNotFound
+			}
+			*v.(*subclassDetail) = devotion
+			return nil
+		}}
+		res, err := runSubclassTool(context.Background(), src, subclassToolInput{Name: "devotion"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		txt, _ := mcp.AsTextContent(res.Content[0])
+		var out subclassToolOutput
+		if err := json.Unmarshal([]byte(txt.Text), &out); err != nil {
+			t.Fatalf("unmarshal output: %v", err)
+		}
+		if out.Subclass == nil || out.Subclass.SubclassFlavor != "Sacred Oath" || len(out.Subclass.Spells) != 1 {
+			t.Errorf("unexpected subclass: %+v", out.Subclass)
+		}
+	})
+
+	t.Run("by name error", func(t *testing.T) {
+		src := &mockDataSource{get: func(context.Context, endpoint, string, any) error { return errNotFound }}
+		res, err := runSubclassTool(context.Background(), src, subclassToolInput{Name: "vengeance"})
+		if !errors.Is(err, errNotFound) || res == nil || !res.IsError {
+			t.Errorf("expected not found error result, got %+v, %v", res, err)
+		}
+	})
+
+	t.Run("list", func(t *testing.T) {
+		src := &mockDataSource{list: func(_ context.Context, _ endpoint, _ string, v any) error {
+			*v.(*[]subclassListAPIResponse) = list
+			return nil
+		}}
+		res, err := runSubclassTool(context.Background(), src, subclassToolInput{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		txt, _ := mcp.AsTextContent(res.Content[0])
+		var out subclassToolOutput
+		if err := json.Unmarshal([]byte(txt.Text), &out); err != nil {
+			t.Fatalf("unmarshal output: %v", err)
+		}
+		if out.Count != 2 || len(out.Results) != 2 {
+			t.Errorf("expected 2 subclasses, got %+v", out)
+		}
+	})
+}
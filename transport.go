@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/sirupsen/logrus"
+)
+
+// serverTransport is the protocol over which MCP clients reach the server.
+type serverTransport string
+
+const (
+	// transportStdio serves a single client over standard input and output.
+	transportStdio serverTransport = "stdio"
+	// transportSSE serves remote clients over HTTP with server-sent events, at <base>/sse and <base>/message.
+	transportSSE serverTransport = "sse"
+	// transportHTTP serves remote clients over streamable HTTP at <base>/mcp.
+	transportHTTP serverTransport = "http"
+
+	// defaultListenAddr is the address the HTTP transports listen on unless -addr is given.
+	defaultListenAddr = "localhost:8080"
+	// defaultShutdownTimeout is how long open requests get to finish once the server is asked to stop.
+	defaultShutdownTimeout = 10 * time.Second
+)
+
+// allTransports lists every transport the server can serve.
+var allTransports = []serverTransport{transportStdio, transportSSE, transportHTTP}
+
+// parseTransport returns the transport named by s.
+func parseTransport(s string) (serverTransport, error) {
+	for _, t := range allTransports {
+		if strings.ToLower(strings.TrimSpace(s)) == string(t) {
+			return t, nil
+		}
+	}
+	return "", fmt.Errorf("unknown transport %q: must be one of %s, %s, %s", s, transportStdio, transportSSE, transportHTTP)
+}
+
+// transportConfig selects how the MCP server is exposed to clients.
+type transportConfig struct {
+	Transport       serverTransport
+	Addr            string         // listen address of the HTTP transports
+	BasePath        string         // URL path prefix of the HTTP transports' endpoints, e.g. /dnd
+	ShutdownTimeout time.Duration  // how long to wait for open requests on shutdown
+	Auth            *authenticator // API keys required by the HTTP transports; nil allows every request
+	Audit           *logrus.Logger // where rejected requests are recorded when Auth is set
+}
+
+// basePath returns the configured base path with a leading slash and no trailing slash, or "" for the root.
+func (c transportConfig) basePath() string {
+	p := strings.Trim(c.BasePath, "/")
+	if p == "" {
+		return ""
+	}
+	return "/" + p
+}
+
+// serve runs the MCP server s over the configured transport until ctx is cancelled or the transport fails.
+// Cancelling ctx shuts the server down gracefully and is not an error.
+func serve(ctx context.Context, s *server.MCPServer, cfg transportConfig) error {
+	if cfg.Transport == transportStdio {
+		logrus.Info("Serving MCP over stdio")
+		err := server.NewStdioServer(s).Listen(ctx, os.Stdin, os.Stdout)
+		if errors.Is(err, context.Canceled) {
+			return nil
+		}
+		return err
+	}
+	ln, err := net.Listen("tcp", cfg.Addr)
+	if err != nil {
+		return err
+	}
+	return serveHTTP(ctx, s, cfg, ln)
+}
+
+// httpTransport is the part of mcp-go's SSE and streamable HTTP servers used to stop them.
+type httpTransport interface {
+	Shutdown(ctx context.Context) error
+}
+
+// serveHTTP serves s over the SSE or streamable HTTP transport on ln. On cancellation of ctx it stops
+// accepting connections, closes open sessions and waits up to cfg.ShutdownTimeout for requests to finish
+// before closing the rest.
+func serveHTTP(ctx context.Context, s *server.MCPServer, cfg transportConfig, ln net.Listener) error {
+	srv := &http.Server{ReadHeaderTimeout: 10 * time.Second}
+	mux := http.NewServeMux()
+	var t httpTransport
+	var endpoints []string
+	switch cfg.Transport {
+	case transportSSE:
+		sse := server.NewSSEServer(s,
+			server.WithStaticBasePath(cfg.basePath()),
+			server.WithHTTPServer(srv),
+			server.WithKeepAlive(true),
+		)
+		endpoints = []string{sse.CompleteSsePath(), sse.CompleteMessagePath()}
+		mux.Handle(endpoints[0], sse.SSEHandler())
+		mux.Handle(endpoints[1], sse.MessageHandler())
+		t = sse
+	case transportHTTP:
+		endpoints = []string{path.Join("/", cfg.basePath(), "mcp")}
+		h := server.NewStreamableHTTPServer(s, server.WithStreamableHTTPServer(srv))
+		mux.Handle(endpoints[0], h)
+		t = h
+	default:
+		ln.Close()
+		return fmt.Errorf("transport %q is not served over HTTP", cfg.Transport)
+	}
+	srv.Handler = mux
+	if cfg.Auth != nil {
+		srv.Handler = cfg.Auth.middleware(cfg.Audit, mux)
+	}
+
+	errc := make(chan error, 1)
+	go func() { errc <- srv.Serve(ln) }()
+	logrus.WithFields(logrus.Fields{
+		"transport": cfg.Transport,
+		"addr":      ln.Addr().String(),
+		"endpoints": endpoints,
+	}).Info("Serving MCP over HTTP")
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	timeout := cfg.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+	logrus.WithField("timeout", timeout).Info("Shutting down HTTP transport")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := t.Shutdown(shutdownCtx); err != nil {
+		logrus.WithError(err).Warn("Open requests did not finish in time; closing their connections")
+		srv.Close()
+	}
+	if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func TestParseTransport(t *testing.T) {
+	for name, want := range map[string]serverTransport{"stdio": transportStdio, "SSE": transportSSE, " http ": transportHTTP} {
+		if got, err := parseTransport(name); err != nil || got != want {
+			t.Errorf("parseTransport(%q) = %q, %v; want %q", name, got, err, want)
+		}
+	}
+	if _, err := parseTransport("websocket"); err == nil {
+		t.Error("expected an error for an unknown transport")
+	}
+}
+
+func TestTransportBasePath(t *testing.T) {
+	for in, want := range map[string]string{"": "", "/": "", "dnd": "/dnd", "/dnd/": "/dnd", "/team/dnd": "/team/dnd"} {
+		if got := (transportConfig{BasePath: in}).basePath(); got != want {
+			t.Errorf("basePath(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+// startTestTransport serves a minimal MCP server with cfg and returns its base URL and a function that
+// stops it and returns serveHTTP's result.
+func startTestTransport(t *testing.T, cfg transportConfig) (string, func() error) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := server.NewMCPServer("test", "1.0.0", server.WithToolCapabilities(true))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- serveHTTP(ctx, s, cfg, ln)
+	}()
+	stop := func() error {
+		cancel()
+		select {
+		case err := <-done:
+			return err
+		case <-time.After(5 * time.Second):
+			t.Fatal("server did not shut down")
+			return nil
+		}
+	}
+	t.Cleanup(func() { cancel() })
+	return "http://" + ln.Addr().String(), stop
+}
+
+func TestServeHTTPStreamable(t *testing.T) {
+	base, stop := startTestTransport(t, transportConfig{Transport: transportHTTP, BasePath: "/dnd/", ShutdownTimeout: time.Second})
+	body := `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`
+	resp, err := http.Post(base+"/dnd/mcp", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("POST: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Mcp-Session-Id") == "" {
+		t.Errorf("expected a new session, got status %d, headers %v", resp.StatusCode, resp.Header)
+	}
+	if resp, err := http.Post(base+"/mcp", "application/json", strings.NewReader(body)); err == nil {
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("expected 404 outside the base path, got %d", resp.StatusCode)
+		}
+	}
+	if err := stop(); err != nil {
+		t.Errorf("unexpected shutdown error: %v", err)
+	}
+}
+
+func TestServeHTTPSSE(t *testing.T) {
+	base, stop := startTestTransport(t, transportConfig{Transport: transportSSE, BasePath: "/dnd/", ShutdownTimeout: time.Second})
+	resp, err := http.Get(base + "/dnd/sse")
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	defer resp.Body.Close()
+	sc := bufio.NewScanner(resp.Body)
+	var endpoint string
+	for sc.Scan() {
+		if data, ok := strings.CutPrefix(sc.Text(), "data: "); ok {
+			endpoint = data
+			break
+		}
+	}
+	if !strings.HasPrefix(endpoint, "/dnd/message?sessionId=") {
+		t.Errorf("expected a message endpoint under the base path, got %q", endpoint)
+	}
+	// The open event stream must not hold up shutdown.
+	if err := stop(); err != nil {
+		t.Errorf("unexpected shutdown error: %v", err)
+	}
+}
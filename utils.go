@@ -23,12 +23,12 @@ import (
 //	    Tags  []string `json:"tags" mcp:"description=Tags associated with the item."`
 //	}
 func makeToolOptions(s any) []mcp.ToolOption {
-	logrus.Debugf("Converting struct %T to MCP ToolOptions", s)
+	logrus.Tracef("Converting struct %T to MCP ToolOptions", s)
 	var opts []mcp.ToolOption
 	t := reflect.TypeOf(s)
 	for i := 0; i < t.NumField(); i++ {
 		field := t.Field(i)
-		logrus.Debugf("Processing field: %s, type: %s", field.Name, field.Type)
+		logrus.Tracef("Processing field: %s, type: %s", field.Name, field.Type)
 		jsonTag := field.Tag.Get("json")
 		mcpTag := field.Tag.Get("mcp")
 		if jsonTag == "" {
@@ -52,27 +52,30 @@ func makeToolOptions(s any) []mcp.ToolOption {
 
 // fieldToToolOption converts a struct field to an MCP ToolOption.
 // It determines the field type and creates the appropriate ToolOption based on its kind.
-// Supported types include string, number (int/float), boolean, struct, and slice.
+// Supported types include string, number (int/float), boolean, struct, slice, and map (a free-form object).
 func fieldToToolOption(name string, description string, fieldType reflect.Type) mcp.ToolOption {
-	logrus.Debugf("Converting field '%s' of type '%s' to MCP ToolOption", name, fieldType)
+	logrus.Tracef("Converting field '%s' of type '%s' to MCP ToolOption", name, fieldType)
 	for fieldType.Kind() == reflect.Ptr {
 		fieldType = fieldType.Elem()
 	}
 	switch fieldType.Kind() {
 	case reflect.String:
-		logrus.Debugf("field %s is a string", name)
+		logrus.Tracef("field %s is a string", name)
 		return mcp.WithString(name, mcp.Description(description))
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Float32, reflect.Float64:
-		logrus.Debugf("field %s is a number", name)
+		logrus.Tracef("field %s is a number", name)
 		return mcp.WithNumber(name, mcp.Description(description))
 	case reflect.Bool:
-		logrus.Debugf("field %s is a boolean", name)
+		logrus.Tracef("field %s is a boolean", name)
 		return mcp.WithBoolean(name, mcp.Description(description))
 	case reflect.Struct:
-		logrus.Debugf("field %s is a struct", name)
+		logrus.Tracef("field %s is a struct", name)
 		return mcp.WithObject(name, mcp.Description(description), mcp.Properties(makeProperties(reflect.Zero(fieldType).Interface())))
+	case reflect.Map:
+		logrus.Tracef("field %s is a map", name)
+		return mcp.WithObject(name, mcp.Description(description))
 	case reflect.Slice:
-		logrus.Debugf("field %s is a slice", name)
+		logrus.Tracef("field %s is a slice", name)
 		elemType := fieldType.Elem()
 		for elemType.Kind() == reflect.Ptr {
 			elemType = elemType.Elem()
@@ -100,7 +103,7 @@ func fieldToToolOption(name string, description string, fieldType reflect.Type)
 // It extracts the field type and description from the MCP tag, and constructs a property map accordingly.
 // If the field type is unsupported, it logs a warning and returns nil.
 func fieldToProperty(description string, field reflect.StructField) map[string]any {
-	logrus.Debugf("Converting field '%s' to property", field.Name)
+	logrus.Tracef("Converting field '%s' to property", field.Name)
 	fieldType := field.Type
 	for fieldType.Kind() == reflect.Ptr {
 		fieldType = fieldType.Elem()
@@ -110,20 +113,23 @@ func fieldToProperty(description string, field reflect.StructField) map[string]a
 	}
 	switch fieldType.Kind() {
 	case reflect.String:
-		logrus.Debugf("field %s is a string", field.Name)
+		logrus.Tracef("field %s is a string", field.Name)
 		prop["type"] = "string"
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Float32, reflect.Float64:
-		logrus.Debugf("field %s is a number", field.Name)
+		logrus.Tracef("field %s is a number", field.Name)
 		prop["type"] = "number"
 	case reflect.Bool:
-		logrus.Debugf("field %s is a boolean", field.Name)
+		logrus.Tracef("field %s is a boolean", field.Name)
 		prop["type"] = "boolean"
 	case reflect.Struct:
-		logrus.Debugf("field %s is a struct", field.Name)
+		logrus.Tracef("field %s is a struct", field.Name)
 		prop["type"] = "object"
 		prop["properties"] = makeProperties(reflect.Zero(fieldType).Interface())
+	case reflect.Map:
+		logrus.Tracef("field %s is a map", field.Name)
+		prop["type"] = "object"
 	case reflect.Slice:
-		logrus.Debugf("field %s is a slice", field.Name)
+		logrus.Tracef("field %s is a slice", field.Name)
 		elemType := fieldType.Elem()
 		for elemType.Kind() == reflect.Ptr {
 			elemType = elemType.Elem()
@@ -156,7 +162,7 @@ func fieldToProperty(description string, field reflect.StructField) map[string]a
 // If the MCP tag is not present or does not contain a description, the field is skipped.
 // If a field type is unsupported, it logs a warning and skips that field.
 func makeProperties(s any) map[string]any {
-	logrus.Debugf("Converting struct %T to properties", s)
+	logrus.Tracef("Converting struct %T to properties", s)
 	props := make(map[string]any)
 	t := reflect.TypeOf(s)
 	for i := 0; i < t.NumField(); i++ {
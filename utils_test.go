@@ -34,6 +34,10 @@ type arrayStruct struct {
 	Scores []int    `json:"scores" mcp:"description=List of scores"`
 }
 
+type mapStruct struct {
+	Fields map[string]any `json:"fields" mcp:"description=Free-form fields"`
+}
+
 func TestMakeToolOptions(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -64,6 +68,13 @@ func TestMakeToolOptions(t *testing.T) {
 				mcp.WithArray("scores", mcp.Description("List of scores"), mcp.Items(map[string]any{"type": "number"})),
 			),
 		},
+		{
+			"map field",
+			mapStruct{},
+			mcp.NewTool("test",
+				mcp.WithObject("fields", mcp.Description("Free-form fields")),
+			),
+		},
 		{
 			"field with no json tag",
 			badStructNoJSON{},
@@ -136,6 +147,13 @@ func TestToolProperties(t *testing.T) {
 				},
 			},
 		},
+		{
+			"map field",
+			mapStruct{Fields: map[string]any{"a": 1}},
+			map[string]any{
+				"fields": map[string]any{"type": "object", "description": "Free-form fields"},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {